@@ -6,16 +6,140 @@ CREATE TABLE rooms (
                        id INT AUTO_INCREMENT PRIMARY KEY,
                        name VARCHAR(255) NOT NULL,
                        capacity INT NOT NULL,
-                       available BOOLEAN DEFAULT TRUE
+                       available BOOLEAN DEFAULT TRUE,
+                       buffer_before INT NOT NULL DEFAULT 0,
+                       buffer_after INT NOT NULL DEFAULT 0,
+                       features VARCHAR(255) NOT NULL DEFAULT '',
+                       building VARCHAR(100) NOT NULL DEFAULT ''
+);
+
+CREATE TABLE users (
+                       id INT AUTO_INCREMENT PRIMARY KEY,
+                       name VARCHAR(255) NOT NULL,
+                       role VARCHAR(50) NOT NULL DEFAULT 'user',
+                       group_name VARCHAR(100) NULL
 );
 
 CREATE TABLE reservations (
                               id INT AUTO_INCREMENT PRIMARY KEY,
                               room_id INT,
+                              user_id INT,
                               date DATE NOT NULL,
                               start_time TIME NOT NULL,
                               end_time TIME NOT NULL,
-                              FOREIGN KEY (room_id) REFERENCES rooms(id)
+                              group_id VARCHAR(32) NULL,
+                              series_id VARCHAR(32) NULL,
+                              series_date DATE NULL,
+                              status VARCHAR(20) NOT NULL DEFAULT 'confirmed',
+                              checked_in_at DATETIME NULL,
+                              FOREIGN KEY (room_id) REFERENCES rooms(id),
+                              FOREIGN KEY (user_id) REFERENCES users(id),
+                              INDEX idx_reservations_group (group_id),
+                              INDEX idx_reservations_series (series_id)
+);
+
+-- Séries récurrentes : une réservation par occurrence porte series_id et series_date,
+-- la date prévue par la règle (elle reste inchangée si l'occurrence est déplacée).
+-- frequency : daily ou weekly, tous les interval_count jours / semaines.
+CREATE TABLE reservation_series (
+                                    id VARCHAR(32) PRIMARY KEY,
+                                    room_id INT NOT NULL,
+                                    user_id INT NULL,
+                                    start_date DATE NOT NULL,
+                                    start_time TIME NOT NULL,
+                                    end_time TIME NOT NULL,
+                                    frequency VARCHAR(10) NOT NULL,
+                                    interval_count INT NOT NULL DEFAULT 1,
+                                    occurrences INT NOT NULL,
+                                    FOREIGN KEY (room_id) REFERENCES rooms(id),
+                                    FOREIGN KEY (user_id) REFERENCES users(id)
+);
+
+-- Salles composées : réserver la salle parente bloque ses sous-salles et inversement.
+-- Une seule profondeur : une sous-salle ne peut pas être elle-même composée.
+CREATE TABLE room_components (
+                                 parent_id INT NOT NULL,
+                                 child_id INT NOT NULL,
+                                 PRIMARY KEY (parent_id, child_id),
+                                 FOREIGN KEY (parent_id) REFERENCES rooms(id),
+                                 FOREIGN KEY (child_id) REFERENCES rooms(id)
+);
+
+-- Horaires d'ouverture hebdomadaires (1 = lundi ... 7 = dimanche).
+-- Une salle sans aucun horaire est réservable à toute heure.
+CREATE TABLE room_opening_hours (
+                                    id INT AUTO_INCREMENT PRIMARY KEY,
+                                    room_id INT NOT NULL,
+                                    weekday TINYINT NOT NULL,
+                                    open_time TIME NOT NULL,
+                                    close_time TIME NOT NULL,
+                                    FOREIGN KEY (room_id) REFERENCES rooms(id)
+);
+
+-- Règles de réservation : min_duration, max_duration, granularity, lead_time (minutes)
+-- et horizon (jours). room_id et role à NULL = règle valable pour toutes les salles / tous les rôles.
+CREATE TABLE booking_rules (
+                               id INT AUTO_INCREMENT PRIMARY KEY,
+                               name VARCHAR(255) NOT NULL,
+                               rule_type VARCHAR(50) NOT NULL,
+                               value INT NOT NULL,
+                               room_id INT NULL,
+                               role VARCHAR(50) NULL,
+                               FOREIGN KEY (room_id) REFERENCES rooms(id)
+);
+
+-- Quotas par utilisateur ou par groupe (0 = illimité). user_id et group_name
+-- à NULL = quota appliqué à tout le monde. Seul le niveau le plus spécifique s'applique :
+-- les quotas d'un utilisateur remplacent ceux de son groupe, qui remplacent le quota global.
+CREATE TABLE quotas (
+                        id INT AUTO_INCREMENT PRIMARY KEY,
+                        user_id INT NULL,
+                        group_name VARCHAR(100) NULL,
+                        max_hours_per_week INT NOT NULL DEFAULT 0,
+                        max_concurrent INT NOT NULL DEFAULT 0,
+                        max_per_room_per_day INT NOT NULL DEFAULT 0,
+                        FOREIGN KEY (user_id) REFERENCES users(id)
+);
+
+-- Périodes de gel (sessions d'examens...) : seuls les administrateurs peuvent réserver.
+-- room_id et building à NULL = période valable pour tout le site.
+CREATE TABLE blackouts (
+                           id INT AUTO_INCREMENT PRIMARY KEY,
+                           name VARCHAR(255) NOT NULL,
+                           start_at DATETIME NOT NULL,
+                           end_at DATETIME NOT NULL,
+                           room_id INT NULL,
+                           building VARCHAR(100) NULL,
+                           FOREIGN KEY (room_id) REFERENCES rooms(id)
+);
+
+-- Flux iCalendar auxquels les applications de calendrier s'abonnent : le jeton secret
+-- fait partie de l'URL, et un flux révoqué (revoked_at renseigné) ne répond plus.
+-- Un flux porte sur une salle ou sur un utilisateur.
+CREATE TABLE calendar_feeds (
+                                token VARCHAR(64) PRIMARY KEY,
+                                room_id INT NULL,
+                                user_id INT NULL,
+                                created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+                                revoked_at DATETIME NULL,
+                                FOREIGN KEY (room_id) REFERENCES rooms(id),
+                                FOREIGN KEY (user_id) REFERENCES users(id)
+);
+
+-- Jetons de l'API JSON : chaque requête qui modifie les données est faite au nom de l'utilisateur du jeton
+CREATE TABLE api_tokens (
+                            token VARCHAR(64) PRIMARY KEY,
+                            user_id INT NOT NULL,
+                            created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+                            revoked_at DATETIME NULL,
+                            FOREIGN KEY (user_id) REFERENCES users(id)
+);
+
+-- Jours fériés et fermetures de tout le site
+CREATE TABLE closure_days (
+                              id INT AUTO_INCREMENT PRIMARY KEY,
+                              date DATE NOT NULL UNIQUE,
+                              label VARCHAR(255) NOT NULL
 );
 
 
@@ -27,3 +151,12 @@ INSERT INTO rooms(name, capacity) VALUES ('Salle Go', 100);
 INSERT INTO rooms(name, capacity) VALUES ('Salle 06', 100);
 INSERT INTO rooms(name, capacity) VALUES ('Salle 13', 100);
 
+INSERT INTO users (name, role) VALUES ('Administrateur', 'admin');
+INSERT INTO users (name, role) VALUES ('Utilisateur', 'user');
+
+INSERT INTO booking_rules (name, rule_type, value) VALUES ('Durée minimale', 'min_duration', 15);
+INSERT INTO booking_rules (name, rule_type, value) VALUES ('Créneaux de 15 minutes', 'granularity', 15);
+INSERT INTO booking_rules (name, rule_type, value) VALUES ('Réservation à un an maximum', 'horizon', 365);
+INSERT INTO booking_rules (name, rule_type, value, role) VALUES ('Durée maximale utilisateur', 'max_duration', 240, 'user');
+
+INSERT INTO quotas (max_hours_per_week, max_concurrent, max_per_room_per_day) VALUES (20, 10, 2);
@@ -0,0 +1,155 @@
+package apilogic
+
+import (
+	"Reserve-Go/exportlogic"
+	"Reserve-Go/importlogic"
+	"Reserve-Go/models"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Adresse d'écoute par défaut du serveur web
+const DefaultAddr = ":8095"
+
+// Taille maximale acceptée pour le corps d'une requête
+const maxBodySize = 1 << 20
+
+type server struct {
+	db *sql.DB
+}
+
+// Routes de l'API JSON, toutes préfixées par /api. Les routes qui modifient les données
+// exigent un jeton d'API (voir authenticated) ; la consultation reste libre.
+func NewHandler(db *sql.DB) http.Handler {
+	s := &server{db: db}
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /api/openapi.json", serveOpenAPI)
+
+	mux.HandleFunc("GET /api/rooms", s.listRooms)
+	mux.HandleFunc("POST /api/rooms", s.adminOnly(s.createRoom))
+	mux.HandleFunc("GET /api/rooms/available", s.availableRooms)
+	mux.HandleFunc("GET /api/rooms/{id}", s.getRoom)
+	mux.HandleFunc("PATCH /api/rooms/{id}", s.adminOnly(s.updateRoom))
+	mux.HandleFunc("DELETE /api/rooms/{id}", s.adminOnly(s.deleteRoom))
+
+	mux.HandleFunc("GET /api/reservations", s.authenticated(s.listReservations))
+	mux.HandleFunc("POST /api/reservations", s.authenticated(s.createReservation))
+	mux.HandleFunc("GET /api/reservations/{id}", s.authenticated(s.getReservation))
+	mux.HandleFunc("PATCH /api/reservations/{id}", s.authenticated(s.updateReservation))
+	mux.HandleFunc("DELETE /api/reservations/{id}", s.authenticated(s.cancelReservation))
+
+	mux.HandleFunc("GET /api/exports/reservations.csv", s.authenticated(s.exportCSV))
+	mux.HandleFunc("GET /api/exports/reservations.json", s.authenticated(s.exportJSON))
+	mux.HandleFunc("GET /api/exports/reservations.ndjson", s.authenticated(s.exportNDJSON))
+	mux.HandleFunc("GET /api/exports/reservations.xlsx", s.authenticated(s.exportXLSX))
+	mux.HandleFunc("GET /api/exports/reservations.ics", s.authenticated(s.exportICS))
+	mux.HandleFunc("GET /api/exports/rooms.csv", s.exportRooms("text/csv; charset=utf-8", "rooms.csv", exportlogic.WriteRoomsCSV))
+	mux.HandleFunc("GET /api/exports/rooms.json", s.exportRooms("application/json; charset=utf-8", "rooms.json", exportlogic.WriteRoomsJSON))
+	mux.HandleFunc("GET /api/feeds/{token}/reservations.ics", s.feed)
+	mux.HandleFunc("GET /api/reports/schedule.html", s.adminOnly(s.scheduleReport("html", "text/html; charset=utf-8")))
+	mux.HandleFunc("GET /api/reports/schedule.pdf", s.adminOnly(s.scheduleReport("pdf", "application/pdf")))
+
+	mux.HandleFunc("POST /api/imports/reservations.ics", s.authenticated(s.importICS))
+	mux.HandleFunc("POST /api/imports/reservations.csv", s.adminOnly(s.importBulk(importlogic.ImportReservations, "csv")))
+	mux.HandleFunc("POST /api/imports/reservations.json", s.adminOnly(s.importBulk(importlogic.ImportReservations, "json")))
+	mux.HandleFunc("POST /api/imports/rooms.csv", s.adminOnly(s.importBulk(importlogic.ImportRooms, "csv")))
+	mux.HandleFunc("POST /api/imports/rooms.json", s.adminOnly(s.importBulk(importlogic.ImportRooms, "json")))
+
+	mux.HandleFunc("/api/", func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, models.Errorf(models.ErrNotFound, "route inconnue : %s %s", r.Method, r.URL.Path))
+	})
+	return mux
+}
+
+// Démarre le serveur sur addr et ne rend la main qu'en cas d'erreur
+func ListenAndServe(addr string, handler http.Handler) error {
+	server := &http.Server{
+		Addr:              addr,
+		Handler:           handler,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	return server.ListenAndServe()
+}
+
+// Code HTTP correspondant à la catégorie d'une erreur métier
+func StatusCode(err error) int {
+	switch {
+	case errors.Is(err, models.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, models.ErrInvalid):
+		return http.StatusBadRequest
+	case errors.Is(err, models.ErrConflict):
+		return http.StatusConflict
+	case errors.Is(err, models.ErrForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, models.ErrUnauthorized):
+		return http.StatusUnauthorized
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+type errorBody struct {
+	Error string `json:"error"`
+}
+
+// Répond {"error": "..."} ; le détail des erreurs internes reste dans les logs
+func writeError(w http.ResponseWriter, err error) {
+	status := StatusCode(err)
+	message := err.Error()
+	if status == http.StatusInternalServerError {
+		log.Printf("Erreur API : %v", err)
+		message = "erreur interne du serveur"
+	}
+	if status == http.StatusUnauthorized {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="Reserve-Go"`)
+	}
+	writeJSON(w, status, errorBody{Error: message})
+}
+
+func writeJSON(w http.ResponseWriter, status int, value interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "    ")
+	if err := encoder.Encode(value); err != nil {
+		log.Printf("Erreur lors de l'écriture de la réponse : %v", err)
+	}
+}
+
+// Lit le corps JSON de la requête ; les champs inconnus sont refusés
+func readJSON(w http.ResponseWriter, r *http.Request, value interface{}) error {
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodySize))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(value); err != nil {
+		return models.Errorf(models.ErrInvalid, "corps JSON invalide : %v", err)
+	}
+	return nil
+}
+
+func pathID(r *http.Request) (int, error) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		return 0, models.Errorf(models.ErrInvalid, "identifiant invalide : %q", r.PathValue("id"))
+	}
+	return id, nil
+}
+
+// Paramètre entier facultatif de l'URL (0 s'il est absent)
+func queryInt(r *http.Request, name string) (int, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, models.Errorf(models.ErrInvalid, "paramètre %s invalide : %q", name, value)
+	}
+	return n, nil
+}
@@ -0,0 +1,378 @@
+package apilogic
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+const (
+	adminToken   = "jeton-admin"
+	userToken    = "jeton-utilisateur"
+	revokedToken = "jeton-revoque"
+)
+
+// Données de la base factice : l'administrateur 1, l'utilisateur 2, la salle 1 et la
+// réservation 1 de l'administrateur
+func fixture(query string, args []driver.Value) ([]string, [][]driver.Value) {
+	switch {
+	case strings.Contains(query, "FROM api_tokens WHERE token = ?"):
+		tokens := map[driver.Value][]driver.Value{
+			adminToken:   {adminToken, int64(1), "2024-01-01 08:00:00", ""},
+			userToken:    {userToken, int64(2), "2024-01-01 08:00:00", ""},
+			revokedToken: {revokedToken, int64(2), "2024-01-01 08:00:00", "2024-02-01 08:00:00"},
+		}
+		return oneRow([]string{"token", "user_id", "created_at", "revoked_at"}, tokens[args[0]])
+	case strings.Contains(query, "FROM users WHERE id = ?"):
+		users := map[driver.Value][]driver.Value{
+			int64(1): {int64(1), "Administrateur", "admin", ""},
+			int64(2): {int64(2), "Utilisateur", "user", ""},
+		}
+		return oneRow([]string{"id", "name", "role", "group_name"}, users[args[0]])
+	case strings.Contains(query, "FROM users ORDER BY id"):
+		return []string{"id", "name", "role", "group_name"}, [][]driver.Value{
+			{int64(1), "Administrateur", "admin", ""},
+			{int64(2), "Utilisateur", "user", ""},
+		}
+	case strings.HasPrefix(query, "SELECT id, name, capacity"):
+		if len(args) > 0 && args[0] != int64(1) {
+			return nil, nil
+		}
+		room := []driver.Value{int64(1), "Salle 101", int64(20), int64(0), int64(0), "projecteur", true, "Sciences"}
+		return oneRow([]string{"id", "name", "capacity", "buffer_before", "buffer_after", "features", "available", "building"}, room)
+	case strings.HasPrefix(query, "SELECT id, room_id, COALESCE(user_id, 0)") && strings.Contains(query, "WHERE id = ?"):
+		if args[0] != int64(1) {
+			return nil, nil
+		}
+		reservation := []driver.Value{int64(1), int64(1), int64(1), "2024-05-13", "09:00:00", "10:00:00", "", "", "confirmed"}
+		return oneRow([]string{"id", "room_id", "user_id", "date", "start_time", "end_time", "group_id", "series_id", "status"}, reservation)
+	case strings.HasPrefix(query, "SELECT EXISTS(SELECT 1 FROM rooms WHERE id = ?)"):
+		return oneRow([]string{"exists"}, []driver.Value{args[0] == int64(1)})
+	case strings.HasPrefix(query, "SELECT COUNT("):
+		return oneRow([]string{"count"}, []driver.Value{int64(0)})
+	}
+	return nil, nil
+}
+
+func oneRow(columns []string, row []driver.Value) ([]string, [][]driver.Value) {
+	if row == nil {
+		return nil, nil
+	}
+	return columns, [][]driver.Value{row}
+}
+
+func newTestHandler() http.Handler {
+	return NewHandler(openFakeDB(fixture))
+}
+
+func request(t *testing.T, handler http.Handler, method, path, token, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+// Partie de openapi.json utile aux tests
+type specDocument struct {
+	Paths      map[string]map[string]json.RawMessage
+	Components struct {
+		Schemas map[string]specSchema
+	}
+}
+
+type specOperation struct {
+	OperationID string                     `json:"operationId"`
+	Security    []map[string][]string      `json:"security"`
+	Responses   map[string]json.RawMessage `json:"responses"`
+}
+
+type specSchema struct {
+	Required   []string
+	Properties map[string]json.RawMessage
+}
+
+var httpMethods = []string{"get", "post", "patch", "delete"}
+
+func loadSpec(t *testing.T) specDocument {
+	t.Helper()
+	var spec specDocument
+	if err := json.Unmarshal(openAPISpec, &spec); err != nil {
+		t.Fatalf("openapi.json invalide : %v", err)
+	}
+	return spec
+}
+
+// Opérations de la description, indexées par « MÉTHODE chemin »
+func (spec specDocument) operations(t *testing.T) map[string]specOperation {
+	t.Helper()
+	operations := map[string]specOperation{}
+	for path, item := range spec.Paths {
+		for _, method := range httpMethods {
+			raw, ok := item[method]
+			if !ok {
+				continue
+			}
+			var op specOperation
+			if err := json.Unmarshal(raw, &op); err != nil {
+				t.Fatalf("%s %s : opération invalide : %v", method, path, err)
+			}
+			operations[strings.ToUpper(method)+" "+path] = op
+		}
+	}
+	return operations
+}
+
+// Vérifie que value n'a que des champs décrits par le schéma et qu'il a les champs obligatoires
+func checkSchema(t *testing.T, spec specDocument, name string, value map[string]interface{}) {
+	t.Helper()
+	schema, ok := spec.Components.Schemas[name]
+	if !ok {
+		t.Fatalf("schéma %s absent de la description", name)
+	}
+	for field := range value {
+		if _, ok := schema.Properties[field]; !ok {
+			t.Errorf("%s : champ %s non décrit", name, field)
+		}
+	}
+	for _, field := range schema.Required {
+		if _, ok := value[field]; !ok {
+			t.Errorf("%s : champ obligatoire %s absent", name, field)
+		}
+	}
+}
+
+// Une réponse d'erreur est du JSON de la forme {"error": "..."}
+func checkErrorBody(t *testing.T, label string, rec *httptest.ResponseRecorder) {
+	t.Helper()
+	if contentType := rec.Header().Get("Content-Type"); !strings.HasPrefix(contentType, "application/json") {
+		t.Errorf("%s : Content-Type %q pour une erreur", label, contentType)
+	}
+	var body map[string]interface{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Errorf("%s : corps d'erreur illisible : %v (%s)", label, err, rec.Body)
+		return
+	}
+	if message, ok := body["error"].(string); !ok || message == "" {
+		t.Errorf("%s : champ error absent du corps %s", label, rec.Body)
+	}
+}
+
+func TestOpenAPIServed(t *testing.T) {
+	rec := request(t, newTestHandler(), http.MethodGet, "/api/openapi.json", "", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("statut %d, attendu 200", rec.Code)
+	}
+	if !bytes.Equal(rec.Body.Bytes(), openAPISpec) {
+		t.Error("le document servi diffère de openapi.json")
+	}
+	loadSpec(t)
+}
+
+// Chaque route de NewHandler est décrite, et chaque opération décrite est routée
+func TestRoutesMatchSpec(t *testing.T) {
+	source, err := os.ReadFile("apilogic.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	routes := map[string]bool{}
+	for _, match := range regexp.MustCompile(`mux\.HandleFunc\("([A-Z]+ /api/[^"]*)"`).FindAllStringSubmatch(string(source), -1) {
+		routes[match[1]] = true
+	}
+	operations := loadSpec(t).operations(t)
+	for route := range routes {
+		if _, ok := operations[route]; !ok {
+			t.Errorf("route %s absente de openapi.json", route)
+		}
+	}
+	for route := range operations {
+		if !routes[route] {
+			t.Errorf("opération %s décrite mais absente de NewHandler", route)
+		}
+	}
+}
+
+// Chaque opération ne renvoie que des codes déclarés, avec ou sans jeton, et les routes
+// protégées déclarent un schéma de sécurité et refusent les requêtes sans jeton
+func TestStatusCodesDeclared(t *testing.T) {
+	handler := newTestHandler()
+	operations := loadSpec(t).operations(t)
+	keys := make([]string, 0, len(operations))
+	for key := range operations {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	placeholders := strings.NewReplacer("{id}", "1", "{token}", "inconnu")
+	for _, key := range keys {
+		op := operations[key]
+		method, path, _ := strings.Cut(key, " ")
+		path = placeholders.Replace(path)
+		tokens := []string{""}
+		if op.Security != nil {
+			tokens = []string{"", userToken, adminToken}
+		}
+		for _, token := range tokens {
+			body := ""
+			if method == http.MethodPost || method == http.MethodPatch {
+				body = "{}"
+			}
+			rec := request(t, handler, method, path, token, body)
+			label := key + " sans jeton"
+			if token != "" {
+				label = key + " avec " + token
+			}
+			if _, ok := op.Responses[strconv.Itoa(rec.Code)]; !ok {
+				t.Errorf("%s : statut %d non déclaré (%s)", label, rec.Code, strings.TrimSpace(rec.Body.String()))
+			}
+			if rec.Code >= 400 && rec.Code != http.StatusNotModified {
+				checkErrorBody(t, label, rec)
+			}
+			if op.Security != nil && token == "" && rec.Code != http.StatusUnauthorized {
+				t.Errorf("%s : statut %d sans jeton, attendu 401", label, rec.Code)
+			}
+		}
+		if strings.Contains(key, "/imports/") || method != http.MethodGet {
+			if op.Security == nil {
+				t.Errorf("%s : route qui modifie les données sans schéma de sécurité", key)
+			}
+		}
+	}
+}
+
+func TestAuthentication(t *testing.T) {
+	handler := newTestHandler()
+	tests := []struct {
+		name, method, path, token, body string
+		status                          int
+	}{
+		{"sans jeton", http.MethodPost, "/api/rooms", "", `{"Name": "Salle 102"}`, http.StatusUnauthorized},
+		{"jeton inconnu", http.MethodPost, "/api/rooms", "inconnu", `{"Name": "Salle 102"}`, http.StatusUnauthorized},
+		{"jeton révoqué", http.MethodDelete, "/api/reservations/1", revokedToken, "", http.StatusUnauthorized},
+		{"salle créée par un utilisateur", http.MethodPost, "/api/rooms", userToken, `{"Name": "Salle 102"}`, http.StatusForbidden},
+		{"import en masse par un utilisateur", http.MethodPost, "/api/imports/rooms.csv", userToken, "", http.StatusForbidden},
+		{"réservation au nom d'un autre", http.MethodPost, "/api/reservations", userToken,
+			`{"UserID": 1, "RoomID": 1, "Date": "2024-05-13", "StartTime": "09:00", "EndTime": "10:00"}`, http.StatusForbidden},
+		{"annulation de la réservation d'un autre", http.MethodDelete, "/api/reservations/1", userToken, "", http.StatusForbidden},
+		{"import iCalendar au nom d'un autre", http.MethodPost, "/api/imports/reservations.ics?user=1", userToken, "", http.StatusForbidden},
+		{"réservation d'un autre consultée", http.MethodGet, "/api/reservations/1", userToken, "", http.StatusForbidden},
+		{"réservations d'un autre exportées", http.MethodGet, "/api/exports/reservations.csv?user=1", userToken, "", http.StatusForbidden},
+		{"liste des réservations sans jeton", http.MethodGet, "/api/reservations", "", "", http.StatusUnauthorized},
+		{"affiche demandée par un utilisateur", http.MethodGet, "/api/reports/schedule.html?room=1", userToken, "", http.StatusForbidden},
+		{"consultation sans jeton", http.MethodGet, "/api/rooms", "", "", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := request(t, handler, tt.method, tt.path, tt.token, tt.body)
+			if rec.Code != tt.status {
+				t.Fatalf("statut %d, attendu %d (%s)", rec.Code, tt.status, strings.TrimSpace(rec.Body.String()))
+			}
+			if tt.status >= 400 {
+				checkErrorBody(t, tt.name, rec)
+			}
+			if tt.status == http.StatusUnauthorized && rec.Header().Get("WWW-Authenticate") == "" {
+				t.Error("en-tête WWW-Authenticate absent d'une réponse 401")
+			}
+		})
+	}
+}
+
+// Le cookie de session de l'interface web vaut jeton pour les lectures seulement : un
+// formulaire d'un autre site ne peut pas s'en servir pour modifier les données
+func TestSessionCookie(t *testing.T) {
+	handler := newTestHandler()
+	tests := []struct {
+		method, path string
+		status       int
+	}{
+		{http.MethodGet, "/api/reservations/1", http.StatusOK},
+		{http.MethodDelete, "/api/reservations/1", http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, tt.path, nil)
+		req.AddCookie(&http.Cookie{Name: SessionCookie, Value: adminToken})
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != tt.status {
+			t.Errorf("%s %s avec le cookie de session : statut %d, attendu %d", tt.method, tt.path, rec.Code, tt.status)
+		}
+	}
+}
+
+func TestErrorStatuses(t *testing.T) {
+	handler := newTestHandler()
+	tests := []struct {
+		name, method, path, token string
+		status                    int
+	}{
+		{"identifiant invalide", http.MethodGet, "/api/rooms/abc", "", http.StatusBadRequest},
+		{"salle inconnue", http.MethodGet, "/api/rooms/2", "", http.StatusNotFound},
+		{"réservation inconnue", http.MethodGet, "/api/reservations/2", adminToken, http.StatusNotFound},
+		{"paramètres manquants", http.MethodGet, "/api/rooms/available?date=2024-05-13", "", http.StatusBadRequest},
+		{"date invalide", http.MethodGet, "/api/reservations?date=13/05/2024", userToken, http.StatusBadRequest},
+		{"route inconnue", http.MethodGet, "/api/inconnue", "", http.StatusNotFound},
+		{"flux inconnu", http.MethodGet, "/api/feeds/inconnu/reservations.ics", "", http.StatusNotFound},
+		{"calendrier d'une salle hors flux", http.MethodGet, "/api/exports/reservations.ics?room=1", userToken, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := request(t, handler, tt.method, tt.path, tt.token, "")
+			if rec.Code != tt.status {
+				t.Fatalf("statut %d, attendu %d (%s)", rec.Code, tt.status, strings.TrimSpace(rec.Body.String()))
+			}
+			checkErrorBody(t, tt.name, rec)
+		})
+	}
+}
+
+// Les corps renvoyés n'ont que des champs décrits par les schémas Room et Reservation
+func TestBodiesMatchSchemas(t *testing.T) {
+	handler := newTestHandler()
+	spec := loadSpec(t)
+
+	rec := request(t, handler, http.MethodGet, "/api/rooms", "", "")
+	var rooms []map[string]interface{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &rooms); err != nil || len(rooms) != 1 {
+		t.Fatalf("liste des salles illisible : %v (%s)", err, rec.Body)
+	}
+	checkSchema(t, spec, "Room", rooms[0])
+
+	rec = request(t, handler, http.MethodPost, "/api/rooms", adminToken, `{"Name": "Salle 101", "Capacity": 20}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("création de salle : statut %d (%s)", rec.Code, strings.TrimSpace(rec.Body.String()))
+	}
+	if location := rec.Header().Get("Location"); location != "/api/rooms/1" {
+		t.Errorf("Location = %q, attendu /api/rooms/1", location)
+	}
+	var room map[string]interface{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &room); err != nil {
+		t.Fatalf("salle créée illisible : %v", err)
+	}
+	checkSchema(t, spec, "Room", room)
+
+	rec = request(t, handler, http.MethodGet, "/api/reservations/1", adminToken, "")
+	var reservation map[string]interface{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &reservation); err != nil {
+		t.Fatalf("réservation illisible : %v (%s)", err, rec.Body)
+	}
+	checkSchema(t, spec, "Reservation", reservation)
+	if reservation["StartTime"] != "09:00:00" {
+		t.Errorf("StartTime = %v, attendu 09:00:00", reservation["StartTime"])
+	}
+
+	rec = request(t, handler, http.MethodPost, "/api/rooms", adminToken, `{"Nom": "Salle 101"}`)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("champ inconnu : statut %d, attendu 400", rec.Code)
+	}
+}
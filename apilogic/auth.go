@@ -0,0 +1,52 @@
+package apilogic
+
+import (
+	"Reserve-Go/models"
+	"Reserve-Go/userlogic"
+	"net/http"
+	"strings"
+)
+
+// Cookie de session de l'interface web (le jeton d'API saisi à la connexion), accepté à la
+// place de l'en-tête Authorization pour les lectures : les liens d'export et d'affiches
+// des pages fonctionnent ainsi, sans qu'un autre site puisse faire modifier des données
+const SessionCookie = "reserve_session"
+
+// Handler d'une route protégée, appelé avec l'utilisateur du jeton
+type authHandlerFunc func(w http.ResponseWriter, r *http.Request, user *models.User)
+
+// Exige l'en-tête Authorization: Bearer <jeton> avec un jeton d'API actif (401 sinon), ou
+// pour GET le cookie de session de l'interface web
+func (s *server) authenticated(next authHandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token := bearerToken(r)
+		if cookie, err := r.Cookie(SessionCookie); token == "" && err == nil && (r.Method == http.MethodGet || r.Method == http.MethodHead) {
+			token = cookie.Value
+		}
+		user, err := userlogic.Authenticate(s.db, token)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		next(w, r, user)
+	}
+}
+
+// Comme authenticated, pour les routes réservées aux administrateurs (403 sinon)
+func (s *server) adminOnly(next http.HandlerFunc) http.HandlerFunc {
+	return s.authenticated(func(w http.ResponseWriter, r *http.Request, user *models.User) {
+		if err := userlogic.CheckAdmin(user); err != nil {
+			writeError(w, err)
+			return
+		}
+		next(w, r)
+	})
+}
+
+func bearerToken(r *http.Request) string {
+	scheme, token, found := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") {
+		return ""
+	}
+	return strings.TrimSpace(token)
+}
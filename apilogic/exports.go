@@ -0,0 +1,142 @@
+package apilogic
+
+import (
+	"Reserve-Go/exportlogic"
+	"Reserve-Go/icallogic"
+	"Reserve-Go/models"
+	"Reserve-Go/reservationlogic"
+	"Reserve-Go/roomlogic"
+	"Reserve-Go/userlogic"
+	"bytes"
+	"io"
+	"log"
+	"net/http"
+	"time"
+)
+
+func (s *server) exportCSV(w http.ResponseWriter, r *http.Request, user *models.User) {
+	s.export(w, r, user, "csv", "text/csv; charset=utf-8")
+}
+
+func (s *server) exportJSON(w http.ResponseWriter, r *http.Request, user *models.User) {
+	s.export(w, r, user, "json", "application/json; charset=utf-8")
+}
+
+func (s *server) exportNDJSON(w http.ResponseWriter, r *http.Request, user *models.User) {
+	s.export(w, r, user, "ndjson", "application/x-ndjson; charset=utf-8")
+}
+
+func (s *server) exportXLSX(w http.ResponseWriter, r *http.Request, user *models.User) {
+	s.export(w, r, user, "xlsx", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+}
+
+// Critères d'export lus dans l'URL : from, to, room (IDs séparés par des virgules), user, status et sort ;
+// un utilisateur n'exporte que ses propres réservations
+func queryFilter(r *http.Request, user *models.User) (reservationlogic.Filter, error) {
+	query := r.URL.Query()
+	filter := reservationlogic.Filter{
+		From:     query.Get("from"),
+		To:       query.Get("to"),
+		Statuses: reservationlogic.ParseStatuses(query.Get("status")),
+		Sort:     query.Get("sort"),
+	}
+	var err error
+	if filter.RoomIDs, err = reservationlogic.ParseRoomIDs(query.Get("room")); err != nil {
+		return filter, err
+	}
+	if filter.UserID, err = queryInt(r, "user"); err != nil {
+		return filter, err
+	}
+	if filter.UserID, err = userlogic.ReadableUserID(user, filter.UserID); err != nil {
+		return filter, err
+	}
+	return filter, filter.Validate()
+}
+
+// GET /api/exports/rooms.csv et rooms.json : fichiers relus par l'import des salles
+func (s *server) exportRooms(contentType, filename string, write func(io.Writer, []models.Room) error) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		rooms, err := roomlogic.GetRooms(s.db)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		var buf bytes.Buffer
+		if err := write(&buf, rooms); err != nil {
+			writeError(w, err)
+			return
+		}
+		w.Header().Set("Content-Type", contentType)
+		w.Header().Set("Content-Disposition", `attachment; filename="`+filename+`"`)
+		if _, err := buf.WriteTo(w); err != nil {
+			log.Printf("Erreur lors de l'envoi de l'export : %v", err)
+		}
+	}
+}
+
+// Même contenu que les fichiers générés par le menu, proposé en téléchargement,
+// limité aux réservations qui vérifient les critères de l'URL et aux colonnes demandées
+// (?columns=RoomName,Date,... ou all). Les réservations sont
+// envoyées au fil de la lecture : une erreur ne peut plus être signalée en JSON une fois
+// l'envoi commencé, la connexion est alors interrompue pour que le client ne prenne pas
+// l'export tronqué pour un export complet.
+func (s *server) export(w http.ResponseWriter, r *http.Request, user *models.User, format, contentType string) {
+	filter, err := queryFilter(r, user)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	columns, err := exportlogic.ParseColumns(r.URL.Query().Get("columns"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", `attachment; filename="reservations.`+format+`"`)
+	out := &trackingWriter{w: w}
+	if err := exportlogic.StreamReservations(s.db, out, format, filter, columns); err != nil {
+		if !out.written {
+			w.Header().Del("Content-Disposition")
+			writeError(w, err)
+			return
+		}
+		log.Printf("Erreur lors de l'envoi de l'export : %v", err)
+		panic(http.ErrAbortHandler)
+	}
+}
+
+// Retient si des octets ont déjà été envoyés
+type trackingWriter struct {
+	w       io.Writer
+	written bool
+}
+
+func (t *trackingWriter) Write(p []byte) (int, error) {
+	t.written = true
+	return t.w.Write(p)
+}
+
+// GET /api/exports/reservations.ics : toutes les réservations pour un administrateur, les
+// siennes pour un utilisateur. Le calendrier d'une salle ou d'un autre utilisateur n'est
+// servi que par son flux à jeton (GET /api/feeds/{token}/...).
+func (s *server) exportICS(w http.ResponseWriter, r *http.Request, user *models.User) {
+	if query := r.URL.Query(); query.Has("room") || query.Has("user") {
+		writeError(w, models.NewError(models.ErrInvalid, "le calendrier d'une salle ou d'un utilisateur est servi par un flux d'abonnement (reserve feeds create)"))
+		return
+	}
+	userID, err := userlogic.ReadableUserID(user, 0)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	var buf bytes.Buffer
+	if err := icallogic.Export(s.db, &buf, icallogic.Scope{UserID: userID}, time.Now()); err != nil {
+		writeError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.Header().Set("Content-Disposition", `attachment; filename="reservations.ics"`)
+	if _, err := buf.WriteTo(w); err != nil {
+		log.Printf("Erreur lors de l'envoi de l'export : %v", err)
+	}
+}
@@ -0,0 +1,58 @@
+package apilogic
+
+import (
+	"Reserve-Go/icallogic"
+	"bytes"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// GET /api/feeds/{token}/reservations.ics : calendrier d'abonnement, interrogé
+// régulièrement par les applications de calendrier. Le jeton tient lieu d'authentification ;
+// If-None-Match évite de renvoyer un calendrier inchangé.
+func (s *server) feed(w http.ResponseWriter, r *http.Request) {
+	feed, err := icallogic.GetActiveFeed(s.db, r.PathValue("token"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	calendar, err := icallogic.BuildCalendar(s.db, icallogic.FeedScope(feed))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	etag, err := icallogic.ETag(calendar)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "private, no-cache")
+	if etagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := icallogic.Write(&buf, calendar, time.Now()); err != nil {
+		writeError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	if _, err := buf.WriteTo(w); err != nil {
+		log.Printf("Erreur lors de l'envoi du flux : %v", err)
+	}
+}
+
+// If-None-Match peut lister plusieurs versions, éventuellement faibles (W/), ou valoir *
+func etagMatches(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == etag || candidate == "*" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,69 @@
+package apilogic
+
+import (
+	"Reserve-Go/importlogic"
+	"Reserve-Go/models"
+	"Reserve-Go/userlogic"
+	"bytes"
+	"database/sql"
+	"io"
+	"net/http"
+)
+
+// Taille maximale d'un fichier importé
+const maxImportSize = 10 << 20
+
+// POST /api/imports/reservations.ics[?user=ID][&commit=true] : le corps est le fichier .ics,
+// importé au nom de l'utilisateur du jeton (ou de user pour un administrateur) ;
+// sans commit=true, la réponse est une simulation et rien n'est créé
+func (s *server) importICS(w http.ResponseWriter, r *http.Request, authUser *models.User) {
+	userID, err := queryInt(r, "user")
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	user, err := userlogic.ActingUser(s.db, authUser, userID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	content, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxImportSize))
+	if err != nil {
+		writeError(w, models.Errorf(models.ErrInvalid, "fichier illisible : %v", err))
+		return
+	}
+	report, err := importlogic.ImportICS(s.db, user, bytes.NewReader(content), r.URL.Query().Get("commit") == "true")
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	status := http.StatusOK
+	if report.Committed && report.Created > 0 {
+		status = http.StatusCreated
+	}
+	writeJSON(w, status, report)
+}
+
+// POST /api/imports/{rooms|reservations}.{csv|json}?mode=dry-run|partial|all-or-nothing :
+// le corps est le fichier, au format des exports ; simulation par défaut
+func (s *server) importBulk(importFile func(*sql.DB, io.Reader, string, string) (*importlogic.BulkReport, error), format string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		mode := r.URL.Query().Get("mode")
+		if mode == "" {
+			mode = importlogic.ModeDryRun
+		}
+		report, err := importFile(s.db, http.MaxBytesReader(w, r.Body, maxImportSize), format, mode)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		status := http.StatusOK
+		switch {
+		case report.Aborted:
+			status = http.StatusConflict
+		case report.Committed && report.Created > 0:
+			status = http.StatusCreated
+		}
+		writeJSON(w, status, report)
+	}
+}
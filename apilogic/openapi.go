@@ -0,0 +1,20 @@
+package apilogic
+
+import (
+	_ "embed"
+	"log"
+	"net/http"
+)
+
+// Description OpenAPI 3 de toutes les routes ; à tenir à jour avec NewHandler
+//
+//go:embed openapi.json
+var openAPISpec []byte
+
+// GET /api/openapi.json
+func serveOpenAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	if _, err := w.Write(openAPISpec); err != nil {
+		log.Printf("Erreur lors de l'envoi de la spécification : %v", err)
+	}
+}
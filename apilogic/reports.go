@@ -0,0 +1,42 @@
+package apilogic
+
+import (
+	"Reserve-Go/reportlogic"
+	"Reserve-Go/utils"
+	"bytes"
+	"log"
+	"net/http"
+	"time"
+)
+
+// GET /api/reports/schedule.html et schedule.pdf : affiches de la semaine d'une salle
+// (?room=ID) ou livret d'un bâtiment (?building=NOM), pour la semaine de ?date= (aujourd'hui
+// par défaut). Le document est ouvert dans le navigateur, prêt à imprimer.
+func (s *server) scheduleReport(format, contentType string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		roomID, err := queryInt(r, "room")
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		date := r.URL.Query().Get("date")
+		if date == "" {
+			date = time.Now().Format(utils.DateLayout)
+		}
+		booklet, err := reportlogic.Build(s.db, roomID, r.URL.Query().Get("building"), date)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		var buf bytes.Buffer
+		if err := reportlogic.Write(&buf, booklet, format, time.Now()); err != nil {
+			writeError(w, err)
+			return
+		}
+		w.Header().Set("Content-Type", contentType)
+		w.Header().Set("Content-Disposition", `inline; filename="schedule-`+booklet.From+`.`+format+`"`)
+		if _, err := buf.WriteTo(w); err != nil {
+			log.Printf("Erreur lors de l'envoi de l'affiche : %v", err)
+		}
+	}
+}
@@ -0,0 +1,231 @@
+package apilogic
+
+import (
+	"Reserve-Go/models"
+	"Reserve-Go/reservationlogic"
+	"Reserve-Go/userlogic"
+	"Reserve-Go/utils"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+)
+
+// Corps de POST /api/reservations : RoomIDs pour une réservation multi-salles,
+// Repeat pour une série récurrente dans une salle. UserID vaut par défaut
+// l'utilisateur du jeton ; seul un administrateur peut réserver pour un autre.
+type reservationRequest struct {
+	UserID    int
+	RoomID    int
+	RoomIDs   []int
+	Date      string
+	StartTime string
+	EndTime   string
+	Repeat    *repeatRequest
+}
+
+type repeatRequest struct {
+	Frequency string
+	Interval  int
+	Count     int
+}
+
+// Réponse 409 lorsqu'une salle est déjà prise, avec les propositions du menu
+type conflictBody struct {
+	Error        string                         `json:"error"`
+	Alternatives *reservationlogic.Alternatives `json:"alternatives,omitempty"`
+}
+
+// GET /api/reservations, filtrable par ?room=ID et ?date=AAAA-MM-JJ ; un utilisateur
+// ne reçoit que ses propres réservations
+func (s *server) listReservations(w http.ResponseWriter, r *http.Request, user *models.User) {
+	roomID, err := queryInt(r, "room")
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	filter := reservationlogic.Filter{From: r.URL.Query().Get("date"), To: r.URL.Query().Get("date")}
+	if roomID != 0 {
+		filter.RoomIDs = []int{roomID}
+	}
+	if filter.UserID, err = userlogic.ReadableUserID(user, 0); err != nil {
+		writeError(w, err)
+		return
+	}
+	reservations, err := reservationlogic.FindReservations(s.db, filter)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, nonNil(reservations))
+}
+
+func (s *server) getReservation(w http.ResponseWriter, r *http.Request, user *models.User) {
+	id, err := pathID(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if err := reservationlogic.CheckOwner(s.db, user, id); err != nil {
+		writeError(w, err)
+		return
+	}
+	reservation, err := reservationlogic.GetReservation(s.db, id)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, reservation)
+}
+
+func (s *server) createReservation(w http.ResponseWriter, r *http.Request, authUser *models.User) {
+	var req reservationRequest
+	if err := readJSON(w, r, &req); err != nil {
+		writeError(w, err)
+		return
+	}
+	roomIDs := req.RoomIDs
+	if req.RoomID != 0 {
+		roomIDs = append([]int{req.RoomID}, roomIDs...)
+	}
+	if len(roomIDs) == 0 || req.Date == "" || req.StartTime == "" || req.EndTime == "" {
+		writeError(w, models.NewError(models.ErrInvalid, "RoomID (ou RoomIDs), Date, StartTime et EndTime sont obligatoires"))
+		return
+	}
+	startTime, err := utils.NormalizeClock(req.StartTime)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	endTime, err := utils.NormalizeClock(req.EndTime)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	user, err := userlogic.ActingUser(s.db, authUser, req.UserID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if req.Repeat != nil {
+		if len(roomIDs) > 1 {
+			writeError(w, models.NewError(models.ErrInvalid, "une série ne porte que sur une salle"))
+			return
+		}
+		series := models.Series{RoomID: roomIDs[0], StartDate: req.Date, StartTime: startTime, EndTime: endTime,
+			Frequency: req.Repeat.Frequency, Interval: req.Repeat.Interval, Count: req.Repeat.Count}
+		seriesID, err := reservationlogic.BookSeries(s.db, user, series)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		occurrences, err := reservationlogic.GetSeriesOccurrences(s.db, seriesID)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		reservations := make([]models.Reservation, len(occurrences))
+		for i, o := range occurrences {
+			reservations[i] = o.Reservation
+		}
+		writeJSON(w, http.StatusCreated, reservations)
+		return
+	}
+
+	if len(roomIDs) > 1 {
+		groupID, err := reservationlogic.BookGroup(s.db, user, roomIDs, req.Date, startTime, endTime)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		group, err := reservationlogic.GetReservationsByGroup(s.db, groupID)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusCreated, group)
+		return
+	}
+
+	id, err := reservationlogic.BookReservation(s.db, user, roomIDs[0], req.Date, startTime, endTime)
+	if errors.Is(err, reservationlogic.ErrRoomUnavailable) {
+		s.writeConflict(w, err, roomIDs[0], req.Date, startTime, endTime)
+		return
+	}
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	reservation, err := reservationlogic.GetReservation(s.db, id)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	w.Header().Set("Location", fmt.Sprintf("/api/reservations/%d", id))
+	writeJSON(w, http.StatusCreated, reservation)
+}
+
+func (s *server) writeConflict(w http.ResponseWriter, err error, roomID int, date, startTime, endTime string) {
+	body := conflictBody{Error: err.Error()}
+	alternatives, altErr := reservationlogic.SuggestAlternatives(s.db, roomID, date, startTime, endTime)
+	if altErr != nil {
+		log.Printf("Erreur lors de la recherche d'alternatives : %v", altErr)
+	} else {
+		body.Alternatives = alternatives
+	}
+	writeJSON(w, http.StatusConflict, body)
+}
+
+// PATCH /api/reservations/{id} : déplacement vers une autre salle ou un autre créneau
+func (s *server) updateReservation(w http.ResponseWriter, r *http.Request, user *models.User) {
+	id, err := pathID(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if err := reservationlogic.CheckOwner(s.db, user, id); err != nil {
+		writeError(w, err)
+		return
+	}
+	var update reservationlogic.ReservationUpdate
+	if err := readJSON(w, r, &update); err != nil {
+		writeError(w, err)
+		return
+	}
+	for _, clock := range []*string{update.StartTime, update.EndTime} {
+		if clock == nil {
+			continue
+		}
+		if *clock, err = utils.NormalizeClock(*clock); err != nil {
+			writeError(w, err)
+			return
+		}
+	}
+	reservation, err := reservationlogic.UpdateReservation(s.db, id, update)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, reservation)
+}
+
+// DELETE /api/reservations/{id}, avec ?group=true pour annuler tout le groupe multi-salles ;
+// la réservation est conservée avec le statut cancelled
+func (s *server) cancelReservation(w http.ResponseWriter, r *http.Request, user *models.User) {
+	id, err := pathID(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if err := reservationlogic.CheckOwner(s.db, user, id); err != nil {
+		writeError(w, err)
+		return
+	}
+	withGroup := r.URL.Query().Get("group") == "true"
+	if _, err := reservationlogic.CancelReservation(s.db, id, withGroup); err != nil {
+		writeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,123 @@
+package apilogic
+
+import (
+	"Reserve-Go/models"
+	"Reserve-Go/roomlogic"
+	"Reserve-Go/utils"
+	"fmt"
+	"net/http"
+)
+
+func (s *server) listRooms(w http.ResponseWriter, r *http.Request) {
+	rooms, err := roomlogic.GetRooms(s.db)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, nonNil(rooms))
+}
+
+func (s *server) getRoom(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	room, err := roomlogic.GetRoom(s.db, id)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, room)
+}
+
+func (s *server) createRoom(w http.ResponseWriter, r *http.Request) {
+	var room models.Room
+	if err := readJSON(w, r, &room); err != nil {
+		writeError(w, err)
+		return
+	}
+	id, err := roomlogic.InsertRoom(s.db, room)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	created, err := roomlogic.GetRoom(s.db, id)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	w.Header().Set("Location", fmt.Sprintf("/api/rooms/%d", id))
+	writeJSON(w, http.StatusCreated, created)
+}
+
+func (s *server) updateRoom(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	var update roomlogic.RoomUpdate
+	if err := readJSON(w, r, &update); err != nil {
+		writeError(w, err)
+		return
+	}
+	room, err := roomlogic.UpdateRoom(s.db, id, update)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, room)
+}
+
+func (s *server) deleteRoom(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if err := roomlogic.DeleteRoom(s.db, id); err != nil {
+		writeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Équivalent de l'option « salles disponibles à un temps donné » du menu :
+// GET /api/rooms/available?date=AAAA-MM-JJ&start=HH:MM&end=HH:MM
+func (s *server) availableRooms(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	date, start, end := query.Get("date"), query.Get("start"), query.Get("end")
+	if date == "" || start == "" || end == "" {
+		writeError(w, models.NewError(models.ErrInvalid, "les paramètres date, start et end sont obligatoires"))
+		return
+	}
+	if _, err := utils.ParseDate(date); err != nil {
+		writeError(w, err)
+		return
+	}
+	startTime, err := utils.NormalizeClock(start)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	endTime, err := utils.NormalizeClock(end)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	rooms, err := roomlogic.GetAvailableRooms(s.db, date, startTime, endTime)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, nonNil(rooms))
+}
+
+// Une liste vide est renvoyée sous la forme [] plutôt que null
+func nonNil[T any](items []T) []T {
+	if items == nil {
+		return []T{}
+	}
+	return items
+}
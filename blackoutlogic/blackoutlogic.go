@@ -0,0 +1,201 @@
+package blackoutlogic
+
+import (
+	"Reserve-Go/models"
+	"Reserve-Go/roomlogic"
+	"Reserve-Go/userlogic"
+	"Reserve-Go/utils"
+	"database/sql"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+)
+
+var ErrBlackout = models.NewError(models.ErrForbidden, "période de gel : seuls les administrateurs peuvent réserver")
+
+const blackoutColumns = "id, name, start_at, end_at, COALESCE(room_id, 0), COALESCE(building, '')"
+
+// Refuse la réservation si elle tombe dans une période de gel de la salle (ou d'une de
+// ses sous-salles ou salles parentes, qu'elle occupe aussi), de son bâtiment ou du site,
+// sauf pour les administrateurs
+func CheckBlackout(db *sql.DB, user *models.User, roomID int, date, startTime, endTime string) error {
+	if user.Role == userlogic.RoleAdmin {
+		return nil
+	}
+	room, err := roomlogic.GetRoom(db, roomID)
+	if err != nil {
+		return err
+	}
+	start, err := utils.ParseClock(startTime)
+	if err != nil {
+		return err
+	}
+	end, err := utils.ParseClock(endTime)
+	if err != nil {
+		return err
+	}
+	components, err := roomlogic.GetComponents(db)
+	if err != nil {
+		return err
+	}
+	related := roomlogic.RelatedRoomIDs(components, room.ID)
+	query := "SELECT " + blackoutColumns + ` FROM blackouts
+              WHERE start_at < ? AND end_at > ?
+                AND ((room_id IS NULL AND building IS NULL) OR room_id IN (?` + strings.Repeat(", ?", len(related)-1) + `) OR building = ?)
+              ORDER BY start_at`
+	args := []interface{}{date + " " + utils.FormatClock(end), date + " " + utils.FormatClock(start)}
+	for _, id := range related {
+		args = append(args, id)
+	}
+	args = append(args, room.Building)
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return err
+	}
+	blackouts, err := scanBlackouts(rows)
+	if err != nil {
+		return err
+	}
+	if len(blackouts) > 0 {
+		b := blackouts[0]
+		return fmt.Errorf("%w (« %s » du %s au %s)", ErrBlackout, b.Name, b.StartAt, b.EndAt)
+	}
+	return nil
+}
+
+// Indique si la période de gel couvre (même partiellement) le créneau dans la salle, dont
+// related sont les salles liées (roomlogic.RelatedRoomIDs) ; même critère que CheckBlackout,
+// pour filtrer des créneaux sans interroger la base
+func Blocks(b models.Blackout, room models.Room, related []int, date, startTime, endTime string) bool {
+	if (b.RoomID != 0 && !slices.Contains(related, b.RoomID)) || (b.Building != "" && b.Building != room.Building) {
+		return false
+	}
+	start, err := utils.NormalizeClock(startTime)
+	if err != nil {
+		return false
+	}
+	end, err := utils.NormalizeClock(endTime)
+	if err != nil {
+		return false
+	}
+	return b.StartAt < date+" "+end && b.EndAt > date+" "+start
+}
+
+func GetBlackouts(db *sql.DB) ([]models.Blackout, error) {
+	rows, err := db.Query("SELECT " + blackoutColumns + " FROM blackouts ORDER BY start_at")
+	if err != nil {
+		return nil, err
+	}
+	return scanBlackouts(rows)
+}
+
+func scanBlackouts(rows *sql.Rows) ([]models.Blackout, error) {
+	var blackouts []models.Blackout
+	defer func(rows *sql.Rows) {
+		rowErr := rows.Close()
+		if rowErr != nil {
+			log.Printf("Erreur: %v", rowErr)
+		}
+	}(rows)
+
+	for rows.Next() {
+		var b models.Blackout
+		if err := rows.Scan(&b.ID, &b.Name, &b.StartAt, &b.EndAt, &b.RoomID, &b.Building); err != nil {
+			return nil, err
+		}
+		blackouts = append(blackouts, b)
+	}
+	return blackouts, rows.Err()
+}
+
+func InsertBlackout(db *sql.DB, b models.Blackout) (int, error) {
+	start, err := utils.ParseDateTime(b.StartAt)
+	if err != nil {
+		return 0, err
+	}
+	end, err := utils.ParseDateTime(b.EndAt)
+	if err != nil {
+		return 0, err
+	}
+	if !end.After(start) {
+		return 0, models.NewError(models.ErrInvalid, "la fin de la période doit être après son début")
+	}
+	if b.RoomID != 0 && !roomlogic.IsRoomExists(db, b.RoomID) {
+		return 0, models.Errorf(models.ErrNotFound, "la salle avec l'ID %d n'existe pas", b.RoomID)
+	}
+	var roomID sql.NullInt64
+	if b.RoomID != 0 {
+		roomID = sql.NullInt64{Int64: int64(b.RoomID), Valid: true}
+	}
+	var building sql.NullString
+	if b.Building != "" {
+		building = sql.NullString{String: b.Building, Valid: true}
+	}
+	query := "INSERT INTO blackouts (name, start_at, end_at, room_id, building) VALUES (?, ?, ?, ?, ?)"
+	result, err := db.Exec(query, b.Name, start.Format(utils.DateTimeLayout), end.Format(utils.DateTimeLayout), roomID, building)
+	if err != nil {
+		return 0, err
+	}
+	id, err := result.LastInsertId()
+	return int(id), err
+}
+
+func DeleteBlackout(db *sql.DB, id int) error {
+	_, err := db.Exec("DELETE FROM blackouts WHERE id = ?", id)
+	return err
+}
+
+// Réservations actives qui tombent (même partiellement) dans la période de gel ; pour une
+// salle, celles de ses sous-salles et salles parentes comptent aussi
+func GetReservationsInBlackout(db *sql.DB, b models.Blackout) ([]models.Reservation, error) {
+	query := `SELECT r.id, r.room_id, COALESCE(r.user_id, 0), r.date, r.start_time, r.end_time, COALESCE(r.group_id, ''), COALESCE(r.series_id, ''), r.status
+              FROM reservations r
+              JOIN rooms c ON c.id = r.room_id
+              WHERE r.status IN ('confirmed', 'checked_in')
+                AND TIMESTAMP(r.date, r.start_time) < ? AND TIMESTAMP(r.date, r.end_time) > ?`
+	args := []interface{}{b.EndAt, b.StartAt}
+	switch {
+	case b.RoomID != 0:
+		query += ` AND (r.room_id = ?
+                    OR r.room_id IN (SELECT child_id FROM room_components WHERE parent_id = ?)
+                    OR r.room_id IN (SELECT parent_id FROM room_components WHERE child_id = ?))`
+		args = append(args, b.RoomID, b.RoomID, b.RoomID)
+	case b.Building != "":
+		query += " AND c.building = ?"
+		args = append(args, b.Building)
+	}
+	query += " ORDER BY r.date, r.start_time"
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func(rows *sql.Rows) {
+		rowErr := rows.Close()
+		if rowErr != nil {
+			log.Printf("Erreur: %v", rowErr)
+		}
+	}(rows)
+
+	var reservations []models.Reservation
+	for rows.Next() {
+		var r models.Reservation
+		if err := rows.Scan(&r.ID, &r.RoomID, &r.UserID, &r.Date, &r.StartTime, &r.EndTime, &r.GroupID, &r.SeriesID, &r.Status); err != nil {
+			return nil, err
+		}
+		reservations = append(reservations, r)
+	}
+	return reservations, rows.Err()
+}
+
+func DescribeScope(b models.Blackout) string {
+	switch {
+	case b.RoomID != 0:
+		return fmt.Sprintf("salle %d", b.RoomID)
+	case b.Building != "":
+		return "bâtiment " + b.Building
+	default:
+		return "tout le site"
+	}
+}
@@ -0,0 +1,185 @@
+package calendarlogic
+
+import (
+	"Reserve-Go/models"
+	"Reserve-Go/utils"
+	"database/sql"
+	"fmt"
+	"log"
+)
+
+var (
+	ErrClosureDay          = models.NewError(models.ErrConflict, "le site est fermé ce jour-là")
+	ErrOutsideOpeningHours = models.NewError(models.ErrConflict, "le créneau est en dehors des horaires d'ouverture de la salle")
+	ErrInvalidSlot         = models.NewError(models.ErrInvalid, "créneau invalide")
+)
+
+// Vérifie qu'un créneau respecte les jours de fermeture et les horaires d'ouverture de la salle
+func CheckBookable(db *sql.DB, roomID int, date, startTime, endTime string) error {
+	day, err := utils.ParseDate(date)
+	if err != nil {
+		return fmt.Errorf("%w : %v", ErrInvalidSlot, err)
+	}
+	start, err := utils.ParseClock(startTime)
+	if err != nil {
+		return fmt.Errorf("%w : %v", ErrInvalidSlot, err)
+	}
+	end, err := utils.ParseClock(endTime)
+	if err != nil {
+		return fmt.Errorf("%w : %v", ErrInvalidSlot, err)
+	}
+	if end <= start {
+		return fmt.Errorf("%w : l'heure de fin doit être après l'heure de début", ErrInvalidSlot)
+	}
+
+	closure, err := GetClosureDay(db, date)
+	if err != nil {
+		return err
+	}
+	if closure != nil {
+		return fmt.Errorf("%w (%s)", ErrClosureDay, closure.Label)
+	}
+
+	hours, err := GetOpeningHours(db, roomID)
+	if err != nil {
+		return err
+	}
+	if !FitsOpeningHours(hours, utils.IsoWeekday(day), start, end) {
+		return fmt.Errorf("%w (%s %s-%s)", ErrOutsideOpeningHours, utils.WeekdayName(utils.IsoWeekday(day)),
+			utils.FormatClock(start), utils.FormatClock(end))
+	}
+	return nil
+}
+
+// Une salle sans horaire configuré est ouverte en permanence ; sinon le créneau
+// doit tenir entièrement dans une des plages du jour.
+func FitsOpeningHours(hours []models.OpeningHours, weekday, start, end int) bool {
+	if len(hours) == 0 {
+		return true
+	}
+	for _, h := range hours {
+		if h.Weekday != weekday {
+			continue
+		}
+		open, openErr := utils.ParseClock(h.OpenTime)
+		closeAt, closeErr := utils.ParseClock(h.CloseTime)
+		if openErr != nil || closeErr != nil {
+			continue
+		}
+		if open <= start && end <= closeAt {
+			return true
+		}
+	}
+	return false
+}
+
+func GetOpeningHours(db *sql.DB, roomID int) ([]models.OpeningHours, error) {
+	var hours []models.OpeningHours
+	query := "SELECT id, room_id, weekday, open_time, close_time FROM room_opening_hours WHERE room_id = ? ORDER BY weekday, open_time"
+	rows, err := db.Query(query, roomID)
+	if err != nil {
+		return nil, err
+	}
+	defer func(rows *sql.Rows) {
+		rowErr := rows.Close()
+		if rowErr != nil {
+			log.Printf("Erreur: %v", rowErr)
+		}
+	}(rows)
+
+	for rows.Next() {
+		var h models.OpeningHours
+		if err := rows.Scan(&h.ID, &h.RoomID, &h.Weekday, &h.OpenTime, &h.CloseTime); err != nil {
+			return nil, err
+		}
+		hours = append(hours, h)
+	}
+	return hours, rows.Err()
+}
+
+// Remplace les plages d'un jour par une seule plage d'ouverture
+func SetOpeningHours(db *sql.DB, roomID, weekday int, openTime, closeTime string) error {
+	if weekday < 1 || weekday > 7 {
+		return models.Errorf(models.ErrInvalid, "jour invalide : %d", weekday)
+	}
+	open, err := utils.ParseClock(openTime)
+	if err != nil {
+		return err
+	}
+	closeAt, err := utils.ParseClock(closeTime)
+	if err != nil {
+		return err
+	}
+	if closeAt <= open {
+		return models.NewError(models.ErrInvalid, "l'heure de fermeture doit être après l'heure d'ouverture")
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM room_opening_hours WHERE room_id = ? AND weekday = ?", roomID, weekday); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	query := "INSERT INTO room_opening_hours (room_id, weekday, open_time, close_time) VALUES (?, ?, ?, ?)"
+	if _, err := tx.Exec(query, roomID, weekday, utils.FormatClock(open), utils.FormatClock(closeAt)); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// Supprime les plages d'un jour (la salle devient fermée ce jour-là si d'autres jours sont configurés)
+func ClearOpeningHours(db *sql.DB, roomID, weekday int) error {
+	_, err := db.Exec("DELETE FROM room_opening_hours WHERE room_id = ? AND weekday = ?", roomID, weekday)
+	return err
+}
+
+func GetClosureDay(db *sql.DB, date string) (*models.ClosureDay, error) {
+	var c models.ClosureDay
+	err := db.QueryRow("SELECT id, date, label FROM closure_days WHERE date = ?", date).Scan(&c.ID, &c.Date, &c.Label)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+func GetClosureDays(db *sql.DB) ([]models.ClosureDay, error) {
+	var closures []models.ClosureDay
+	rows, err := db.Query("SELECT id, date, label FROM closure_days ORDER BY date")
+	if err != nil {
+		return nil, err
+	}
+	defer func(rows *sql.Rows) {
+		rowErr := rows.Close()
+		if rowErr != nil {
+			log.Printf("Erreur: %v", rowErr)
+		}
+	}(rows)
+
+	for rows.Next() {
+		var c models.ClosureDay
+		if err := rows.Scan(&c.ID, &c.Date, &c.Label); err != nil {
+			return nil, err
+		}
+		closures = append(closures, c)
+	}
+	return closures, rows.Err()
+}
+
+func AddClosureDay(db *sql.DB, date, label string) error {
+	if _, err := utils.ParseDate(date); err != nil {
+		return err
+	}
+	_, err := db.Exec("INSERT INTO closure_days (date, label) VALUES (?, ?)", date, label)
+	return err
+}
+
+func DeleteClosureDay(db *sql.DB, date string) error {
+	_, err := db.Exec("DELETE FROM closure_days WHERE date = ?", date)
+	return err
+}
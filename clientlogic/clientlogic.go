@@ -0,0 +1,435 @@
+package clientlogic
+
+import (
+	"Reserve-Go/importlogic"
+	"Reserve-Go/models"
+	"Reserve-Go/reservationlogic"
+	"Reserve-Go/roomlogic"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Client de l'API JSON décrite par /api/openapi.json
+type Client struct {
+	BaseURL    string
+	HTTPClient *http.Client
+	// Jeton d'API (reserve tokens create) : obligatoire pour les requêtes qui modifient les données
+	Token string
+}
+
+// baseURL est l'adresse du serveur, par exemple "http://localhost:8095"
+func New(baseURL string) *Client {
+	return &Client{BaseURL: strings.TrimRight(baseURL, "/"), HTTPClient: http.DefaultClient}
+}
+
+// Erreur renvoyée par le serveur ; errors.Is la rattache aux catégories de models
+// (models.ErrNotFound, ErrInvalid, ErrConflict, ErrForbidden, ErrUnauthorized)
+type APIError struct {
+	StatusCode int
+	Message    string
+	// Propositions du serveur lorsqu'une salle est déjà prise (409 sur une réservation simple)
+	Alternatives *reservationlogic.Alternatives
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("%s (HTTP %d)", e.Message, e.StatusCode)
+}
+
+func (e *APIError) Unwrap() error {
+	switch e.StatusCode {
+	case http.StatusNotFound:
+		return models.ErrNotFound
+	case http.StatusBadRequest:
+		return models.ErrInvalid
+	case http.StatusConflict:
+		return models.ErrConflict
+	case http.StatusForbidden:
+		return models.ErrForbidden
+	case http.StatusUnauthorized:
+		return models.ErrUnauthorized
+	}
+	return nil
+}
+
+// Corps de POST /api/reservations ; UserID vaut 0 pour réserver au nom du jeton
+type ReservationRequest struct {
+	UserID    int   `json:",omitempty"`
+	RoomID    int   `json:",omitempty"`
+	RoomIDs   []int `json:",omitempty"`
+	Date      string
+	StartTime string
+	EndTime   string
+	Repeat    *Repeat `json:",omitempty"`
+}
+
+// Répétition d'une série : Frequency vaut "daily" ou "weekly", Interval 0 équivaut à 1
+type Repeat struct {
+	Frequency string
+	Interval  int
+	Count     int
+}
+
+func (c *Client) ListRooms(ctx context.Context) ([]models.Room, error) {
+	var rooms []models.Room
+	return rooms, c.do(ctx, http.MethodGet, "/api/rooms", nil, &rooms)
+}
+
+func (c *Client) GetRoom(ctx context.Context, id int) (*models.Room, error) {
+	var room models.Room
+	if err := c.do(ctx, http.MethodGet, "/api/rooms/"+strconv.Itoa(id), nil, &room); err != nil {
+		return nil, err
+	}
+	return &room, nil
+}
+
+func (c *Client) CreateRoom(ctx context.Context, room models.Room) (*models.Room, error) {
+	var created models.Room
+	if err := c.do(ctx, http.MethodPost, "/api/rooms", room, &created); err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+// Seuls les champs non nil de update sont modifiés
+func (c *Client) UpdateRoom(ctx context.Context, id int, update roomlogic.RoomUpdate) (*models.Room, error) {
+	var room models.Room
+	if err := c.do(ctx, http.MethodPatch, "/api/rooms/"+strconv.Itoa(id), update, &room); err != nil {
+		return nil, err
+	}
+	return &room, nil
+}
+
+func (c *Client) DeleteRoom(ctx context.Context, id int) error {
+	return c.do(ctx, http.MethodDelete, "/api/rooms/"+strconv.Itoa(id), nil, nil)
+}
+
+// Salles libres sur un créneau ; date au format AAAA-MM-JJ, heures au format HH:MM
+func (c *Client) AvailableRooms(ctx context.Context, date, startTime, endTime string) ([]models.Room, error) {
+	query := url.Values{"date": {date}, "start": {startTime}, "end": {endTime}}
+	var rooms []models.Room
+	return rooms, c.do(ctx, http.MethodGet, "/api/rooms/available?"+query.Encode(), nil, &rooms)
+}
+
+// Réservations d'une salle (roomID ≠ 0), d'une date (date ≠ ""), ou toutes
+func (c *Client) ListReservations(ctx context.Context, roomID int, date string) ([]models.Reservation, error) {
+	query := url.Values{}
+	if roomID != 0 {
+		query.Set("room", strconv.Itoa(roomID))
+	}
+	if date != "" {
+		query.Set("date", date)
+	}
+	path := "/api/reservations"
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+	var reservations []models.Reservation
+	return reservations, c.do(ctx, http.MethodGet, path, nil, &reservations)
+}
+
+func (c *Client) GetReservation(ctx context.Context, id int) (*models.Reservation, error) {
+	var reservation models.Reservation
+	if err := c.do(ctx, http.MethodGet, "/api/reservations/"+strconv.Itoa(id), nil, &reservation); err != nil {
+		return nil, err
+	}
+	return &reservation, nil
+}
+
+// Réserve une salle au nom de userID (0 : l'utilisateur du jeton) ; en cas de créneau
+// pris, l'*APIError contient les alternatives
+func (c *Client) Book(ctx context.Context, userID, roomID int, date, startTime, endTime string) (*models.Reservation, error) {
+	req := ReservationRequest{UserID: userID, RoomID: roomID, Date: date, StartTime: startTime, EndTime: endTime}
+	var reservation models.Reservation
+	if err := c.do(ctx, http.MethodPost, "/api/reservations", req, &reservation); err != nil {
+		return nil, err
+	}
+	return &reservation, nil
+}
+
+// Réserve le même créneau dans plusieurs salles : toutes ou aucune
+func (c *Client) BookGroup(ctx context.Context, userID int, roomIDs []int, date, startTime, endTime string) ([]models.Reservation, error) {
+	req := ReservationRequest{UserID: userID, RoomIDs: roomIDs, Date: date, StartTime: startTime, EndTime: endTime}
+	var group []models.Reservation
+	return group, c.do(ctx, http.MethodPost, "/api/reservations", req, &group)
+}
+
+// Réserve une série récurrente dans une salle : toutes les occurrences ou aucune
+func (c *Client) BookSeries(ctx context.Context, userID, roomID int, date, startTime, endTime string, repeat Repeat) ([]models.Reservation, error) {
+	req := ReservationRequest{UserID: userID, RoomID: roomID, Date: date, StartTime: startTime, EndTime: endTime, Repeat: &repeat}
+	var series []models.Reservation
+	return series, c.do(ctx, http.MethodPost, "/api/reservations", req, &series)
+}
+
+// Déplace une réservation confirmée ; seuls les champs non nil de update sont modifiés
+func (c *Client) UpdateReservation(ctx context.Context, id int, update reservationlogic.ReservationUpdate) (*models.Reservation, error) {
+	var reservation models.Reservation
+	if err := c.do(ctx, http.MethodPatch, "/api/reservations/"+strconv.Itoa(id), update, &reservation); err != nil {
+		return nil, err
+	}
+	return &reservation, nil
+}
+
+// Annule une réservation, ou tout son groupe multi-salles si withGroup est vrai
+func (c *Client) CancelReservation(ctx context.Context, id int, withGroup bool) error {
+	path := "/api/reservations/" + strconv.Itoa(id)
+	if withGroup {
+		path += "?group=true"
+	}
+	return c.do(ctx, http.MethodDelete, path, nil, nil)
+}
+
+// Copie l'export des réservations ("csv", "json", "ndjson", "xlsx" ou "ics") dans w
+func (c *Client) ExportReservations(ctx context.Context, format string, w io.Writer) error {
+	if format != "csv" && format != "json" && format != "ndjson" && format != "xlsx" && format != "ics" {
+		return models.Errorf(models.ErrInvalid, "format d'export inconnu : %s", format)
+	}
+	return c.download(ctx, "/api/exports/reservations."+format, w)
+}
+
+// Copie dans w l'export csv, json, ndjson ou xlsx des réservations qui vérifient filter, avec les
+// colonnes demandées (noms de exportlogic.AllColumns ; colonnes habituelles si vide)
+func (c *Client) ExportFilteredReservations(ctx context.Context, format string, filter reservationlogic.Filter, columns []string, w io.Writer) error {
+	if format != "csv" && format != "json" && format != "ndjson" && format != "xlsx" {
+		return models.Errorf(models.ErrInvalid, "format d'export inconnu : %s", format)
+	}
+	query := url.Values{}
+	for name, value := range map[string]string{"from": filter.From, "to": filter.To, "sort": filter.Sort, "status": strings.Join(filter.Statuses, ","), "columns": strings.Join(columns, ",")} {
+		if value != "" {
+			query.Set(name, value)
+		}
+	}
+	if len(filter.RoomIDs) > 0 {
+		ids := make([]string, len(filter.RoomIDs))
+		for i, id := range filter.RoomIDs {
+			ids[i] = strconv.Itoa(id)
+		}
+		query.Set("room", strings.Join(ids, ","))
+	}
+	if filter.UserID != 0 {
+		query.Set("user", strconv.Itoa(filter.UserID))
+	}
+	path := "/api/exports/reservations." + format
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+	return c.download(ctx, path, w)
+}
+
+// Copie dans w le calendrier iCalendar de toutes les réservations ; celui d'une salle
+// ou d'un utilisateur se lit avec Feed et le jeton de son flux
+func (c *Client) ExportCalendar(ctx context.Context, w io.Writer) error {
+	return c.download(ctx, "/api/exports/reservations.ics", w)
+}
+
+// Copie dans w les affiches ("html" ou "pdf") de la semaine de date : celle d'une salle
+// (roomID ≠ 0) ou le livret d'un bâtiment ; date vide pour la semaine en cours
+func (c *Client) ScheduleReport(ctx context.Context, format string, roomID int, building, date string, w io.Writer) error {
+	if format != "html" && format != "pdf" {
+		return models.Errorf(models.ErrInvalid, "format d'impression inconnu : %s", format)
+	}
+	query := url.Values{}
+	if roomID != 0 {
+		query.Set("room", strconv.Itoa(roomID))
+	}
+	if building != "" {
+		query.Set("building", building)
+	}
+	if date != "" {
+		query.Set("date", date)
+	}
+	return c.download(ctx, "/api/reports/schedule."+format+"?"+query.Encode(), w)
+}
+
+// Lit un flux d'abonnement ; si etag est la version déjà connue et que le calendrier
+// n'a pas changé, rien n'est écrit dans w et modified vaut false
+func (c *Client) Feed(ctx context.Context, token, etag string, w io.Writer) (newETag string, modified bool, err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.BaseURL+"/api/feeds/"+url.PathEscape(token)+"/reservations.ics", nil)
+	if err != nil {
+		return "", false, err
+	}
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+	resp, err := c.httpClient().Do(req)
+	if err != nil {
+		return "", false, err
+	}
+	defer closeBody(resp)
+	switch {
+	case resp.StatusCode == http.StatusNotModified:
+		return etag, false, nil
+	case resp.StatusCode >= 300:
+		return "", false, decodeError(resp)
+	}
+	if _, err := io.Copy(w, resp.Body); err != nil {
+		return "", false, err
+	}
+	return resp.Header.Get("ETag"), true, nil
+}
+
+func (c *Client) download(ctx context.Context, path string, w io.Writer) error {
+	resp, err := c.send(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return err
+	}
+	defer closeBody(resp)
+	if resp.StatusCode >= 300 {
+		return decodeError(resp)
+	}
+	_, err = io.Copy(w, resp.Body)
+	return err
+}
+
+// Importe un fichier iCalendar au nom de userID (0 : l'utilisateur du jeton) ; sans commit,
+// le rapport n'est qu'une simulation
+func (c *Client) ImportICS(ctx context.Context, userID int, calendar io.Reader, commit bool) (*importlogic.Report, error) {
+	query := url.Values{}
+	if userID != 0 {
+		query.Set("user", strconv.Itoa(userID))
+	}
+	if commit {
+		query.Set("commit", "true")
+	}
+	var report importlogic.Report
+	if err := c.upload(ctx, "/api/imports/reservations.ics?"+query.Encode(), "text/calendar", calendar, &report, false); err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+// Importe des salles depuis un fichier csv ou json selon mode (importlogic.ModeDryRun,
+// ModePartial ou ModeAllOrNothing). Un import annulé renvoie son rapport, avec Aborted.
+func (c *Client) ImportRooms(ctx context.Context, format, mode string, file io.Reader) (*importlogic.BulkReport, error) {
+	return c.importBulk(ctx, "rooms", format, mode, file)
+}
+
+// Importe des réservations depuis un fichier csv ou json, comme ImportRooms
+func (c *Client) ImportReservations(ctx context.Context, format, mode string, file io.Reader) (*importlogic.BulkReport, error) {
+	return c.importBulk(ctx, "reservations", format, mode, file)
+}
+
+func (c *Client) importBulk(ctx context.Context, kind, format, mode string, file io.Reader) (*importlogic.BulkReport, error) {
+	contentType := "text/csv"
+	switch format {
+	case "csv":
+	case "json":
+		contentType = "application/json"
+	default:
+		return nil, fmt.Errorf("format d'import inconnu : %s", format)
+	}
+	path := "/api/imports/" + kind + "." + format
+	if mode != "" {
+		path += "?" + url.Values{"mode": {mode}}.Encode()
+	}
+	var report importlogic.BulkReport
+	if err := c.upload(ctx, path, contentType, file, &report, true); err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+// Envoie body tel quel et décode le rapport d'import. Avec reportOnConflict, un 409
+// porte lui aussi un rapport (import annulé) plutôt qu'une erreur.
+func (c *Client) upload(ctx context.Context, path, contentType string, body io.Reader, out interface{}, reportOnConflict bool) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.BaseURL+path, body)
+	if err != nil {
+		return err
+	}
+	c.setHeaders(req)
+	req.Header.Set("Content-Type", contentType)
+	resp, err := c.httpClient().Do(req)
+	if err != nil {
+		return err
+	}
+	defer closeBody(resp)
+	if resp.StatusCode >= 300 && !(reportOnConflict && resp.StatusCode == http.StatusConflict) {
+		return decodeError(resp)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("réponse illisible pour l'import : %w", err)
+	}
+	return nil
+}
+
+// Envoie la requête et décode la réponse JSON dans out (ignorée si nil)
+func (c *Client) do(ctx context.Context, method, path string, in, out interface{}) error {
+	resp, err := c.send(ctx, method, path, in)
+	if err != nil {
+		return err
+	}
+	defer closeBody(resp)
+	if resp.StatusCode >= 300 {
+		return decodeError(resp)
+	}
+	if out == nil || resp.StatusCode == http.StatusNoContent {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("réponse illisible pour %s %s : %w", method, path, err)
+	}
+	return nil
+}
+
+func (c *Client) send(ctx context.Context, method, path string, in interface{}) (*http.Response, error) {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return nil, err
+		}
+		body = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.BaseURL+path, body)
+	if err != nil {
+		return nil, err
+	}
+	c.setHeaders(req)
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	return c.httpClient().Do(req)
+}
+
+func (c *Client) setHeaders(req *http.Request) {
+	req.Header.Set("Accept", "application/json")
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+}
+
+func (c *Client) httpClient() *http.Client {
+	if c.HTTPClient == nil {
+		return http.DefaultClient
+	}
+	return c.HTTPClient
+}
+
+// Lit le corps {"error": "...", "alternatives": {...}} d'une réponse en erreur
+func decodeError(resp *http.Response) error {
+	var body struct {
+		Error        string                         `json:"error"`
+		Alternatives *reservationlogic.Alternatives `json:"alternatives"`
+	}
+	apiErr := &APIError{StatusCode: resp.StatusCode}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil || body.Error == "" {
+		apiErr.Message = http.StatusText(resp.StatusCode)
+		return apiErr
+	}
+	apiErr.Message = body.Error
+	apiErr.Alternatives = body.Alternatives
+	return apiErr
+}
+
+func closeBody(resp *http.Response) {
+	if err := resp.Body.Close(); err != nil {
+		log.Printf("Erreur: %v", err)
+	}
+}
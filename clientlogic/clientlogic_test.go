@@ -0,0 +1,172 @@
+package clientlogic
+
+import (
+	"Reserve-Go/models"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// Serveur de test dont handler vérifie la requête reçue ; le client porte un jeton
+func newTestClient(t *testing.T, handler http.HandlerFunc) *Client {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	client := New(server.URL + "/")
+	client.Token = "jeton"
+	return client
+}
+
+func writeBody(t *testing.T, w http.ResponseWriter, status int, body string) {
+	t.Helper()
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	if _, err := w.Write([]byte(body)); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestBook(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/api/reservations" {
+			t.Errorf("requête %s %s, attendu POST /api/reservations", r.Method, r.URL.Path)
+		}
+		if auth := r.Header.Get("Authorization"); auth != "Bearer jeton" {
+			t.Errorf("Authorization = %q", auth)
+		}
+		var body map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := body["UserID"]; ok {
+			t.Error("UserID envoyé alors qu'il vaut 0")
+		}
+		if body["RoomID"] != 3.0 || body["Date"] != "2024-05-13" || body["StartTime"] != "09:00" {
+			t.Errorf("corps inattendu : %v", body)
+		}
+		writeBody(t, w, http.StatusCreated, `{"ID": 42, "RoomID": 3, "UserID": 2, "Date": "2024-05-13", "StartTime": "09:00:00", "EndTime": "10:00:00", "Status": "confirmed"}`)
+	})
+
+	reservation, err := client.Book(context.Background(), 0, 3, "2024-05-13", "09:00", "10:00")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reservation.ID != 42 || reservation.UserID != 2 || reservation.StartTime != "09:00:00" {
+		t.Errorf("réservation décodée : %+v", reservation)
+	}
+}
+
+func TestBookConflictAlternatives(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		writeBody(t, w, http.StatusConflict, `{"error": "salle déjà réservée", "alternatives": {"SameRoom": [{"RoomID": 3, "Date": "2024-05-13", "StartTime": "10:00:00", "EndTime": "11:00:00"}]}}`)
+	})
+
+	_, err := client.Book(context.Background(), 0, 3, "2024-05-13", "09:00", "10:00")
+	if !errors.Is(err, models.ErrConflict) {
+		t.Fatalf("erreur %v, attendu ErrConflict", err)
+	}
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.Message != "salle déjà réservée" {
+		t.Fatalf("erreur %#v, attendu *APIError", err)
+	}
+	if apiErr.Alternatives == nil || len(apiErr.Alternatives.SameRoom) != 1 || apiErr.Alternatives.SameRoom[0].StartTime != "10:00:00" {
+		t.Errorf("alternatives : %+v", apiErr.Alternatives)
+	}
+}
+
+// Chaque code d'erreur est rattaché à sa catégorie de models
+func TestErrorCategories(t *testing.T) {
+	tests := []struct {
+		status int
+		kind   error
+	}{
+		{http.StatusBadRequest, models.ErrInvalid},
+		{http.StatusUnauthorized, models.ErrUnauthorized},
+		{http.StatusForbidden, models.ErrForbidden},
+		{http.StatusNotFound, models.ErrNotFound},
+		{http.StatusConflict, models.ErrConflict},
+	}
+	for _, tt := range tests {
+		client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodDelete || r.URL.Path != "/api/rooms/7" {
+				t.Errorf("requête %s %s, attendu DELETE /api/rooms/7", r.Method, r.URL.Path)
+			}
+			writeBody(t, w, tt.status, `{"error": "refusé"}`)
+		})
+		err := client.DeleteRoom(context.Background(), 7)
+		if !errors.Is(err, tt.kind) {
+			t.Errorf("HTTP %d : erreur %v, attendu %v", tt.status, err, tt.kind)
+		}
+	}
+
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	var apiErr *APIError
+	if err := client.DeleteRoom(context.Background(), 7); !errors.As(err, &apiErr) || apiErr.Message != "Internal Server Error" {
+		t.Errorf("corps vide : erreur %v", err)
+	}
+}
+
+func TestFeed(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/feeds/abc/reservations.ics" {
+			t.Errorf("chemin %s", r.URL.Path)
+		}
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		_, _ = w.Write([]byte("BEGIN:VCALENDAR\r\n"))
+	})
+
+	var buf bytes.Buffer
+	etag, modified, err := client.Feed(context.Background(), "abc", "", &buf)
+	if err != nil || !modified || etag != `"v1"` || buf.String() != "BEGIN:VCALENDAR\r\n" {
+		t.Fatalf("premier appel : %q %v %v %q", etag, modified, err, buf.String())
+	}
+	buf.Reset()
+	etag, modified, err = client.Feed(context.Background(), "abc", etag, &buf)
+	if err != nil || modified || etag != `"v1"` || buf.Len() != 0 {
+		t.Errorf("calendrier inchangé : %q %v %v %q", etag, modified, err, buf.String())
+	}
+}
+
+func TestImportICS(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Has("user") || r.URL.Query().Get("commit") != "true" {
+			t.Errorf("paramètres %s", r.URL.RawQuery)
+		}
+		if contentType := r.Header.Get("Content-Type"); contentType != "text/calendar" {
+			t.Errorf("Content-Type = %q", contentType)
+		}
+		if auth := r.Header.Get("Authorization"); auth != "Bearer jeton" {
+			t.Errorf("Authorization = %q", auth)
+		}
+		writeBody(t, w, http.StatusCreated, `{"Committed": true, "Created": 2}`)
+	})
+
+	report, err := client.ImportICS(context.Background(), 0, strings.NewReader("BEGIN:VCALENDAR\r\n"), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !report.Committed || report.Created != 2 {
+		t.Errorf("rapport : %+v", report)
+	}
+}
+
+// Un format inconnu est refusé sans appeler le serveur
+func TestExportUnknownFormat(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("requête inattendue %s %s", r.Method, r.URL)
+	})
+	if err := client.ExportReservations(context.Background(), "pdf", &bytes.Buffer{}); !errors.Is(err, models.ErrInvalid) {
+		t.Errorf("erreur %v, attendu ErrInvalid", err)
+	}
+}
@@ -0,0 +1,842 @@
+package clilogic
+
+import (
+	"Reserve-Go/apilogic"
+	"Reserve-Go/exportlogic"
+	"Reserve-Go/icallogic"
+	"Reserve-Go/importlogic"
+	"Reserve-Go/models"
+	"Reserve-Go/planninglogic"
+	"Reserve-Go/reportlogic"
+	"Reserve-Go/reservationlogic"
+	"Reserve-Go/roomlogic"
+	"Reserve-Go/slotlogic"
+	"Reserve-Go/userlogic"
+	"Reserve-Go/utils"
+	"Reserve-Go/weblogic"
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Codes de sortie des sous-commandes
+const (
+	ExitOK    = 0
+	ExitError = 1
+	ExitUsage = 2
+	// Réservation refusée : créneau pris, règle, quota ou période de gel
+	ExitRefused = 3
+)
+
+const usage = `Usage : reserve <commande> [options]
+
+Commandes :
+  rooms list                                   Lister les salles
+  rooms available --date D --start HH:MM --end HH:MM
+                                               Lister les salles libres sur un créneau
+  reservations list [--room ID] [--date D]     Lister les réservations
+  book --user ID --room ID[,ID...] --date D --start HH:MM --end HH:MM [--repeat daily|weekly --count N [--interval N]]
+                                               Réserver une salle (plusieurs salles = réservation groupée,
+                                               --repeat = série récurrente dans une salle)
+  cancel ID [--group]                          Annuler une réservation (et tout son groupe avec --group)
+  checkin ID                                   Enregistrer l'arrivée dans la salle
+  slots --duration MIN [--from D] [--to D] [--capacity N] [--features a,b] [--limit N] [--user ID]
+                                               Rechercher des créneaux libres
+  planning [--date D] [--day]                  Grille d'occupation des salles (semaine de la date, ou journée)
+  export --format csv|json|ndjson|xlsx|ics [--output FICHIER] [--room ID[,ID...]] [--user ID]
+         [--from D] [--to D] [--status S[,S...]] [--sort [-]date|room|user|id] [--columns C[,C...]|all] [--rooms]
+                                               Exporter les réservations (stdout par défaut ; ics : d'une salle
+                                               ou d'un utilisateur ; --rooms : les salles, en csv ou json)
+  report (--room ID | --building NOM) [--date D] [--format html|pdf] [--output FICHIER]
+                                               Affiches de la semaine d'une salle, ou livret d'un bâtiment,
+                                               à imprimer (pdf sur stdout par défaut)
+  import ics --user ID --file FICHIER [--commit]
+                                               Importer un calendrier iCalendar (simulation sans --commit)
+  import rooms|reservations --file FICHIER [--input-format csv|json] [--mode dry-run|partial|all-or-nothing]
+                                               Importer des salles ou des réservations au format des exports
+                                               (simulation par défaut)
+  feeds list | create (--room ID | --user ID) [--base-url URL] | revoke JETON
+                                               Gérer les abonnements iCalendar (URL à jeton secret)
+  tokens list | create --user ID | revoke JETON
+                                               Gérer les jetons de l'API JSON (en-tête Authorization: Bearer)
+  serve [--addr ADRESSE]                       Démarrer le serveur web et l'API JSON (:8095 par défaut)
+  help                                         Afficher cette aide
+
+Les commandes de consultation acceptent --format text|json.
+Codes de sortie : 0 succès, 1 erreur, 2 utilisation incorrecte, 3 réservation refusée.
+`
+
+type cli struct {
+	db     *sql.DB
+	stdout io.Writer
+	stderr io.Writer
+	format string
+}
+
+// Exécute une sous-commande et renvoie le code de sortie du programme
+func Run(db *sql.DB, args []string, stdout, stderr io.Writer) int {
+	c := &cli{db: db, stdout: stdout, stderr: stderr, format: "text"}
+	if len(args) == 0 {
+		fmt.Fprint(stderr, usage)
+		return ExitUsage
+	}
+
+	switch args[0] {
+	case "rooms":
+		if len(args) < 2 {
+			return c.usageError("sous-commande de rooms manquante (list, available)")
+		}
+		switch args[1] {
+		case "list":
+			return c.roomsList(args[2:])
+		case "available":
+			return c.roomsAvailable(args[2:])
+		}
+		return c.usageError("sous-commande de rooms inconnue : " + args[1])
+	case "reservations":
+		if len(args) < 2 || args[1] != "list" {
+			return c.usageError("sous-commande de reservations attendue : list")
+		}
+		return c.reservationsList(args[2:])
+	case "book":
+		return c.book(args[1:])
+	case "cancel":
+		return c.cancel(args[1:])
+	case "checkin":
+		return c.checkin(args[1:])
+	case "slots":
+		return c.slots(args[1:])
+	case "planning":
+		return c.planning(args[1:])
+	case "export":
+		return c.export(args[1:])
+	case "report":
+		return c.report(args[1:])
+	case "import":
+		if len(args) < 2 {
+			return c.usageError("sous-commande de import manquante (ics, rooms, reservations)")
+		}
+		switch args[1] {
+		case "ics":
+			return c.importICS(args[2:])
+		case "rooms", "reservations":
+			return c.importBulk(args[1], args[2:])
+		}
+		return c.usageError("sous-commande de import inconnue : " + args[1])
+	case "feeds":
+		if len(args) < 2 {
+			return c.usageError("sous-commande de feeds manquante (list, create, revoke)")
+		}
+		switch args[1] {
+		case "list":
+			return c.feedsList(args[2:])
+		case "create":
+			return c.feedsCreate(args[2:])
+		case "revoke":
+			return c.feedsRevoke(args[2:])
+		}
+		return c.usageError("sous-commande de feeds inconnue : " + args[1])
+	case "tokens":
+		if len(args) < 2 {
+			return c.usageError("sous-commande de tokens manquante (list, create, revoke)")
+		}
+		switch args[1] {
+		case "list":
+			return c.tokensList(args[2:])
+		case "create":
+			return c.tokensCreate(args[2:])
+		case "revoke":
+			return c.tokensRevoke(args[2:])
+		}
+		return c.usageError("sous-commande de tokens inconnue : " + args[1])
+	case "serve":
+		return c.serve(args[1:])
+	case "help", "-h", "--help":
+		fmt.Fprint(stdout, usage)
+		return ExitOK
+	}
+	return c.usageError("commande inconnue : " + args[0])
+}
+
+// Analyse les options en acceptant qu'elles suivent les arguments positionnels
+func (c *cli) parse(fs *flag.FlagSet, args []string) ([]string, error) {
+	fs.SetOutput(c.stderr)
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		if fs.NArg() == 0 {
+			return positional, nil
+		}
+		positional = append(positional, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+}
+
+func (c *cli) newFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.StringVar(&c.format, "format", "text", "format de sortie : text ou json")
+	return fs
+}
+
+func (c *cli) usageError(message string) int {
+	fmt.Fprintf(c.stderr, "erreur : %s\n\n%s", message, usage)
+	return ExitUsage
+}
+
+// Signale une erreur sur stderr, et sur stdout en JSON si ce format est demandé
+func (c *cli) fail(code int, err error) int {
+	fmt.Fprintln(c.stderr, "erreur :", err)
+	if c.format == "json" {
+		_ = c.writeJSON(map[string]string{"error": err.Error()})
+	}
+	return code
+}
+
+// Code de sortie correspondant à la catégorie d'une erreur métier
+func exitCode(err error) int {
+	switch {
+	case errors.Is(err, models.ErrConflict), errors.Is(err, models.ErrForbidden):
+		return ExitRefused
+	case errors.Is(err, models.ErrInvalid):
+		return ExitUsage
+	default:
+		return ExitError
+	}
+}
+
+func (c *cli) writeJSON(value interface{}) error {
+	encoder := json.NewEncoder(c.stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(value)
+}
+
+// Écrit value en JSON, ou appelle text pour la sortie lisible
+func (c *cli) output(value interface{}, text func()) int {
+	switch c.format {
+	case "json":
+		if err := c.writeJSON(value); err != nil {
+			return c.fail(ExitError, err)
+		}
+	case "text":
+		text()
+	default:
+		return c.usageError("format inconnu : " + c.format)
+	}
+	return ExitOK
+}
+
+func (c *cli) roomsList(args []string) int {
+	fs := c.newFlagSet("rooms list")
+	if _, err := c.parse(fs, args); err != nil {
+		return ExitUsage
+	}
+	rooms, err := roomlogic.GetRooms(c.db)
+	if err != nil {
+		return c.fail(ExitError, err)
+	}
+	return c.output(rooms, func() {
+		for _, room := range rooms {
+			fmt.Fprintf(c.stdout, "%d\t%s\t%d\t%s\n", room.ID, room.Name, room.Capacity, roomlogic.JoinFeatures(room.Features))
+		}
+	})
+}
+
+func (c *cli) roomsAvailable(args []string) int {
+	fs := c.newFlagSet("rooms available")
+	date := fs.String("date", "", "date (AAAA-MM-JJ)")
+	start := fs.String("start", "", "heure de début (HH:MM)")
+	end := fs.String("end", "", "heure de fin (HH:MM)")
+	if _, err := c.parse(fs, args); err != nil {
+		return ExitUsage
+	}
+	if *date == "" || *start == "" || *end == "" {
+		return c.usageError("--date, --start et --end sont obligatoires")
+	}
+	startTime, err := utils.NormalizeClock(*start)
+	if err != nil {
+		return c.fail(ExitUsage, err)
+	}
+	endTime, err := utils.NormalizeClock(*end)
+	if err != nil {
+		return c.fail(ExitUsage, err)
+	}
+	rooms, err := roomlogic.GetAvailableRooms(c.db, *date, startTime, endTime)
+	if err != nil {
+		return c.fail(ExitError, err)
+	}
+	return c.output(rooms, func() {
+		for _, room := range rooms {
+			fmt.Fprintf(c.stdout, "%d\t%s\t%d\n", room.ID, room.Name, room.Capacity)
+		}
+	})
+}
+
+func (c *cli) reservationsList(args []string) int {
+	fs := c.newFlagSet("reservations list")
+	roomID := fs.Int("room", 0, "ID de la salle")
+	date := fs.String("date", "", "date (AAAA-MM-JJ)")
+	if _, err := c.parse(fs, args); err != nil {
+		return ExitUsage
+	}
+
+	var reservations []models.Reservation
+	var err error
+	switch {
+	case *roomID != 0:
+		reservations, err = reservationlogic.GetReservationsByRoom(c.db, *roomID)
+	case *date != "":
+		reservations, err = reservationlogic.GetReservationsByDate(c.db, *date)
+	default:
+		reservations, err = reservationlogic.GetAllReservations(c.db)
+	}
+	if err != nil {
+		return c.fail(ExitError, err)
+	}
+	if *roomID != 0 && *date != "" {
+		filtered := reservations[:0]
+		for _, r := range reservations {
+			if r.Date == *date {
+				filtered = append(filtered, r)
+			}
+		}
+		reservations = filtered
+	}
+	return c.output(reservations, func() {
+		for _, r := range reservations {
+			fmt.Fprintf(c.stdout, "%d\t%d\t%d\t%s\t%s\t%s\t%s\n", r.ID, r.RoomID, r.UserID, r.Date, r.StartTime, r.EndTime, r.Status)
+		}
+	})
+}
+
+func (c *cli) book(args []string) int {
+	fs := c.newFlagSet("book")
+	userID := fs.Int("user", 0, "ID de l'utilisateur")
+	rooms := fs.String("room", "", "ID de la salle, ou plusieurs IDs séparés par des virgules")
+	date := fs.String("date", "", "date (AAAA-MM-JJ)")
+	start := fs.String("start", "", "heure de début (HH:MM)")
+	end := fs.String("end", "", "heure de fin (HH:MM)")
+	repeat := fs.String("repeat", "", "répétition : daily ou weekly")
+	count := fs.Int("count", 0, "nombre d'occurrences de la série")
+	interval := fs.Int("interval", 1, "tous les N jours ou semaines")
+	if _, err := c.parse(fs, args); err != nil {
+		return ExitUsage
+	}
+	if *userID == 0 || *rooms == "" || *date == "" || *start == "" || *end == "" {
+		return c.usageError("--user, --room, --date, --start et --end sont obligatoires")
+	}
+	roomIDs, err := reservationlogic.ParseRoomIDs(*rooms)
+	if err != nil {
+		return c.fail(ExitUsage, err)
+	}
+	if (*repeat == "") != (*count == 0) {
+		return c.usageError("--repeat et --count vont ensemble")
+	}
+	if *repeat != "" && len(roomIDs) > 1 {
+		return c.usageError("une série ne porte que sur une salle")
+	}
+	startTime, err := utils.NormalizeClock(*start)
+	if err != nil {
+		return c.fail(ExitUsage, err)
+	}
+	endTime, err := utils.NormalizeClock(*end)
+	if err != nil {
+		return c.fail(ExitUsage, err)
+	}
+	user, err := userlogic.GetUser(c.db, *userID)
+	if err != nil {
+		return c.fail(ExitError, err)
+	}
+
+	if *repeat != "" {
+		series := models.Series{RoomID: roomIDs[0], StartDate: *date, StartTime: startTime, EndTime: endTime,
+			Frequency: *repeat, Interval: *interval, Count: *count}
+		seriesID, err := reservationlogic.BookSeries(c.db, user, series)
+		if err != nil {
+			return c.fail(exitCode(err), err)
+		}
+		result := map[string]interface{}{"series_id": seriesID, "count": *count}
+		return c.output(result, func() { fmt.Fprintln(c.stdout, seriesID) })
+	}
+
+	if len(roomIDs) > 1 {
+		groupID, err := reservationlogic.BookGroup(c.db, user, roomIDs, *date, startTime, endTime)
+		if err != nil {
+			return c.fail(exitCode(err), err)
+		}
+		result := map[string]interface{}{"group_id": groupID, "room_ids": roomIDs}
+		return c.output(result, func() { fmt.Fprintln(c.stdout, groupID) })
+	}
+
+	id, err := reservationlogic.BookReservation(c.db, user, roomIDs[0], *date, startTime, endTime)
+	if err != nil {
+		return c.fail(exitCode(err), err)
+	}
+	return c.output(map[string]int{"id": id}, func() { fmt.Fprintln(c.stdout, id) })
+}
+
+func (c *cli) reservationID(fs *flag.FlagSet, args []string) (int, int) {
+	positional, err := c.parse(fs, args)
+	if err != nil {
+		return 0, ExitUsage
+	}
+	if len(positional) != 1 {
+		return 0, c.usageError("identifiant de réservation attendu")
+	}
+	id, err := strconv.Atoi(positional[0])
+	if err != nil {
+		return 0, c.usageError("identifiant de réservation invalide : " + positional[0])
+	}
+	return id, ExitOK
+}
+
+func (c *cli) cancel(args []string) int {
+	fs := c.newFlagSet("cancel")
+	group := fs.Bool("group", false, "annuler tout le groupe multi-salles")
+	id, code := c.reservationID(fs, args)
+	if code != ExitOK {
+		return code
+	}
+	if _, err := reservationlogic.CancelReservation(c.db, id, *group); err != nil {
+		return c.fail(exitCode(err), err)
+	}
+	return c.output(map[string]int{"cancelled": id}, func() { fmt.Fprintln(c.stdout, id) })
+}
+
+func (c *cli) checkin(args []string) int {
+	fs := c.newFlagSet("checkin")
+	id, code := c.reservationID(fs, args)
+	if code != ExitOK {
+		return code
+	}
+	if err := reservationlogic.CheckIn(c.db, id, time.Now()); err != nil {
+		return c.fail(exitCode(err), err)
+	}
+	return c.output(map[string]int{"checked_in": id}, func() { fmt.Fprintln(c.stdout, id) })
+}
+
+func (c *cli) slots(args []string) int {
+	fs := c.newFlagSet("slots")
+	today := time.Now().Format(utils.DateLayout)
+	var search slotlogic.Search
+	fs.IntVar(&search.Duration, "duration", 0, "durée en minutes")
+	fs.StringVar(&search.From, "from", today, "début de la période (AAAA-MM-JJ)")
+	fs.StringVar(&search.To, "to", "", "fin de la période (AAAA-MM-JJ, 7 jours par défaut)")
+	fs.IntVar(&search.MinCapacity, "capacity", 0, "capacité minimale")
+	features := fs.String("features", "", "équipements requis, séparés par des virgules")
+	fs.StringVar(&search.PreferredStart, "after", "", "heure de début au plus tôt (HH:MM)")
+	fs.StringVar(&search.PreferredEnd, "before", "", "heure de fin au plus tard (HH:MM)")
+	fs.IntVar(&search.Limit, "limit", slotlogic.DefaultLimit, "nombre de créneaux")
+	fs.IntVar(&search.UserID, "user", 0, "utilisateur qui réservera : créneaux permis par ses règles et hors périodes de gel")
+	if _, err := c.parse(fs, args); err != nil {
+		return ExitUsage
+	}
+	if search.Duration <= 0 {
+		return c.usageError("--duration est obligatoire")
+	}
+	if search.To == "" {
+		from, err := utils.ParseDate(search.From)
+		if err != nil {
+			return c.fail(ExitUsage, err)
+		}
+		search.To = from.AddDate(0, 0, 7).Format(utils.DateLayout)
+	}
+	search.Features = roomlogic.ParseFeatures(*features)
+
+	slots, err := slotlogic.FindFreeSlots(c.db, search, time.Now())
+	if err != nil {
+		return c.fail(ExitError, err)
+	}
+	return c.output(slots, func() {
+		for _, slot := range slots {
+			fmt.Fprintf(c.stdout, "%d\t%s\t%s\t%s\t%s\n", slot.RoomID, slot.RoomName, slot.Date, slot.StartTime, slot.EndTime)
+		}
+	})
+}
+
+func (c *cli) planning(args []string) int {
+	fs := c.newFlagSet("planning")
+	date := fs.String("date", time.Now().Format(utils.DateLayout), "date de la période (AAAA-MM-JJ)")
+	day := fs.Bool("day", false, "afficher la seule journée au lieu de la semaine")
+	if _, err := c.parse(fs, args); err != nil {
+		return ExitUsage
+	}
+	view := planninglogic.WeekView
+	if *day {
+		view = planninglogic.DayView
+	}
+	grid, err := planninglogic.BuildGrid(c.db, *date, view)
+	if err != nil {
+		return c.fail(exitCode(err), err)
+	}
+	return c.output(grid, func() {
+		if err := planninglogic.WriteText(c.stdout, grid); err != nil {
+			log.Printf("Erreur: %v", err)
+		}
+	})
+}
+
+func (c *cli) export(args []string) int {
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	format := fs.String("format", "csv", "format d'export : csv, json, ndjson, xlsx ou ics")
+	output := fs.String("output", "-", "fichier de sortie (- pour stdout)")
+	rooms := fs.Bool("rooms", false, "exporter les salles au lieu des réservations (csv ou json)")
+	roomIDs := fs.String("room", "", "réservations de ces salles (IDs séparés par des virgules ; une seule salle en ics)")
+	var filter reservationlogic.Filter
+	fs.IntVar(&filter.UserID, "user", 0, "réservations de cet utilisateur")
+	fs.StringVar(&filter.From, "from", "", "csv/json : à partir de cette date (AAAA-MM-JJ)")
+	fs.StringVar(&filter.To, "to", "", "csv/json : jusqu'à cette date incluse (AAAA-MM-JJ)")
+	statuses := fs.String("status", "", "csv/json : statuts retenus, séparés par des virgules")
+	fs.StringVar(&filter.Sort, "sort", "", "csv/json : tri (date, room, user ou id, précédé de - pour l'ordre décroissant)")
+	columnSpec := fs.String("columns", "", "csv/json : colonnes séparées par des virgules, ou all (salle, bâtiment, propriétaire, statut...)")
+	if _, err := c.parse(fs, args); err != nil {
+		return ExitUsage
+	}
+	*format = strings.ToLower(*format)
+	if *format != "csv" && *format != "json" && *format != "ndjson" && *format != "xlsx" && *format != "ics" {
+		return c.usageError("format d'export inconnu : " + *format)
+	}
+	var err error
+	if filter.RoomIDs, err = reservationlogic.ParseRoomIDs(*roomIDs); err != nil {
+		return c.usageError(err.Error())
+	}
+	filter.Statuses = reservationlogic.ParseStatuses(*statuses)
+	if *rooms && (*format == "ics" || *format == "ndjson" || *format == "xlsx" || len(filter.RoomIDs) > 0 || filter.UserID != 0 || filter.From != "" || filter.To != "" || len(filter.Statuses) > 0 || filter.Sort != "") {
+		return c.usageError("--rooms s'utilise avec les formats csv et json, sans filtre")
+	}
+	if *format == "ics" && (len(filter.RoomIDs) > 1 || filter.From != "" || filter.To != "" || len(filter.Statuses) > 0 || filter.Sort != "") {
+		return c.usageError("le format ics ne se filtre que par une salle (--room) ou un utilisateur (--user)")
+	}
+	if err := filter.Validate(); err != nil {
+		return c.usageError(err.Error())
+	}
+	columns, err := exportlogic.ParseColumns(*columnSpec)
+	if err != nil {
+		return c.usageError(err.Error())
+	}
+	if len(columns) > 0 && (*rooms || *format == "ics") {
+		return c.usageError("--columns ne s'applique qu'aux exports de réservations csv, json et ndjson")
+	}
+
+	// Salles et calendriers sont lus avant de créer le fichier de sortie ; les réservations
+	// sont écrites au fil de la lecture, sans être chargées en mémoire
+	var write func(io.Writer) error
+	switch {
+	case *rooms:
+		list, err := roomlogic.GetRooms(c.db)
+		if err != nil {
+			return c.fail(ExitError, err)
+		}
+		write = func(w io.Writer) error { return exportlogic.WriteRoomsCSV(w, list) }
+		if *format == "json" {
+			write = func(w io.Writer) error { return exportlogic.WriteRoomsJSON(w, list) }
+		}
+	case *format == "ics":
+		scope := icallogic.Scope{UserID: filter.UserID}
+		if len(filter.RoomIDs) == 1 {
+			scope.RoomID = filter.RoomIDs[0]
+		}
+		calendar, err := icallogic.BuildCalendar(c.db, scope)
+		if err != nil {
+			return c.fail(exitCode(err), err)
+		}
+		write = func(w io.Writer) error { return icallogic.Write(w, calendar, time.Now()) }
+	default:
+		if *output != "-" {
+			// Le fichier est supprimé si l'export échoue en cours de route
+			if err := exportlogic.ExportReservations(c.db, *output, *format, filter, columns); err != nil {
+				return c.fail(exitCode(err), err)
+			}
+			return ExitOK
+		}
+		write = func(w io.Writer) error { return exportlogic.StreamReservations(c.db, w, *format, filter, columns) }
+	}
+
+	w := c.stdout
+	if *output != "-" {
+		file, err := os.Create(*output)
+		if err != nil {
+			return c.fail(ExitError, err)
+		}
+		defer func(file *os.File) {
+			if err := file.Close(); err != nil {
+				log.Printf("Erreur: %v", err)
+			}
+		}(file)
+		w = file
+	}
+
+	if err := write(w); err != nil {
+		return c.fail(ExitError, err)
+	}
+	return ExitOK
+}
+
+func (c *cli) report(args []string) int {
+	fs := flag.NewFlagSet("report", flag.ContinueOnError)
+	format := fs.String("format", "pdf", "format d'impression : html ou pdf")
+	output := fs.String("output", "-", "fichier de sortie (- pour stdout)")
+	roomID := fs.Int("room", 0, "affiche de cette salle")
+	building := fs.String("building", "", "livret de toutes les salles de ce bâtiment")
+	date := fs.String("date", time.Now().Format(utils.DateLayout), "un jour de la semaine à imprimer (AAAA-MM-JJ)")
+	if _, err := c.parse(fs, args); err != nil {
+		return ExitUsage
+	}
+	*format = strings.ToLower(*format)
+	if *format != "html" && *format != "pdf" {
+		return c.usageError("format d'impression inconnu : " + *format)
+	}
+	if (*roomID == 0) == (strings.TrimSpace(*building) == "") {
+		return c.usageError("--room ou --building est obligatoire (un seul des deux)")
+	}
+
+	booklet, err := reportlogic.Build(c.db, *roomID, *building, *date)
+	if err != nil {
+		return c.fail(exitCode(err), err)
+	}
+	// Le document est composé en mémoire : aucun fichier n'est créé en cas d'erreur
+	var buf bytes.Buffer
+	if err := reportlogic.Write(&buf, booklet, *format, time.Now()); err != nil {
+		return c.fail(ExitError, err)
+	}
+	if *output == "-" {
+		_, err = c.stdout.Write(buf.Bytes())
+	} else {
+		err = os.WriteFile(*output, buf.Bytes(), 0o644)
+	}
+	if err != nil {
+		return c.fail(ExitError, err)
+	}
+	return ExitOK
+}
+
+func (c *cli) importICS(args []string) int {
+	fs := c.newFlagSet("import ics")
+	userID := fs.Int("user", 0, "utilisateur au nom duquel les réservations sont créées")
+	file := fs.String("file", "", "fichier .ics à importer (- pour stdin)")
+	commit := fs.Bool("commit", false, "créer les réservations (sinon simple simulation)")
+	if _, err := c.parse(fs, args); err != nil {
+		return ExitUsage
+	}
+	if *userID == 0 || *file == "" {
+		return c.usageError("--user et --file sont obligatoires")
+	}
+	user, err := userlogic.GetUser(c.db, *userID)
+	if err != nil {
+		return c.fail(exitCode(err), err)
+	}
+
+	var r io.Reader = os.Stdin
+	if *file != "-" {
+		f, err := os.Open(*file)
+		if err != nil {
+			return c.fail(ExitError, err)
+		}
+		defer func(f *os.File) {
+			if err := f.Close(); err != nil {
+				log.Printf("Erreur: %v", err)
+			}
+		}(f)
+		r = f
+	}
+	report, err := importlogic.ImportICS(c.db, user, r, *commit)
+	if err != nil {
+		return c.fail(exitCode(err), err)
+	}
+	return c.output(report, func() {
+		if err := importlogic.WriteReport(c.stdout, report); err != nil {
+			fmt.Fprintln(c.stderr, "erreur :", err)
+		}
+	})
+}
+
+// Import en masse de salles ou de réservations au format des exports CSV et JSON
+func (c *cli) importBulk(kind string, args []string) int {
+	fs := c.newFlagSet("import " + kind)
+	file := fs.String("file", "", "fichier à importer (- pour stdin)")
+	fileFormat := fs.String("input-format", "", "format du fichier : csv ou json (déduit de l'extension par défaut)")
+	mode := fs.String("mode", importlogic.ModeDryRun, "dry-run, partial ou all-or-nothing")
+	if _, err := c.parse(fs, args); err != nil {
+		return ExitUsage
+	}
+	if *file == "" {
+		return c.usageError("--file est obligatoire")
+	}
+	if *fileFormat == "" {
+		*fileFormat = strings.TrimPrefix(strings.ToLower(filepath.Ext(*file)), ".")
+	}
+
+	var r io.Reader = os.Stdin
+	if *file != "-" {
+		f, err := os.Open(*file)
+		if err != nil {
+			return c.fail(ExitError, err)
+		}
+		defer func(f *os.File) {
+			if err := f.Close(); err != nil {
+				log.Printf("Erreur: %v", err)
+			}
+		}(f)
+		r = f
+	}
+	importFile := importlogic.ImportReservations
+	if kind == "rooms" {
+		importFile = importlogic.ImportRooms
+	}
+	report, err := importFile(c.db, r, *fileFormat, *mode)
+	if err != nil {
+		return c.fail(exitCode(err), err)
+	}
+	code := c.output(report, func() {
+		if err := importlogic.WriteBulkReport(c.stdout, report); err != nil {
+			fmt.Fprintln(c.stderr, "erreur :", err)
+		}
+	})
+	if code == ExitOK && report.Aborted {
+		return ExitRefused
+	}
+	return code
+}
+
+func (c *cli) feedsList(args []string) int {
+	fs := c.newFlagSet("feeds list")
+	if _, err := c.parse(fs, args); err != nil {
+		return ExitUsage
+	}
+	feeds, err := icallogic.GetFeeds(c.db)
+	if err != nil {
+		return c.fail(ExitError, err)
+	}
+	return c.output(feeds, func() {
+		for _, f := range feeds {
+			state := "actif"
+			if f.RevokedAt != "" {
+				state = "révoqué le " + f.RevokedAt
+			}
+			fmt.Fprintf(c.stdout, "%s\t%s\tcréé le %s\t%s\n", f.Token, describeFeed(f), f.CreatedAt, state)
+		}
+	})
+}
+
+func (c *cli) feedsCreate(args []string) int {
+	fs := c.newFlagSet("feeds create")
+	var scope icallogic.Scope
+	fs.IntVar(&scope.RoomID, "room", 0, "flux des réservations d'une salle")
+	fs.IntVar(&scope.UserID, "user", 0, "flux des réservations d'un utilisateur")
+	baseURL := fs.String("base-url", "http://localhost"+apilogic.DefaultAddr, "adresse du serveur web, reprise dans l'URL du flux")
+	if _, err := c.parse(fs, args); err != nil {
+		return ExitUsage
+	}
+	if (scope.RoomID == 0) == (scope.UserID == 0) {
+		return c.usageError("--room ou --user est obligatoire (un seul des deux)")
+	}
+	feed, err := icallogic.CreateFeed(c.db, scope)
+	if err != nil {
+		return c.fail(exitCode(err), err)
+	}
+	url := strings.TrimRight(*baseURL, "/") + icallogic.FeedPath(feed.Token)
+	result := map[string]string{"token": feed.Token, "url": url}
+	return c.output(result, func() { fmt.Fprintln(c.stdout, url) })
+}
+
+func (c *cli) feedsRevoke(args []string) int {
+	fs := c.newFlagSet("feeds revoke")
+	positional, err := c.parse(fs, args)
+	if err != nil {
+		return ExitUsage
+	}
+	if len(positional) != 1 {
+		return c.usageError("jeton du flux attendu")
+	}
+	if err := icallogic.RevokeFeed(c.db, positional[0]); err != nil {
+		return c.fail(exitCode(err), err)
+	}
+	return c.output(map[string]string{"revoked": positional[0]}, func() { fmt.Fprintln(c.stdout, positional[0]) })
+}
+
+func describeFeed(f models.Feed) string {
+	if f.RoomID != 0 {
+		return fmt.Sprintf("salle %d", f.RoomID)
+	}
+	return fmt.Sprintf("utilisateur %d", f.UserID)
+}
+
+func (c *cli) tokensList(args []string) int {
+	fs := c.newFlagSet("tokens list")
+	if _, err := c.parse(fs, args); err != nil {
+		return ExitUsage
+	}
+	tokens, err := userlogic.GetTokens(c.db)
+	if err != nil {
+		return c.fail(ExitError, err)
+	}
+	return c.output(tokens, func() {
+		for _, t := range tokens {
+			state := "actif"
+			if t.RevokedAt != "" {
+				state = "révoqué le " + t.RevokedAt
+			}
+			fmt.Fprintf(c.stdout, "%s\tutilisateur %d\tcréé le %s\t%s\n", t.Token, t.UserID, t.CreatedAt, state)
+		}
+	})
+}
+
+func (c *cli) tokensCreate(args []string) int {
+	fs := c.newFlagSet("tokens create")
+	userID := fs.Int("user", 0, "utilisateur au nom duquel les requêtes sont faites")
+	if _, err := c.parse(fs, args); err != nil {
+		return ExitUsage
+	}
+	if *userID == 0 {
+		return c.usageError("--user est obligatoire")
+	}
+	token, err := userlogic.CreateToken(c.db, *userID)
+	if err != nil {
+		return c.fail(exitCode(err), err)
+	}
+	return c.output(map[string]string{"token": token.Token}, func() { fmt.Fprintln(c.stdout, token.Token) })
+}
+
+func (c *cli) tokensRevoke(args []string) int {
+	fs := c.newFlagSet("tokens revoke")
+	positional, err := c.parse(fs, args)
+	if err != nil {
+		return ExitUsage
+	}
+	if len(positional) != 1 {
+		return c.usageError("jeton d'API attendu")
+	}
+	if err := userlogic.RevokeToken(c.db, positional[0]); err != nil {
+		return c.fail(exitCode(err), err)
+	}
+	return c.output(map[string]string{"revoked": positional[0]}, func() { fmt.Fprintln(c.stdout, positional[0]) })
+}
+
+func (c *cli) serve(args []string) int {
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	addr := fs.String("addr", apilogic.DefaultAddr, "adresse d'écoute")
+	if _, err := c.parse(fs, args); err != nil {
+		return ExitUsage
+	}
+
+	// Le serveur tourne en continu : les réservations sans check-in sont libérées au fil de l'eau
+	stopNoShowJob := reservationlogic.StartNoShowJob(c.db, reservationlogic.NoShowCheckInterval)
+	defer stopNoShowJob()
+
+	fmt.Fprintln(c.stderr, "Serveur web à l'écoute sur", *addr)
+	if err := apilogic.ListenAndServe(*addr, weblogic.NewHandler(c.db)); err != nil {
+		return c.fail(ExitError, err)
+	}
+	return ExitOK
+}
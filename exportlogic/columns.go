@@ -0,0 +1,110 @@
+package exportlogic
+
+import (
+	"Reserve-Go/models"
+	"Reserve-Go/reservationlogic"
+	"strconv"
+	"strings"
+)
+
+// Colonne d'un export de réservations : nom (en-tête CSV ou clé JSON) et valeur
+type Column struct {
+	Name string
+	kind columnKind
+	// Valeur sous forme de texte, quel que soit son type
+	value func(reservationlogic.ReservationDetail) string
+}
+
+// Type d'une colonne : les entiers sont écrits sans guillemets en JSON, les dates et
+// heures deviennent des cellules typées en XLSX
+type columnKind int
+
+const (
+	kindString columnKind = iota
+	kindInt
+	kindDate
+	kindTime
+)
+
+func intColumn(name string, value func(reservationlogic.ReservationDetail) int) Column {
+	return Column{Name: name, kind: kindInt, value: func(d reservationlogic.ReservationDetail) string {
+		return strconv.Itoa(value(d))
+	}}
+}
+
+func stringColumn(name string, value func(reservationlogic.ReservationDetail) string) Column {
+	return Column{Name: name, value: value}
+}
+
+// Colonnes disponibles, dans l'ordre de l'export complet (--columns all)
+var AllColumns = []Column{
+	intColumn("ID", func(d reservationlogic.ReservationDetail) int { return d.ID }),
+	intColumn("RoomID", func(d reservationlogic.ReservationDetail) int { return d.RoomID }),
+	stringColumn("RoomName", func(d reservationlogic.ReservationDetail) string { return d.RoomName }),
+	intColumn("RoomCapacity", func(d reservationlogic.ReservationDetail) int { return d.RoomCapacity }),
+	stringColumn("Building", func(d reservationlogic.ReservationDetail) string { return d.Building }),
+	intColumn("UserID", func(d reservationlogic.ReservationDetail) int { return d.UserID }),
+	stringColumn("UserName", func(d reservationlogic.ReservationDetail) string { return d.UserName }),
+	stringColumn("UserGroup", func(d reservationlogic.ReservationDetail) string { return d.UserGroup }),
+	{Name: "Date", kind: kindDate, value: func(d reservationlogic.ReservationDetail) string { return d.Date }},
+	{Name: "StartTime", kind: kindTime, value: func(d reservationlogic.ReservationDetail) string { return d.StartTime }},
+	{Name: "EndTime", kind: kindTime, value: func(d reservationlogic.ReservationDetail) string { return d.EndTime }},
+	stringColumn("Status", func(d reservationlogic.ReservationDetail) string { return d.Status }),
+	stringColumn("GroupID", func(d reservationlogic.ReservationDetail) string { return d.GroupID }),
+	stringColumn("SeriesID", func(d reservationlogic.ReservationDetail) string { return d.SeriesID }),
+}
+
+// Colonnes par défaut : celles de ReservationCSVHeader en CSV, les champs de
+// models.Reservation en JSON, pour que les exports restent relisibles par l'import
+func defaultColumns(format string) []Column {
+	names := ReservationCSVHeader
+	if format != "csv" {
+		names = []string{"ID", "RoomID", "UserID", "Date", "StartTime", "EndTime", "GroupID", "SeriesID", "Status"}
+	}
+	columns, _ := ParseColumns(strings.Join(names, ","))
+	return columns
+}
+
+// Lit une liste de colonnes séparées par des virgules, sans tenir compte de la casse ;
+// « all » donne toutes les colonnes. Une liste vide renvoie nil : les colonnes par
+// défaut du format sont alors utilisées.
+func ParseColumns(spec string) ([]Column, error) {
+	if strings.EqualFold(strings.TrimSpace(spec), "all") {
+		return AllColumns, nil
+	}
+	var columns []Column
+	seen := map[string]bool{}
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		column, ok := findColumn(part)
+		if !ok {
+			return nil, models.Errorf(models.ErrInvalid, "colonne inconnue : %q (%s ou all)", part, strings.Join(ColumnNames(AllColumns), ", "))
+		}
+		if seen[column.Name] {
+			return nil, models.Errorf(models.ErrInvalid, "colonne %s demandée deux fois", column.Name)
+		}
+		seen[column.Name] = true
+		columns = append(columns, column)
+	}
+	return columns, nil
+}
+
+func findColumn(name string) (Column, bool) {
+	for _, column := range AllColumns {
+		if strings.EqualFold(column.Name, name) {
+			return column, true
+		}
+	}
+	return Column{}, false
+}
+
+func ColumnNames(columns []Column) []string {
+	names := make([]string, len(columns))
+	for i, column := range columns {
+		names[i] = column.Name
+	}
+	return names
+}
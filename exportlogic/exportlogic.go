@@ -1,28 +1,67 @@
 package exportlogic
 
 import (
-	"Reserve-Go/menulogic"
+	"Reserve-Go/icallogic"
+	"Reserve-Go/models"
 	"Reserve-Go/reservationlogic"
-	"bufio"
+	"Reserve-Go/roomlogic"
 	"database/sql"
 	"encoding/csv"
 	"encoding/json"
-	"io/ioutil"
+	"io"
 	"log"
 	"os"
 	"strconv"
+	"time"
 )
 
-func ExportReservationsAsCSV(db *sql.DB, filename string, scanner *bufio.Scanner) error {
-	reservations, err := reservationlogic.GetAllReservations(db)
+// Exporte toutes les réservations dans un fichier CSV
+func ExportReservationsAsCSV(db *sql.DB, filename string) error {
+	return ExportReservations(db, filename, "csv", reservationlogic.Filter{}, nil)
+}
+
+// Exporte toutes les réservations dans un fichier JSON
+func ExportReservationsAsJSON(db *sql.DB, filename string) error {
+	return ExportReservations(db, filename, "json", reservationlogic.Filter{}, nil)
+}
+
+// Exporte au format csv, json ou ndjson les réservations retenues par filter, avec les
+// colonnes demandées (nil pour celles du format). Les réservations sont écrites au fil de
+// la lecture ; en cas d'erreur, le fichier incomplet est supprimé.
+func ExportReservations(db *sql.DB, filename, format string, filter reservationlogic.Filter, columns []Column) error {
+	// Format et critères sont vérifiés avant de créer le fichier
+	if _, err := newEncoder(io.Discard, format, columns); err != nil {
+		return err
+	}
+	if err := filter.Validate(); err != nil {
+		return err
+	}
+
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	err = StreamReservations(db, file, format, filter, columns)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		if rmErr := os.Remove(filename); rmErr != nil {
+			log.Printf("Erreur: %v", rmErr)
+		}
+	}
+	return err
+}
+
+// Exporte au format iCalendar les réservations d'une salle, d'un utilisateur ou toutes
+func ExportReservationsAsICS(db *sql.DB, filename string, scope icallogic.Scope) error {
+	calendar, err := icallogic.BuildCalendar(db, scope)
 	if err != nil {
-		log.Printf("Error fetching reservations: %v", err)
 		return err
 	}
 
 	file, err := os.Create(filename)
 	if err != nil {
-		log.Printf("Error creating CSV file: %v", err)
 		return err
 	}
 	defer func(file *os.File) {
@@ -32,44 +71,67 @@ func ExportReservationsAsCSV(db *sql.DB, filename string, scanner *bufio.Scanner
 		}
 	}(file)
 
-	writer := csv.NewWriter(file)
-	defer writer.Flush()
+	return icallogic.Write(file, calendar, time.Now())
+}
+
+// Colonnes des exports CSV, reprises à l'identique par les imports
+var (
+	ReservationCSVHeader = []string{"ID", "RoomID", "UserID", "Date", "StartTime", "EndTime"}
+	RoomCSVHeader        = []string{"ID", "Name", "Capacity", "BufferBefore", "BufferAfter", "Features", "Building"}
+)
+
+func WriteReservationsCSV(w io.Writer, reservations []models.Reservation) error {
+	return writeReservations(w, "csv", reservations)
+}
+
+// Même document que json.MarshalIndent, un tableau vide s'écrivant []
+func WriteReservationsJSON(w io.Writer, reservations []models.Reservation) error {
+	return writeReservations(w, "json", reservations)
+}
+
+// Une réservation JSON par ligne
+func WriteReservationsNDJSON(w io.Writer, reservations []models.Reservation) error {
+	return writeReservations(w, "ndjson", reservations)
+}
 
-	header := []string{"ID", "RoomID", "Date", "StartTime", "EndTime"}
-	if err := writer.Write(header); err != nil {
-		log.Printf("Error writing header to CSV: %v", err)
+// Colonnes par défaut du format : la salle et l'utilisateur ne sont pas connus ici
+func writeReservations(w io.Writer, format string, reservations []models.Reservation) error {
+	encoder, err := newEncoder(w, format, nil)
+	if err != nil {
 		return err
 	}
+	return encode(encoder, reservations)
+}
 
-	for _, reservation := range reservations {
+// Les équipements sont séparés par des virgules dans une seule colonne
+func WriteRoomsCSV(w io.Writer, rooms []models.Room) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(RoomCSVHeader); err != nil {
+		return err
+	}
+	for _, room := range rooms {
 		record := []string{
-			strconv.Itoa(reservation.ID),
-			strconv.Itoa(reservation.RoomID),
-			reservation.Date,
-			reservation.StartTime,
-			reservation.EndTime,
+			strconv.Itoa(room.ID),
+			room.Name,
+			strconv.Itoa(room.Capacity),
+			strconv.Itoa(room.BufferBefore),
+			strconv.Itoa(room.BufferAfter),
+			roomlogic.JoinFeatures(room.Features),
+			room.Building,
 		}
 		if err := writer.Write(record); err != nil {
-			log.Printf("Error writing record to CSV: %v", err)
 			return err
 		}
 	}
-
-	log.Printf("Reservations successfully exported to %s", filename)
-	menulogic.NavigationOptions(db, scanner)
-	return nil
+	writer.Flush()
+	return writer.Error()
 }
 
-func ExportReservationsAsJSON(db *sql.DB, filename string, scanner *bufio.Scanner) error {
-	reservations, err := reservationlogic.GetAllReservations(db)
-	if err != nil {
-		return err
-	}
-
-	data, err := json.MarshalIndent(reservations, "", "    ")
+func WriteRoomsJSON(w io.Writer, rooms []models.Room) error {
+	data, err := json.MarshalIndent(rooms, "", "    ")
 	if err != nil {
 		return err
 	}
-	menulogic.NavigationOptions(db, scanner)
-	return ioutil.WriteFile(filename, data, 0644)
+	_, err = w.Write(append(data, '\n'))
+	return err
 }
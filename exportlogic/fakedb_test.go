@@ -0,0 +1,108 @@
+package exportlogic
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"strconv"
+)
+
+// Base factice pour les benchmarks : toute requête renvoie n réservations détaillées
+// (colonnes de reservationlogic.EachReservationDetail), produites une à une au fil du
+// curseur sans être gardées en mémoire. Comme le pilote MySQL sans paramètres (protocole
+// texte), les valeurs sont des []byte valables jusqu'à la ligne suivante.
+func openFakeDB(n int) *sql.DB {
+	return sql.OpenDB(fakeConnector{rows: n})
+}
+
+type fakeConnector struct {
+	rows int
+}
+
+func (c fakeConnector) Connect(context.Context) (driver.Conn, error) {
+	return &fakeConn{rows: c.rows}, nil
+}
+
+func (c fakeConnector) Driver() driver.Driver {
+	return fakeDriver{}
+}
+
+type fakeDriver struct{}
+
+func (fakeDriver) Open(string) (driver.Conn, error) {
+	return nil, errors.New("base factice : utiliser openFakeDB")
+}
+
+type fakeConn struct {
+	rows int
+}
+
+func (c *fakeConn) Prepare(string) (driver.Stmt, error) {
+	return &fakeStmt{rows: c.rows}, nil
+}
+
+func (c *fakeConn) Close() error {
+	return nil
+}
+
+func (c *fakeConn) Begin() (driver.Tx, error) {
+	return nil, errors.New("base factice : lecture seule")
+}
+
+type fakeStmt struct {
+	rows int
+}
+
+func (s *fakeStmt) Close() error {
+	return nil
+}
+
+func (s *fakeStmt) NumInput() int {
+	return -1
+}
+
+func (s *fakeStmt) Exec([]driver.Value) (driver.Result, error) {
+	return nil, errors.New("base factice : lecture seule")
+}
+
+func (s *fakeStmt) Query([]driver.Value) (driver.Rows, error) {
+	return &fakeRows{remaining: s.rows}, nil
+}
+
+var fakeColumns = []string{"id", "room_id", "user_id", "date", "start_time", "end_time", "group_id", "series_id", "status",
+	"name", "capacity", "building", "name", "group_name"}
+
+// Valeurs communes à toutes les lignes, après l'identifiant
+var fakeValues = [][]byte{[]byte("3"), []byte("7"), []byte("2024-05-13"), []byte("09:00:00"), []byte("10:00:00"),
+	[]byte(""), []byte(""), []byte("confirmed"), []byte("Salle « Vauban »"), []byte("12"), []byte("Bâtiment A"),
+	[]byte("Camille Martin"), []byte("Informatique")}
+
+type fakeRows struct {
+	remaining int
+	id        int
+	buf       []byte
+}
+
+func (r *fakeRows) Columns() []string {
+	return fakeColumns
+}
+
+func (r *fakeRows) Close() error {
+	return nil
+}
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.remaining == 0 {
+		return io.EOF
+	}
+	r.remaining--
+	r.id++
+	r.buf = strconv.AppendInt(r.buf[:0], int64(r.id), 10)
+	dest[0] = r.buf
+	for i, value := range fakeValues {
+		dest[i+1] = value
+	}
+	return nil
+}
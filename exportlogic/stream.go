@@ -0,0 +1,171 @@
+package exportlogic
+
+import (
+	"Reserve-Go/models"
+	"Reserve-Go/reservationlogic"
+	"bufio"
+	"bytes"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+)
+
+// Formats d'export des réservations
+var ReservationFormats = []string{"csv", "json", "ndjson", "xlsx"}
+
+// Écrit dans w, au format csv, json, ndjson ou xlsx, les réservations retenues par filter
+// au fil de la lecture du curseur : une seule réservation est en mémoire à la fois, quel
+// que soit le nombre de lignes exportées. Sans colonnes (nil), celles du format sont
+// utilisées. En xlsx, les réservations sont triées par salle (une feuille par salle).
+func StreamReservations(db *sql.DB, w io.Writer, format string, filter reservationlogic.Filter, columns []Column) error {
+	encoder, err := newEncoder(w, format, columns)
+	if err != nil {
+		return err
+	}
+	if format == "xlsx" {
+		filter.Sort = "room"
+	}
+	if err := encoder.begin(); err != nil {
+		return err
+	}
+	if err := reservationlogic.EachReservationDetail(db, filter, encoder.row); err != nil {
+		return err
+	}
+	return encoder.end()
+}
+
+// Écriture d'un export réservation par réservation : begin, row pour chacune, puis end
+type reservationEncoder interface {
+	begin() error
+	row(reservationlogic.ReservationDetail) error
+	end() error
+}
+
+func newEncoder(w io.Writer, format string, columns []Column) (reservationEncoder, error) {
+	if format == "xlsx" {
+		return newXLSXEncoder(w, columns), nil
+	}
+	if len(columns) == 0 {
+		columns = defaultColumns(format)
+	}
+	switch format {
+	case "csv":
+		return &csvEncoder{writer: csv.NewWriter(w), columns: columns}, nil
+	case "json":
+		return &jsonEncoder{w: bufio.NewWriter(w), columns: columns, indent: "    "}, nil
+	case "ndjson":
+		return &jsonEncoder{w: bufio.NewWriter(w), columns: columns, lines: true}, nil
+	}
+	return nil, models.Errorf(models.ErrInvalid, "format d'export inconnu : %q (csv, json, ndjson ou xlsx)", format)
+}
+
+func encode(encoder reservationEncoder, reservations []models.Reservation) error {
+	if err := encoder.begin(); err != nil {
+		return err
+	}
+	for _, reservation := range reservations {
+		if err := encoder.row(reservationlogic.ReservationDetail{Reservation: reservation}); err != nil {
+			return err
+		}
+	}
+	return encoder.end()
+}
+
+// csv.Writer vide son tampon au fil de l'eau
+type csvEncoder struct {
+	writer  *csv.Writer
+	columns []Column
+	record  []string
+}
+
+func (e *csvEncoder) begin() error {
+	e.record = make([]string, len(e.columns))
+	return e.writer.Write(ColumnNames(e.columns))
+}
+
+func (e *csvEncoder) row(d reservationlogic.ReservationDetail) error {
+	for i, column := range e.columns {
+		e.record[i] = column.value(d)
+	}
+	return e.writer.Write(e.record)
+}
+
+func (e *csvEncoder) end() error {
+	e.writer.Flush()
+	return e.writer.Error()
+}
+
+// Tableau JSON indenté comme json.MarshalIndent(reservations, "", indent), ou un objet
+// compact par ligne (NDJSON), écrit élément par élément
+type jsonEncoder struct {
+	w       *bufio.Writer
+	columns []Column
+	indent  string
+	lines   bool
+	count   int
+	// Élément en cours d'écriture, réutilisé d'une réservation à l'autre
+	buf bytes.Buffer
+}
+
+func (e *jsonEncoder) begin() error {
+	return nil
+}
+
+func (e *jsonEncoder) row(d reservationlogic.ReservationDetail) error {
+	e.buf.Reset()
+	switch {
+	case e.lines:
+	case e.count == 0:
+		e.buf.WriteString("[\n" + e.indent)
+	default:
+		e.buf.WriteString(",\n" + e.indent)
+	}
+	e.count++
+
+	// Entre deux champs, et autour des champs d'un objet
+	separator, colon, open, close := ",", ":", "{", "}\n"
+	if !e.lines {
+		separator, colon = ",\n"+e.indent+e.indent, ": "
+		open, close = "{\n"+e.indent+e.indent, "\n"+e.indent+"}"
+	}
+	e.buf.WriteString(open)
+	for i, column := range e.columns {
+		if i > 0 {
+			e.buf.WriteString(separator)
+		}
+		key, err := json.Marshal(column.Name)
+		if err != nil {
+			return err
+		}
+		e.buf.Write(key)
+		e.buf.WriteString(colon)
+		value := column.value(d)
+		if column.kind == kindInt {
+			e.buf.WriteString(value)
+			continue
+		}
+		data, err := json.Marshal(value)
+		if err != nil {
+			return err
+		}
+		e.buf.Write(data)
+	}
+	e.buf.WriteString(close)
+	_, err := e.w.Write(e.buf.Bytes())
+	return err
+}
+
+func (e *jsonEncoder) end() error {
+	closing := "\n]\n"
+	switch {
+	case e.lines:
+		closing = ""
+	case e.count == 0:
+		closing = "[]\n"
+	}
+	if _, err := e.w.WriteString(closing); err != nil {
+		return err
+	}
+	return e.w.Flush()
+}
@@ -0,0 +1,58 @@
+package exportlogic
+
+import (
+	"Reserve-Go/reservationlogic"
+	"database/sql"
+	"fmt"
+	"io"
+	"log"
+	"runtime"
+	"testing"
+)
+
+// Écrit dans io.Discard en relevant régulièrement le tas (runtime.ReadMemStats)
+type heapSampler struct {
+	writes int
+	// Plus grande taille du tas observée, en octets
+	peak uint64
+}
+
+func (s *heapSampler) Write(p []byte) (int, error) {
+	if s.writes%256 == 0 {
+		var stats runtime.MemStats
+		runtime.ReadMemStats(&stats)
+		if stats.HeapAlloc > s.peak {
+			s.peak = stats.HeapAlloc
+		}
+	}
+	s.writes++
+	return io.Discard.Write(p)
+}
+
+// Export complet (StreamReservations, curseur et rows.Scan compris) de dix mille puis d'un
+// million de réservations par format : le pic de tas doit rester du même ordre
+func BenchmarkStreamReservations(b *testing.B) {
+	for _, format := range []string{"csv", "json", "ndjson"} {
+		for _, n := range []int{10_000, 1_000_000} {
+			b.Run(fmt.Sprintf("%s/%d", format, n), func(b *testing.B) {
+				db := openFakeDB(n)
+				defer func(db *sql.DB) {
+					if err := db.Close(); err != nil {
+						log.Printf("Erreur: %v", err)
+					}
+				}(db)
+				w := &heapSampler{}
+				runtime.GC()
+				b.ReportAllocs()
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					if err := StreamReservations(db, w, format, reservationlogic.Filter{}, nil); err != nil {
+						b.Fatal(err)
+					}
+				}
+				b.ReportMetric(float64(w.peak), "peak-heap-B")
+				b.ReportMetric(float64(b.Elapsed().Nanoseconds())/float64(b.N*n), "ns/row")
+			})
+		}
+	}
+}
@@ -0,0 +1,440 @@
+package exportlogic
+
+import (
+	"Reserve-Go/models"
+	"Reserve-Go/planninglogic"
+	"Reserve-Go/reservationlogic"
+	"Reserve-Go/utils"
+	"archive/zip"
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Classeur Excel (SpreadsheetML, ECMA-376) écrit sans dépendance : une feuille par salle,
+// dans l'ordre des salles, puis une feuille « Résumé » placée en tête du classeur. Les
+// réservations arrivent triées par salle, chaque feuille est écrite au fil de la lecture.
+
+// Nombre maximal de lignes d'une feuille Excel
+const xlsxMaxRows = 1048576
+
+const summarySheetName = "Résumé"
+
+// Colonnes par défaut des feuilles de salle
+var xlsxDefaultColumns = []string{"ID", "Date", "StartTime", "EndTime", "UserName", "UserGroup", "Status"}
+
+// En-têtes en français des feuilles de salle
+var xlsxHeaders = map[string]string{
+	"ID":           "N°",
+	"RoomID":       "ID salle",
+	"RoomName":     "Salle",
+	"RoomCapacity": "Capacité",
+	"Building":     "Bâtiment",
+	"UserID":       "ID utilisateur",
+	"UserName":     "Utilisateur",
+	"UserGroup":    "Groupe",
+	"Date":         "Date",
+	"StartTime":    "Début",
+	"EndTime":      "Fin",
+	"Status":       "Statut",
+	"GroupID":      "Groupe multi-salles",
+	"SeriesID":     "Série",
+}
+
+var summaryHeaders = []string{"Salle", "Bâtiment", "Capacité", "Réservations", "Actives", "Annulées", "Non honorées", "Heures réservées", "Première date", "Dernière date"}
+
+// Styles de cellule, indices de cellXfs dans styles.xml
+const (
+	styleDefault = iota
+	styleHeader
+	styleDate
+	styleTime
+	styleDuration
+)
+
+// Origine des dates Excel : le nombre de jours depuis le 30/12/1899
+var excelEpoch = time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+
+type xlsxEncoder struct {
+	zip     *zip.Writer
+	columns []Column
+	// Feuille en cours d'écriture (nil avant la première réservation)
+	sheet  *bufio.Writer
+	roomID int
+	// Dernière ligne écrite dans la feuille en cours
+	line int
+	// Feuilles de salle déjà commencées, dans l'ordre
+	sheets []xlsxSheet
+	names  map[string]bool
+}
+
+// Feuille de salle et statistiques reprises dans le résumé
+type xlsxSheet struct {
+	name      string
+	rows      int
+	room      string
+	building  string
+	capacity  int
+	total     int
+	active    int
+	cancelled int
+	noShow    int
+	minutes   int
+	first     string
+	last      string
+}
+
+func newXLSXEncoder(w io.Writer, columns []Column) *xlsxEncoder {
+	if len(columns) == 0 {
+		columns, _ = ParseColumns(strings.Join(xlsxDefaultColumns, ","))
+	}
+	return &xlsxEncoder{zip: zip.NewWriter(w), columns: columns, names: map[string]bool{strings.ToLower(summarySheetName): true}}
+}
+
+func (e *xlsxEncoder) begin() error {
+	return nil
+}
+
+func (e *xlsxEncoder) row(d reservationlogic.ReservationDetail) error {
+	if e.sheet == nil || d.RoomID != e.roomID {
+		if err := e.startSheet(d); err != nil {
+			return err
+		}
+	}
+	sheet := &e.sheets[len(e.sheets)-1]
+	if e.line == xlsxMaxRows {
+		return models.Errorf(models.ErrInvalid, "la salle %s dépasse le nombre de lignes d'une feuille Excel", sheet.room)
+	}
+	e.line++
+	sheet.rows = e.line
+
+	cells := make([]string, len(e.columns))
+	for i, column := range e.columns {
+		cells[i] = e.cell(i, column, d)
+	}
+	if err := writeRow(e.sheet, e.line, cells); err != nil {
+		return err
+	}
+
+	sheet.total++
+	switch d.Status {
+	case models.StatusConfirmed, models.StatusCheckedIn:
+		sheet.active++
+		if start, err := utils.ParseClock(d.StartTime); err == nil {
+			if end, err := utils.ParseClock(d.EndTime); err == nil {
+				sheet.minutes += end - start
+			}
+		}
+	case models.StatusCancelled:
+		sheet.cancelled++
+	case models.StatusNoShow:
+		sheet.noShow++
+	}
+	if sheet.first == "" || d.Date < sheet.first {
+		sheet.first = d.Date
+	}
+	if d.Date > sheet.last {
+		sheet.last = d.Date
+	}
+	return nil
+}
+
+// Cellule typée selon la colonne ; une date ou une heure illisible reste du texte
+func (e *xlsxEncoder) cell(i int, column Column, d reservationlogic.ReservationDetail) string {
+	ref := cellRef(i, e.line)
+	value := column.value(d)
+	switch column.kind {
+	case kindInt:
+		return numberCell(ref, value, styleDefault)
+	case kindDate:
+		if date, err := utils.ParseDate(value); err == nil {
+			return numberCell(ref, strconv.Itoa(excelDays(date)), styleDate)
+		}
+	case kindTime:
+		if minutes, err := utils.ParseClock(value); err == nil {
+			return numberCell(ref, dayFraction(minutes), styleTime)
+		}
+	}
+	if column.Name == "Status" {
+		value = planninglogic.StatusLabel(value)
+	}
+	return stringCell(ref, value, styleDefault)
+}
+
+// Termine la feuille en cours et commence celle de la salle de d
+func (e *xlsxEncoder) startSheet(d reservationlogic.ReservationDetail) error {
+	if err := e.endSheet(); err != nil {
+		return err
+	}
+	room := d.RoomName
+	if room == "" {
+		room = fmt.Sprintf("Salle %d", d.RoomID)
+	}
+	sheet := xlsxSheet{name: e.sheetName(room), rows: 1, room: room, building: d.Building, capacity: d.RoomCapacity}
+	e.sheets = append(e.sheets, sheet)
+	e.roomID, e.line = d.RoomID, 1
+
+	// La feuille 1 est le résumé, écrit à la fin
+	f, err := e.zip.Create(fmt.Sprintf("xl/worksheets/sheet%d.xml", len(e.sheets)+1))
+	if err != nil {
+		return err
+	}
+	e.sheet = bufio.NewWriter(f)
+	headers := make([]string, len(e.columns))
+	widths := make([]int, len(e.columns))
+	for i, column := range e.columns {
+		headers[i] = xlsxHeaders[column.Name]
+		widths[i] = columnWidth(column)
+	}
+	return startWorksheet(e.sheet, headers, widths)
+}
+
+func (e *xlsxEncoder) endSheet() error {
+	if e.sheet == nil {
+		return nil
+	}
+	if err := endWorksheet(e.sheet, len(e.columns), e.line); err != nil {
+		return err
+	}
+	return e.sheet.Flush()
+}
+
+func (e *xlsxEncoder) end() error {
+	if err := e.endSheet(); err != nil {
+		return err
+	}
+	if err := e.writeSummary(); err != nil {
+		return err
+	}
+	for _, part := range e.packageParts() {
+		f, err := e.zip.Create(part.name)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(f, part.content); err != nil {
+			return err
+		}
+	}
+	return e.zip.Close()
+}
+
+// Une ligne par salle exportée, avec les totaux des réservations de la feuille
+func (e *xlsxEncoder) writeSummary() error {
+	f, err := e.zip.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	widths := []int{24, 16, 10, 13, 10, 10, 13, 16, 14, 14}
+	if err := startWorksheet(w, summaryHeaders, widths); err != nil {
+		return err
+	}
+	for i, sheet := range e.sheets {
+		row := i + 2
+		cells := []string{
+			stringCell(cellRef(0, row), sheet.room, styleDefault),
+			stringCell(cellRef(1, row), sheet.building, styleDefault),
+			numberCell(cellRef(2, row), strconv.Itoa(sheet.capacity), styleDefault),
+			numberCell(cellRef(3, row), strconv.Itoa(sheet.total), styleDefault),
+			numberCell(cellRef(4, row), strconv.Itoa(sheet.active), styleDefault),
+			numberCell(cellRef(5, row), strconv.Itoa(sheet.cancelled), styleDefault),
+			numberCell(cellRef(6, row), strconv.Itoa(sheet.noShow), styleDefault),
+			numberCell(cellRef(7, row), dayFraction(sheet.minutes), styleDuration),
+		}
+		for j, date := range []string{sheet.first, sheet.last} {
+			if parsed, err := utils.ParseDate(date); err == nil {
+				cells = append(cells, numberCell(cellRef(8+j, row), strconv.Itoa(excelDays(parsed)), styleDate))
+			}
+		}
+		if err := writeRow(w, row, cells); err != nil {
+			return err
+		}
+	}
+	if err := endWorksheet(w, len(summaryHeaders), len(e.sheets)+1); err != nil {
+		return err
+	}
+	return w.Flush()
+}
+
+// Nom de feuille valide et unique : 31 caractères au plus, sans []:*?/\
+func (e *xlsxEncoder) sheetName(room string) string {
+	clean := strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`[]:*?/\`, r) {
+			return '-'
+		}
+		return r
+	}, strings.TrimSpace(room))
+	clean = strings.Trim(clean, "'")
+	if clean == "" {
+		clean = "Salle"
+	}
+	name := truncateRunes(clean, 31)
+	for n := 2; e.names[strings.ToLower(name)]; n++ {
+		suffix := fmt.Sprintf(" (%d)", n)
+		name = truncateRunes(clean, 31-len(suffix)) + suffix
+	}
+	e.names[strings.ToLower(name)] = true
+	return name
+}
+
+func truncateRunes(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) > n {
+		return string(runes[:n])
+	}
+	return s
+}
+
+func columnWidth(column Column) int {
+	switch column.kind {
+	case kindInt, kindTime:
+		return 10
+	case kindDate:
+		return 12
+	}
+	return 20
+}
+
+func startWorksheet(w *bufio.Writer, headers []string, widths []int) error {
+	w.WriteString(xml.Header)
+	w.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">`)
+	// Ligne d'en-tête figée
+	w.WriteString(`<sheetViews><sheetView workbookViewId="0"><pane ySplit="1" topLeftCell="A2" activePane="bottomLeft" state="frozen"/><selection pane="bottomLeft" activeCell="A2" sqref="A2"/></sheetView></sheetViews>`)
+	w.WriteString(`<cols>`)
+	for i, width := range widths {
+		fmt.Fprintf(w, `<col min="%d" max="%d" width="%d" customWidth="1"/>`, i+1, i+1, width)
+	}
+	w.WriteString(`</cols><sheetData>`)
+	cells := make([]string, len(headers))
+	for i, header := range headers {
+		cells[i] = stringCell(cellRef(i, 1), header, styleHeader)
+	}
+	return writeRow(w, 1, cells)
+}
+
+// Ferme la feuille avec un filtre automatique sur l'en-tête et les lignes écrites
+func endWorksheet(w *bufio.Writer, columns, rows int) error {
+	_, err := fmt.Fprintf(w, `</sheetData><autoFilter ref="%s"/></worksheet>`, filterRange(columns, rows))
+	return err
+}
+
+func filterRange(columns, rows int) string {
+	return cellRef(0, 1) + ":" + cellRef(columns-1, rows)
+}
+
+func writeRow(w *bufio.Writer, row int, cells []string) error {
+	fmt.Fprintf(w, `<row r="%d">`, row)
+	for _, cell := range cells {
+		w.WriteString(cell)
+	}
+	_, err := w.WriteString(`</row>`)
+	return err
+}
+
+func stringCell(ref, value string, style int) string {
+	return fmt.Sprintf(`<c r="%s" s="%d" t="inlineStr"><is><t xml:space="preserve">%s</t></is></c>`, ref, style, escapeXML(value))
+}
+
+func escapeXML(value string) string {
+	var b strings.Builder
+	// strings.Builder ne renvoie jamais d'erreur
+	_ = xml.EscapeText(&b, []byte(value))
+	return b.String()
+}
+
+func numberCell(ref, value string, style int) string {
+	return fmt.Sprintf(`<c r="%s" s="%d"><v>%s</v></c>`, ref, style, value)
+}
+
+// Référence A1 de la colonne i (à partir de 0) et de la ligne row
+func cellRef(i, row int) string {
+	name := ""
+	for i++; i > 0; i = (i - 1) / 26 {
+		name = string(rune('A'+(i-1)%26)) + name
+	}
+	return name + strconv.Itoa(row)
+}
+
+func excelDays(date time.Time) int {
+	date = time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+	return int(date.Sub(excelEpoch).Hours() / 24)
+}
+
+// Durée en minutes exprimée en fraction de jour, l'unité des heures Excel
+func dayFraction(minutes int) string {
+	return strconv.FormatFloat(float64(minutes)/1440, 'f', -1, 64)
+}
+
+type xlsxPart struct {
+	name    string
+	content string
+}
+
+// Classeur, relations, types de contenu et styles
+func (e *xlsxEncoder) packageParts() []xlsxPart {
+	var sheets, names, rels, types strings.Builder
+	all := append([]xlsxSheet{{name: summarySheetName, rows: len(e.sheets) + 1}}, e.sheets...)
+	for i, sheet := range all {
+		columns := len(e.columns)
+		if i == 0 {
+			columns = len(summaryHeaders)
+		}
+		fmt.Fprintf(&sheets, `<sheet name="%s" sheetId="%d" r:id="rId%d"/>`, escapeXML(sheet.name), i+1, i+1)
+		// Plage du filtre automatique, attendue par Excel sous ce nom réservé
+		quoted := "'" + strings.ReplaceAll(sheet.name, "'", "''") + "'"
+		fmt.Fprintf(&names, `<definedName name="_xlnm._FilterDatabase" localSheetId="%d" hidden="1">%s!%s</definedName>`,
+			i, escapeXML(quoted), absoluteRange(columns, sheet.rows))
+		fmt.Fprintf(&rels, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet%d.xml"/>`, i+1, i+1)
+		fmt.Fprintf(&types, `<Override PartName="/xl/worksheets/sheet%d.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>`, i+1)
+	}
+	fmt.Fprintf(&rels, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>`, len(all)+1)
+
+	return []xlsxPart{
+		{"[Content_Types].xml", xml.Header + `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+			`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+			`<Default Extension="xml" ContentType="application/xml"/>` +
+			`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+			`<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>` +
+			types.String() + `</Types>`},
+		{"_rels/.rels", xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+			`</Relationships>`},
+		{"xl/workbook.xml", xml.Header + `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+			`<sheets>` + sheets.String() + `</sheets><definedNames>` + names.String() + `</definedNames></workbook>`},
+		{"xl/_rels/workbook.xml.rels", xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			rels.String() + `</Relationships>`},
+		{"xl/styles.xml", xlsxStyles},
+	}
+}
+
+func absoluteRange(columns, rows int) string {
+	from, to := cellRef(0, 1), cellRef(columns-1, rows)
+	return absolute(from) + ":" + absolute(to)
+}
+
+// $A$1 à partir de A1
+func absolute(ref string) string {
+	i := strings.IndexAny(ref, "0123456789")
+	return "$" + ref[:i] + "$" + ref[i:]
+}
+
+// Styles dans l'ordre des constantes style... : défaut, en-tête en gras, date, heure, durée
+var xlsxStyles = xml.Header + `<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+	`<numFmts count="3"><numFmt numFmtId="164" formatCode="dd/mm/yyyy"/><numFmt numFmtId="165" formatCode="hh:mm"/><numFmt numFmtId="166" formatCode="[h]:mm"/></numFmts>` +
+	`<fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts>` +
+	`<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>` +
+	`<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>` +
+	`<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>` +
+	`<cellXfs count="5">` +
+	`<xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/>` +
+	`<xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/>` +
+	`<xf numFmtId="164" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>` +
+	`<xf numFmtId="165" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>` +
+	`<xf numFmtId="166" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>` +
+	`</cellXfs>` +
+	`<cellStyles count="1"><cellStyle name="Normal" xfId="0" builtinId="0"/></cellStyles>` +
+	`</styleSheet>`
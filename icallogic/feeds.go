@@ -0,0 +1,127 @@
+package icallogic
+
+import (
+	"Reserve-Go/models"
+	"Reserve-Go/roomlogic"
+	"Reserve-Go/userlogic"
+	"Reserve-Go/utils"
+	"bytes"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"log"
+	"time"
+)
+
+const feedColumns = "token, COALESCE(room_id, 0), COALESCE(user_id, 0), created_at, COALESCE(revoked_at, '')"
+
+// Crée un flux pour une salle ou pour un utilisateur (exactement l'un des deux)
+func CreateFeed(db *sql.DB, scope Scope) (*models.Feed, error) {
+	if (scope.RoomID == 0) == (scope.UserID == 0) {
+		return nil, models.NewError(models.ErrInvalid, "un flux porte sur une salle ou sur un utilisateur")
+	}
+	if scope.RoomID != 0 {
+		if _, err := roomlogic.GetRoom(db, scope.RoomID); err != nil {
+			return nil, err
+		}
+	}
+	if scope.UserID != 0 {
+		if _, err := userlogic.GetUser(db, scope.UserID); err != nil {
+			return nil, err
+		}
+	}
+	token, err := utils.NewToken()
+	if err != nil {
+		return nil, err
+	}
+	_, err = db.Exec("INSERT INTO calendar_feeds (token, room_id, user_id) VALUES (?, NULLIF(?, 0), NULLIF(?, 0))",
+		token, scope.RoomID, scope.UserID)
+	if err != nil {
+		return nil, err
+	}
+	return getFeed(db, token)
+}
+
+func GetFeeds(db *sql.DB) ([]models.Feed, error) {
+	rows, err := db.Query("SELECT " + feedColumns + " FROM calendar_feeds ORDER BY created_at, token")
+	if err != nil {
+		return nil, err
+	}
+	defer func(rows *sql.Rows) {
+		rowErr := rows.Close()
+		if rowErr != nil {
+			log.Printf("Erreur: %v", rowErr)
+		}
+	}(rows)
+
+	var feeds []models.Feed
+	for rows.Next() {
+		var f models.Feed
+		if err := rows.Scan(&f.Token, &f.RoomID, &f.UserID, &f.CreatedAt, &f.RevokedAt); err != nil {
+			return nil, err
+		}
+		feeds = append(feeds, f)
+	}
+	return feeds, rows.Err()
+}
+
+// Flux actif correspondant au jeton ; un jeton révoqué est traité comme inconnu
+func GetActiveFeed(db *sql.DB, token string) (*models.Feed, error) {
+	feed, err := getFeed(db, token)
+	if err != nil {
+		return nil, err
+	}
+	if feed.RevokedAt != "" {
+		return nil, models.NewError(models.ErrNotFound, "flux de calendrier inconnu")
+	}
+	return feed, nil
+}
+
+func getFeed(db *sql.DB, token string) (*models.Feed, error) {
+	var f models.Feed
+	err := db.QueryRow("SELECT "+feedColumns+" FROM calendar_feeds WHERE token = ?", token).
+		Scan(&f.Token, &f.RoomID, &f.UserID, &f.CreatedAt, &f.RevokedAt)
+	if err == sql.ErrNoRows {
+		return nil, models.NewError(models.ErrNotFound, "flux de calendrier inconnu")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &f, nil
+}
+
+// Révoque un flux : son URL cesse immédiatement de répondre
+func RevokeFeed(db *sql.DB, token string) error {
+	result, err := db.Exec("UPDATE calendar_feeds SET revoked_at = NOW() WHERE token = ? AND revoked_at IS NULL", token)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return models.NewError(models.ErrNotFound, "flux de calendrier inconnu ou déjà révoqué")
+	}
+	return nil
+}
+
+// Chemin du flux sur le serveur web, à préfixer par son adresse
+func FeedPath(token string) string {
+	return "/api/feeds/" + token + "/reservations.ics"
+}
+
+func FeedScope(feed *models.Feed) Scope {
+	return Scope{RoomID: feed.RoomID, UserID: feed.UserID}
+}
+
+// Version du calendrier pour l'en-tête ETag : elle ne dépend que des événements, pas
+// de l'heure de génération, et ne change donc que si les réservations changent
+func ETag(calendar *Calendar) (string, error) {
+	var buf bytes.Buffer
+	if err := Write(&buf, calendar, time.Time{}); err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(buf.Bytes())
+	return `"` + hex.EncodeToString(sum[:16]) + `"`, nil
+}
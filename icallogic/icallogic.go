@@ -0,0 +1,327 @@
+package icallogic
+
+import (
+	"Reserve-Go/models"
+	"Reserve-Go/planninglogic"
+	"Reserve-Go/reservationlogic"
+	"Reserve-Go/roomlogic"
+	"Reserve-Go/userlogic"
+	"Reserve-Go/utils"
+	"database/sql"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Domaine des UID : un UID reste le même d'un export à l'autre pour que les
+// applications de calendrier mettent à jour l'événement au lieu de le dupliquer
+const uidDomain = "reserve-go"
+
+const productID = "-//Reserve-Go//Reservation de salles//FR"
+
+// Heures « flottantes » (sans fuseau) : une série reste à la même heure locale
+// de part et d'autre d'un changement d'heure
+const floatingLayout = "20060102T150405"
+
+// Périmètre d'un calendrier : une salle, un utilisateur, ou toutes les réservations (les deux à 0)
+type Scope struct {
+	RoomID int
+	UserID int
+}
+
+func (s Scope) includes(roomID, userID int) bool {
+	return (s.RoomID == 0 || s.RoomID == roomID) && (s.UserID == 0 || s.UserID == userID)
+}
+
+type Calendar struct {
+	Name   string
+	Events []Event
+}
+
+// Un VEVENT : une réservation, une série (RRule renseignée) ou une occurrence
+// modifiée d'une série (même UID que la série et RecurrenceID renseigné)
+type Event struct {
+	UID          string
+	RecurrenceID time.Time
+	Start        time.Time
+	End          time.Time
+	RRule        string
+	ExDates      []time.Time
+	Summary      string
+	Location     string
+	Description  string
+	// CONFIRMED ou CANCELLED
+	Status string
+}
+
+// Écrit au format iCalendar les réservations du périmètre
+func Export(db *sql.DB, w io.Writer, scope Scope, now time.Time) error {
+	calendar, err := BuildCalendar(db, scope)
+	if err != nil {
+		return err
+	}
+	return Write(w, calendar, now)
+}
+
+// Un événement par réservation du périmètre ; les séries récurrentes donnent un seul
+// événement avec sa règle de répétition, suivi de leurs occurrences modifiées ou annulées
+func BuildCalendar(db *sql.DB, scope Scope) (*Calendar, error) {
+	rooms, err := roomlogic.GetRooms(db)
+	if err != nil {
+		return nil, err
+	}
+	users, err := userlogic.GetUsers(db)
+	if err != nil {
+		return nil, err
+	}
+	reservations, err := reservationlogic.GetAllReservations(db)
+	if err != nil {
+		return nil, err
+	}
+
+	b := &builder{rooms: map[int]models.Room{}, users: map[int]string{}}
+	for _, room := range rooms {
+		b.rooms[room.ID] = room
+	}
+	for _, u := range users {
+		b.users[u.ID] = u.Name
+	}
+	calendar := &Calendar{Name: b.calendarName(scope)}
+	if scope.RoomID != 0 {
+		if _, ok := b.rooms[scope.RoomID]; !ok {
+			return nil, models.Errorf(models.ErrNotFound, "la salle avec l'ID %d n'existe pas", scope.RoomID)
+		}
+	}
+	if scope.UserID != 0 {
+		if _, ok := b.users[scope.UserID]; !ok {
+			return nil, models.Errorf(models.ErrNotFound, "l'utilisateur %d n'existe pas", scope.UserID)
+		}
+	}
+
+	series := map[string]*models.Series{}
+	for _, r := range reservations {
+		if r.SeriesID != "" {
+			s, seen := series[r.SeriesID]
+			if !seen {
+				if s, err = reservationlogic.GetSeries(db, r.SeriesID); err != nil {
+					return nil, err
+				}
+				series[r.SeriesID] = s
+				// La série entière est publiée à la place de sa première occurrence
+				if scope.includes(s.RoomID, s.UserID) {
+					events, err := b.seriesEvents(db, s)
+					if err != nil {
+						return nil, err
+					}
+					calendar.Events = append(calendar.Events, events...)
+				}
+			}
+			if scope.includes(s.RoomID, s.UserID) {
+				continue
+			}
+		}
+		// Occurrence déplacée dans la salle d'une série hors du périmètre : publiée seule
+		if scope.includes(r.RoomID, r.UserID) {
+			event, err := b.reservationEvent(r)
+			if err != nil {
+				return nil, err
+			}
+			calendar.Events = append(calendar.Events, event)
+		}
+	}
+	return calendar, nil
+}
+
+type builder struct {
+	rooms map[int]models.Room
+	users map[int]string
+}
+
+func (b *builder) calendarName(scope Scope) string {
+	switch {
+	case scope.RoomID != 0:
+		return "Réservations - " + b.roomName(scope.RoomID)
+	case scope.UserID != 0:
+		return "Réservations de " + planninglogic.UserLabel(scope.UserID, b.users)
+	default:
+		return "Réservations des salles"
+	}
+}
+
+func (b *builder) roomName(roomID int) string {
+	if room, ok := b.rooms[roomID]; ok {
+		return room.Name
+	}
+	return fmt.Sprintf("Salle %d", roomID)
+}
+
+func (b *builder) reservationEvent(r models.Reservation) (Event, error) {
+	start, end, err := bounds(r.Date, r.StartTime, r.EndTime)
+	if err != nil {
+		return Event{}, err
+	}
+	return Event{
+		UID:      fmt.Sprintf("reservation-%d@%s", r.ID, uidDomain),
+		Start:    start,
+		End:      end,
+		Summary:  "Réservation " + b.roomName(r.RoomID),
+		Location: b.roomName(r.RoomID),
+		Description: fmt.Sprintf("Réservée par %s (réservation %d, %s)",
+			planninglogic.UserLabel(r.UserID, b.users), r.ID, planninglogic.StatusLabel(r.Status)),
+		Status: eventStatus(r.Status),
+	}, nil
+}
+
+// Événement récurrent de la série, puis une exception par occurrence qui ne suit plus la règle
+func (b *builder) seriesEvents(db *sql.DB, s *models.Series) ([]Event, error) {
+	dates, err := reservationlogic.SeriesDates(*s)
+	if err != nil {
+		return nil, err
+	}
+	occurrences, err := reservationlogic.GetSeriesOccurrences(db, s.ID)
+	if err != nil {
+		return nil, err
+	}
+	byDate := make(map[string]models.Reservation, len(occurrences))
+	for _, o := range occurrences {
+		byDate[o.SeriesDate] = o.Reservation
+	}
+
+	start, end, err := bounds(s.StartDate, s.StartTime, s.EndTime)
+	if err != nil {
+		return nil, err
+	}
+	frequency := "WEEKLY"
+	if s.Frequency == models.FrequencyDaily {
+		frequency = "DAILY"
+	}
+	master := Event{
+		UID:         fmt.Sprintf("series-%s@%s", s.ID, uidDomain),
+		Start:       start,
+		End:         end,
+		RRule:       fmt.Sprintf("FREQ=%s;INTERVAL=%d;COUNT=%d", frequency, s.Interval, s.Count),
+		Summary:     "Réservation " + b.roomName(s.RoomID),
+		Location:    b.roomName(s.RoomID),
+		Description: "Série réservée par " + planninglogic.UserLabel(s.UserID, b.users),
+		Status:      "CONFIRMED",
+	}
+
+	var exceptions []Event
+	for _, date := range dates {
+		planned, _, err := bounds(date, s.StartTime, s.EndTime)
+		if err != nil {
+			return nil, err
+		}
+		r, ok := byDate[date]
+		if !ok {
+			// Occurrence supprimée de la base : simplement retirée de la série
+			master.ExDates = append(master.ExDates, planned)
+			continue
+		}
+		active := r.Status == models.StatusConfirmed || r.Status == models.StatusCheckedIn
+		if active && r.RoomID == s.RoomID && r.Date == date && r.StartTime == s.StartTime && r.EndTime == s.EndTime {
+			continue
+		}
+		exception, err := b.reservationEvent(r)
+		if err != nil {
+			return nil, err
+		}
+		exception.UID = master.UID
+		exception.RecurrenceID = planned
+		exceptions = append(exceptions, exception)
+	}
+	return append([]Event{master}, exceptions...), nil
+}
+
+func bounds(date, startTime, endTime string) (time.Time, time.Time, error) {
+	start, err := utils.ParseDateTime(date + " " + startTime)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	end, err := utils.ParseDateTime(date + " " + endTime)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	return start, end, nil
+}
+
+// Une réservation annulée ou libérée faute de check-in n'a plus lieu
+func eventStatus(status string) string {
+	if status == models.StatusConfirmed || status == models.StatusCheckedIn {
+		return "CONFIRMED"
+	}
+	return "CANCELLED"
+}
+
+// Écrit le calendrier au format RFC 5545 ; now sert d'horodatage (DTSTAMP)
+func Write(w io.Writer, calendar *Calendar, now time.Time) error {
+	var b strings.Builder
+	line := func(name, value string) {
+		writeFolded(&b, name+":"+value)
+	}
+	stamp := now.UTC().Format("20060102T150405Z")
+
+	line("BEGIN", "VCALENDAR")
+	line("VERSION", "2.0")
+	line("PRODID", productID)
+	line("CALSCALE", "GREGORIAN")
+	line("METHOD", "PUBLISH")
+	line("X-WR-CALNAME", escapeText(calendar.Name))
+	for _, e := range calendar.Events {
+		line("BEGIN", "VEVENT")
+		line("UID", e.UID)
+		line("DTSTAMP", stamp)
+		if !e.RecurrenceID.IsZero() {
+			line("RECURRENCE-ID", e.RecurrenceID.Format(floatingLayout))
+		}
+		line("DTSTART", e.Start.Format(floatingLayout))
+		line("DTEND", e.End.Format(floatingLayout))
+		if e.RRule != "" {
+			line("RRULE", e.RRule)
+		}
+		if len(e.ExDates) > 0 {
+			dates := make([]string, len(e.ExDates))
+			for i, d := range e.ExDates {
+				dates[i] = d.Format(floatingLayout)
+			}
+			line("EXDATE", strings.Join(dates, ","))
+		}
+		line("SUMMARY", escapeText(e.Summary))
+		line("LOCATION", escapeText(e.Location))
+		line("DESCRIPTION", escapeText(e.Description))
+		line("STATUS", e.Status)
+		line("END", "VEVENT")
+	}
+	line("END", "VCALENDAR")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// Échappement des valeurs texte (RFC 5545, 3.3.11)
+func escapeText(value string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`).Replace(value)
+}
+
+// Lignes de 75 octets au plus, suites commençant par une espace, sans couper un caractère UTF-8
+func writeFolded(b *strings.Builder, content string) {
+	limit := 75
+	for len(content) > limit {
+		cut := limit
+		for cut > 0 && !isRuneStart(content[cut]) {
+			cut--
+		}
+		b.WriteString(content[:cut])
+		b.WriteString("\r\n ")
+		content = content[cut:]
+		// L'espace de continuation compte dans les 75 octets
+		limit = 74
+	}
+	b.WriteString(content)
+	b.WriteString("\r\n")
+}
+
+func isRuneStart(c byte) bool {
+	return c&0xC0 != 0x80
+}
@@ -0,0 +1,489 @@
+package importlogic
+
+import (
+	"Reserve-Go/calendarlogic"
+	"Reserve-Go/exportlogic"
+	"Reserve-Go/models"
+	"Reserve-Go/roomlogic"
+	"Reserve-Go/userlogic"
+	"Reserve-Go/utils"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Modes d'import en masse
+const (
+	// Rien n'est écrit : le rapport indique ce que l'import ferait
+	ModeDryRun = "dry-run"
+	// Les lignes valides sont créées, les autres sont ignorées
+	ModePartial = "partial"
+	// Tout est créé, ou rien si une ligne est invalide ou en conflit ; les doublons et
+	// les lignes ignorées ne sont pas recréés, ce qui permet de relancer un import
+	ModeAllOrNothing = "all-or-nothing"
+)
+
+// Sorts propres à l'import en masse, en plus de OutcomeCreate et OutcomeConflict
+const (
+	OutcomeInvalid   = "invalid"
+	OutcomeDuplicate = "duplicate"
+)
+
+// Résultat d'une ligne du fichier
+type RowResult struct {
+	// Numéro de la ligne (CSV, en-tête compris) ou de l'élément (JSON, à partir de 1)
+	Row int
+	// Identifiant indiqué dans le fichier (colonne ID)
+	SourceID int
+	Outcome  string
+	Reason   string
+	// Identifiant attribué en base (import confirmé uniquement)
+	CreatedID int
+}
+
+type BulkReport struct {
+	Mode      string
+	Committed bool
+	// Mode tout ou rien annulé parce qu'au moins une ligne est invalide ou en conflit
+	Aborted    bool
+	Rows       []RowResult
+	Created    int
+	Invalid    int
+	Duplicates int
+	Conflicts  int
+	Skipped    int
+}
+
+func (r *BulkReport) add(row RowResult) {
+	switch row.Outcome {
+	case OutcomeCreate:
+		r.Created++
+	case OutcomeInvalid:
+		r.Invalid++
+	case OutcomeDuplicate:
+		r.Duplicates++
+	case OutcomeConflict:
+		r.Conflicts++
+	case OutcomeSkip:
+		r.Skipped++
+	}
+	r.Rows = append(r.Rows, row)
+}
+
+func checkMode(mode, format string) error {
+	if mode != ModeDryRun && mode != ModePartial && mode != ModeAllOrNothing {
+		return models.Errorf(models.ErrInvalid, "mode d'import inconnu : %q (dry-run, partial ou all-or-nothing)", mode)
+	}
+	if format != "csv" && format != "json" {
+		return models.Errorf(models.ErrInvalid, "format d'import inconnu : %q (csv ou json)", format)
+	}
+	return nil
+}
+
+// Importe des salles au format de WriteRoomsCSV ou WriteRoomsJSON. Une salle dont le nom
+// existe déjà (en base ou plus haut dans le fichier) est un doublon et n'est pas recréée.
+func ImportRooms(db *sql.DB, r io.Reader, format, mode string) (*BulkReport, error) {
+	if err := checkMode(mode, format); err != nil {
+		return nil, err
+	}
+	rows, err := readRooms(r, format)
+	if err != nil {
+		return nil, err
+	}
+	existing, err := roomlogic.GetRooms(db)
+	if err != nil {
+		return nil, err
+	}
+	names := map[string]bool{}
+	for _, room := range existing {
+		names[roomKey(room.Name)] = true
+	}
+
+	report := &BulkReport{Mode: mode}
+	var accepted []models.Room
+	for _, row := range rows {
+		result := RowResult{Row: row.line, SourceID: row.room.ID, Outcome: OutcomeCreate, Reason: row.err}
+		if row.err == "" {
+			if err := roomlogic.ValidateRoom(row.room); err != nil {
+				row.err = err.Error()
+				result.Reason = row.err
+			}
+		}
+		switch {
+		case row.err != "":
+			result.Outcome = OutcomeInvalid
+		case names[roomKey(row.room.Name)]:
+			result.Outcome, result.Reason = OutcomeDuplicate, fmt.Sprintf("une salle « %s » existe déjà", row.room.Name)
+		default:
+			names[roomKey(row.room.Name)] = true
+			accepted = append(accepted, row.room)
+		}
+		report.add(result)
+	}
+
+	query := "INSERT INTO rooms (name, capacity, buffer_before, buffer_after, features, building) VALUES (?, ?, ?, ?, ?, ?)"
+	err = commitRows(db, report, func(tx *sql.Tx, i int) (sql.Result, error) {
+		room := accepted[i]
+		return tx.Exec(query, strings.TrimSpace(room.Name), room.Capacity, room.BufferBefore, room.BufferAfter,
+			roomlogic.JoinFeatures(room.Features), strings.TrimSpace(room.Building))
+	})
+	return report, err
+}
+
+type roomRow struct {
+	line int
+	room models.Room
+	// Ligne illisible (valeur non numérique...), vide sinon
+	err string
+}
+
+func readRooms(r io.Reader, format string) ([]roomRow, error) {
+	if format == "json" {
+		var rooms []models.Room
+		if err := json.NewDecoder(r).Decode(&rooms); err != nil {
+			return nil, models.Errorf(models.ErrInvalid, "fichier JSON invalide : %v", err)
+		}
+		rows := make([]roomRow, len(rooms))
+		for i, room := range rooms {
+			rows[i] = roomRow{line: i + 1, room: room}
+		}
+		return rows, nil
+	}
+
+	records, err := readCSV(r, exportlogic.RoomCSVHeader)
+	if err != nil {
+		return nil, err
+	}
+	rows := make([]roomRow, len(records))
+	for i, record := range records {
+		row := roomRow{line: i + 2, err: record.err}
+		row.room.Name = record.get("Name")
+		row.room.Features = roomlogic.ParseFeatures(record.get("Features"))
+		row.room.Building = record.get("Building")
+		if row.err == "" {
+			row.err = parseInts(map[string]*int{
+				"ID": &row.room.ID, "Capacity": &row.room.Capacity,
+				"BufferBefore": &row.room.BufferBefore, "BufferAfter": &row.room.BufferAfter,
+			}, record.get)
+		}
+		rows[i] = row
+	}
+	return rows, nil
+}
+
+// Importe des réservations au format de WriteReservationsCSV ou WriteReservationsJSON.
+// Chaque ligne est vérifiée : salle et utilisateur existants, date et horaires valides,
+// jours de fermeture et horaires d'ouverture, disponibilité de la salle. Une réservation
+// identique à une réservation active (même salle, même créneau, même utilisateur) est un
+// doublon ; les réservations annulées ou non honorées du fichier sont ignorées. Les
+// règles de réservation et les quotas ne s'appliquent pas : l'import sert à reprendre
+// des données existantes. Les identifiants du fichier ne sont pas conservés.
+func ImportReservations(db *sql.DB, r io.Reader, format, mode string) (*BulkReport, error) {
+	if err := checkMode(mode, format); err != nil {
+		return nil, err
+	}
+	rows, err := readReservations(r, format)
+	if err != nil {
+		return nil, err
+	}
+	users, err := userlogic.GetUsers(db)
+	if err != nil {
+		return nil, err
+	}
+	userIDs := map[int]bool{}
+	for _, u := range users {
+		userIDs[u.ID] = true
+	}
+
+	// Réservations déjà retenues du fichier, qui bloquent les suivantes comme des réservations en base
+	batch, err := roomlogic.NewBatch(db)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &BulkReport{Mode: mode}
+	for _, row := range rows {
+		result := RowResult{Row: row.line, SourceID: row.reservation.ID, Outcome: OutcomeCreate, Reason: row.err}
+		if row.err != "" {
+			result.Outcome = OutcomeInvalid
+			report.add(result)
+			continue
+		}
+		res, err := normalizeReservation(row.reservation)
+		if err == nil && !roomlogic.IsRoomExists(db, res.RoomID) {
+			err = models.Errorf(models.ErrNotFound, "la salle avec l'ID %d n'existe pas", res.RoomID)
+		}
+		if err == nil && res.UserID != 0 && !userIDs[res.UserID] {
+			err = models.Errorf(models.ErrNotFound, "l'utilisateur %d n'existe pas", res.UserID)
+		}
+		if err != nil {
+			result.Outcome, result.Reason = OutcomeInvalid, err.Error()
+			report.add(result)
+			continue
+		}
+		if res.Status != models.StatusConfirmed && res.Status != models.StatusCheckedIn {
+			result.Outcome, result.Reason = OutcomeSkip, "réservation non active (statut "+res.Status+")"
+			report.add(result)
+			continue
+		}
+
+		result.Outcome, result.Reason, err = checkReservation(db, res, batch)
+		if err != nil {
+			return nil, err
+		}
+		if result.Outcome == OutcomeCreate {
+			batch.Add(res)
+		}
+		report.add(result)
+	}
+
+	query := `INSERT INTO reservations (room_id, user_id, date, start_time, end_time, status) VALUES (?, NULLIF(?, 0), ?, ?, ?, ?)`
+	accepted := batch.Reservations()
+	err = commitRows(db, report, func(tx *sql.Tx, i int) (sql.Result, error) {
+		res := accepted[i]
+		// Une réservation enregistrée depuis checkReservation ne doit pas être chevauchée
+		free, err := roomlogic.IsRoomFreeInTx(tx, res.RoomID, res.Date, res.StartTime, res.EndTime, 0)
+		if err != nil {
+			return nil, err
+		}
+		if !free {
+			return nil, errTakenMeanwhile
+		}
+		return tx.Exec(query, res.RoomID, res.UserID, res.Date, res.StartTime, res.EndTime, res.Status)
+	})
+	return report, err
+}
+
+type reservationRow struct {
+	line        int
+	reservation models.Reservation
+	err         string
+}
+
+func readReservations(r io.Reader, format string) ([]reservationRow, error) {
+	if format == "json" {
+		var reservations []models.Reservation
+		if err := json.NewDecoder(r).Decode(&reservations); err != nil {
+			return nil, models.Errorf(models.ErrInvalid, "fichier JSON invalide : %v", err)
+		}
+		rows := make([]reservationRow, len(reservations))
+		for i, res := range reservations {
+			rows[i] = reservationRow{line: i + 1, reservation: res}
+		}
+		return rows, nil
+	}
+
+	records, err := readCSV(r, exportlogic.ReservationCSVHeader)
+	if err != nil {
+		return nil, err
+	}
+	rows := make([]reservationRow, len(records))
+	for i, record := range records {
+		row := reservationRow{line: i + 2, err: record.err}
+		row.reservation.Date = record.get("Date")
+		row.reservation.StartTime = record.get("StartTime")
+		row.reservation.EndTime = record.get("EndTime")
+		if row.err == "" {
+			row.err = parseInts(map[string]*int{
+				"ID": &row.reservation.ID, "RoomID": &row.reservation.RoomID, "UserID": &row.reservation.UserID,
+			}, record.get)
+		}
+		rows[i] = row
+	}
+	return rows, nil
+}
+
+// Date et heures au format de la base ; le statut vaut confirmed s'il est absent (CSV)
+func normalizeReservation(res models.Reservation) (models.Reservation, error) {
+	var err error
+	if _, err = utils.ParseDate(res.Date); err != nil {
+		return res, err
+	}
+	if res.StartTime, err = utils.NormalizeClock(res.StartTime); err != nil {
+		return res, err
+	}
+	if res.EndTime, err = utils.NormalizeClock(res.EndTime); err != nil {
+		return res, err
+	}
+	if res.EndTime <= res.StartTime {
+		return res, models.NewError(models.ErrInvalid, "l'heure de fin doit être après l'heure de début")
+	}
+	if res.Status == "" {
+		res.Status = models.StatusConfirmed
+	}
+	return res, nil
+}
+
+// Doublon ou conflit avec la base ou avec les lignes déjà retenues du fichier ; les unes
+// comme les autres bloquent la salle, ses sous-salles et sa salle parente, temps de préparation compris
+func checkReservation(db *sql.DB, res models.Reservation, batch *roomlogic.Batch) (string, string, error) {
+	i, err := batch.Blocking(res)
+	if err != nil {
+		return "", "", err
+	}
+	if i >= 0 {
+		other := batch.Reservations()[i]
+		if sameSlot(other, res) {
+			return OutcomeDuplicate, "ligne identique plus haut dans le fichier", nil
+		}
+		return OutcomeConflict, fmt.Sprintf("chevauche %s - %s (salle %d) importé du même fichier", other.StartTime, other.EndTime, other.RoomID), nil
+	}
+	conflicts, err := roomlogic.GetConflictingReservations(db, res.RoomID, res.Date, res.StartTime, res.EndTime)
+	if err != nil {
+		return "", "", err
+	}
+	for _, c := range conflicts {
+		if sameSlot(c, res) {
+			return OutcomeDuplicate, fmt.Sprintf("déjà en base (réservation %d)", c.ID), nil
+		}
+	}
+	if len(conflicts) > 0 {
+		c := conflicts[0]
+		return OutcomeConflict, fmt.Sprintf("salle prise par la réservation %d (%s - %s)", c.ID, c.StartTime, c.EndTime), nil
+	}
+	if err := calendarlogic.CheckBookable(db, res.RoomID, res.Date, res.StartTime, res.EndTime); err != nil {
+		if errors.Is(err, models.ErrConflict) || errors.Is(err, models.ErrInvalid) {
+			return OutcomeConflict, err.Error(), nil
+		}
+		return "", "", err
+	}
+	return OutcomeCreate, "", nil
+}
+
+func sameSlot(a, b models.Reservation) bool {
+	return a.RoomID == b.RoomID && a.UserID == b.UserID && a.Date == b.Date && a.StartTime == b.StartTime && a.EndTime == b.EndTime
+}
+
+// Enregistre les lignes retenues dans une transaction, selon le mode du rapport ;
+// insert est appelé pour la i-ème ligne retenue. Une erreur ErrConflict d'insert met la
+// ligne en conflit : elle est ignorée en mode partiel et annule tout l'import en mode
+// tout ou rien.
+func commitRows(db *sql.DB, report *BulkReport, insert func(tx *sql.Tx, i int) (sql.Result, error)) error {
+	switch report.Mode {
+	case ModeDryRun:
+		return nil
+	case ModeAllOrNothing:
+		if report.Invalid > 0 || report.Conflicts > 0 {
+			report.Aborted = true
+			return nil
+		}
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	i := 0
+	for j := range report.Rows {
+		row := &report.Rows[j]
+		if row.Outcome != OutcomeCreate {
+			continue
+		}
+		result, err := insert(tx, i)
+		i++
+		if errors.Is(err, models.ErrConflict) {
+			row.Outcome, row.Reason = OutcomeConflict, err.Error()
+			report.Created--
+			report.Conflicts++
+			if report.Mode == ModeAllOrNothing {
+				_ = tx.Rollback()
+				report.Aborted = true
+				// Les lignes déjà insérées sont annulées avec la transaction
+				for k := range report.Rows[:j] {
+					report.Rows[k].CreatedID = 0
+				}
+				return nil
+			}
+			continue
+		}
+		if err != nil {
+			_ = tx.Rollback()
+			return fmt.Errorf("ligne %d : %w", row.Row, err)
+		}
+		id, err := result.LastInsertId()
+		if err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+		row.CreatedID = int(id)
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	report.Committed = true
+	return nil
+}
+
+// Ligne d'un fichier CSV, lue par nom de colonne
+type csvRecord struct {
+	fields  []string
+	columns map[string]int
+	// Nombre de colonnes différent de l'en-tête, vide sinon
+	err string
+}
+
+func (r csvRecord) get(name string) string {
+	if i := r.columns[name]; i < len(r.fields) {
+		return strings.TrimSpace(r.fields[i])
+	}
+	return ""
+}
+
+// Lit un fichier CSV dont l'en-tête doit contenir les colonnes attendues (dans n'importe quel ordre)
+func readCSV(r io.Reader, expected []string) ([]csvRecord, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, models.Errorf(models.ErrInvalid, "fichier CSV invalide : %v", err)
+	}
+	if len(records) == 0 {
+		return nil, models.NewError(models.ErrInvalid, "fichier CSV vide")
+	}
+	header := records[0]
+	columns := map[string]int{}
+	for i, name := range header {
+		// Les tableurs ajoutent parfois une marque d'ordre des octets en tête de fichier
+		columns[strings.TrimSpace(strings.TrimPrefix(name, "\ufeff"))] = i
+	}
+	for _, name := range expected {
+		if _, ok := columns[name]; !ok {
+			return nil, models.Errorf(models.ErrInvalid, "colonne %s manquante (attendues : %s)", name, strings.Join(expected, ", "))
+		}
+	}
+	rows := make([]csvRecord, 0, len(records)-1)
+	for _, fields := range records[1:] {
+		row := csvRecord{fields: fields, columns: columns}
+		if len(fields) != len(header) {
+			row.err = fmt.Sprintf("%d colonnes au lieu de %d", len(fields), len(header))
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// Lit les colonnes entières ; renvoie la première erreur sous forme de texte, vide sinon.
+// Une colonne ID vide est acceptée (ligne écrite à la main).
+func parseInts(fields map[string]*int, get func(string) string) string {
+	for _, name := range []string{"ID", "RoomID", "UserID", "Capacity", "BufferBefore", "BufferAfter"} {
+		target, ok := fields[name]
+		if !ok {
+			continue
+		}
+		value := get(name)
+		if value == "" && (name == "ID" || name == "BufferBefore" || name == "BufferAfter") {
+			continue
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Sprintf("colonne %s : nombre entier attendu, %q trouvé", name, value)
+		}
+		*target = n
+	}
+	return ""
+}
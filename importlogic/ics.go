@@ -0,0 +1,461 @@
+package importlogic
+
+import (
+	"Reserve-Go/models"
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Nombre maximum d'occurrences développées pour un événement répétitif
+const MaxOccurrences = 500
+
+// Une règle sans COUNT ni UNTIL est développée sur cette durée à partir du premier événement
+const unboundedHorizon = 365 * 24 * time.Hour
+
+// VEVENT lu dans le fichier, avant développement de sa règle de répétition
+type icsEvent struct {
+	UID      string
+	Summary  string
+	Location string
+	Status   string
+	Start    time.Time
+	End      time.Time
+	Duration time.Duration
+	AllDay   bool
+	RRule    string
+	ExDates  []time.Time
+	// EXDATE sous forme de date seule (AAAAMMJJ) : toutes les occurrences du jour sont exclues
+	ExDays       []string
+	RecurrenceID time.Time
+}
+
+// Occurrence d'un événement, à l'heure locale
+type occurrence struct {
+	UID      string
+	Summary  string
+	Location string
+	Start    time.Time
+	End      time.Time
+	// Raison d'ignorer l'occurrence (annulée dans le fichier, sans horaire...), vide sinon
+	Skip string
+}
+
+type property struct {
+	Name   string
+	Params map[string]string
+	Value  string
+}
+
+// Lit les VEVENT d'un fichier iCalendar ; les autres composants sont ignorés
+func parseCalendar(r io.Reader) ([]icsEvent, error) {
+	lines, err := unfold(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var events []icsEvent
+	var current *icsEvent
+	// Profondeur des sous-composants d'un VEVENT (VALARM...), ignorés
+	nested := 0
+	seenCalendar := false
+	for i, line := range lines {
+		if line == "" {
+			continue
+		}
+		p, ok := parseProperty(line)
+		if !ok {
+			return nil, models.Errorf(models.ErrInvalid, "ligne %d illisible : %q", i+1, line)
+		}
+		switch {
+		case p.Name == "BEGIN" && strings.EqualFold(p.Value, "VCALENDAR"):
+			seenCalendar = true
+		case p.Name == "BEGIN" && strings.EqualFold(p.Value, "VEVENT") && current == nil:
+			current = &icsEvent{}
+		case p.Name == "BEGIN" && current != nil:
+			nested++
+		case p.Name == "END" && current != nil && nested > 0:
+			nested--
+		case p.Name == "END" && strings.EqualFold(p.Value, "VEVENT") && current != nil:
+			// DURATION remplace DTEND et peut précéder DTSTART
+			if current.End.IsZero() && current.Duration > 0 {
+				current.End = current.Start.Add(current.Duration)
+			}
+			events = append(events, *current)
+			current = nil
+		case current != nil && nested == 0:
+			if err := current.set(p); err != nil {
+				return nil, models.Errorf(models.ErrInvalid, "ligne %d : %v", i+1, err)
+			}
+		}
+	}
+	if !seenCalendar {
+		return nil, models.NewError(models.ErrInvalid, "le fichier n'est pas un calendrier iCalendar (BEGIN:VCALENDAR manquant)")
+	}
+	if current != nil {
+		return nil, models.NewError(models.ErrInvalid, "fichier tronqué : END:VEVENT manquant")
+	}
+	return events, nil
+}
+
+// Lignes logiques du fichier : une ligne commençant par une espace ou une tabulation prolonge la précédente
+func unfold(r io.Reader) ([]string, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	var lines []string
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines, scanner.Err()
+}
+
+// NOM;PARAM=valeur;PARAM="valeur":contenu
+func parseProperty(line string) (property, bool) {
+	inQuotes := false
+	colon := -1
+	for i, c := range line {
+		if c == '"' {
+			inQuotes = !inQuotes
+		}
+		if c == ':' && !inQuotes {
+			colon = i
+			break
+		}
+	}
+	if colon <= 0 {
+		return property{}, false
+	}
+	parts := strings.Split(line[:colon], ";")
+	p := property{Name: strings.ToUpper(parts[0]), Params: map[string]string{}, Value: line[colon+1:]}
+	for _, param := range parts[1:] {
+		name, value, _ := strings.Cut(param, "=")
+		p.Params[strings.ToUpper(name)] = strings.Trim(value, `"`)
+	}
+	return p, true
+}
+
+func (e *icsEvent) set(p property) error {
+	var err error
+	switch p.Name {
+	case "UID":
+		e.UID = p.Value
+	case "SUMMARY":
+		e.Summary = unescapeText(p.Value)
+	case "LOCATION":
+		e.Location = unescapeText(p.Value)
+	case "STATUS":
+		e.Status = strings.ToUpper(p.Value)
+	case "DTSTART":
+		e.Start, e.AllDay, err = parseTime(p.Value, p.Params)
+	case "DTEND":
+		e.End, _, err = parseTime(p.Value, p.Params)
+	case "DURATION":
+		e.Duration, err = parseDuration(p.Value)
+	case "RRULE":
+		e.RRule = p.Value
+	case "EXDATE":
+		for _, value := range strings.Split(p.Value, ",") {
+			t, allDay, err := parseTime(value, p.Params)
+			if err != nil {
+				return err
+			}
+			if allDay {
+				e.ExDays = append(e.ExDays, t.Format("20060102"))
+			} else {
+				e.ExDates = append(e.ExDates, t)
+			}
+		}
+	case "RECURRENCE-ID":
+		e.RecurrenceID, _, err = parseTime(p.Value, p.Params)
+	}
+	return err
+}
+
+// Date (VALUE=DATE), heure UTC (suffixe Z), heure d'un fuseau (TZID) ou heure flottante
+// (heure locale du serveur) ; le fuseau est conservé pour développer les répétitions
+func parseTime(value string, params map[string]string) (time.Time, bool, error) {
+	value = strings.TrimSpace(value)
+	if params["VALUE"] == "DATE" || len(value) == 8 {
+		t, err := time.ParseInLocation("20060102", value, time.Local)
+		if err != nil {
+			return time.Time{}, false, models.Errorf(models.ErrInvalid, "date invalide : %q", value)
+		}
+		return t, true, nil
+	}
+	if strings.HasSuffix(value, "Z") {
+		t, err := time.Parse("20060102T150405Z", value)
+		if err != nil {
+			return time.Time{}, false, models.Errorf(models.ErrInvalid, "date et heure invalides : %q", value)
+		}
+		return t, false, nil
+	}
+	loc := time.Local
+	if tzid := params["TZID"]; tzid != "" {
+		// Les fuseaux inconnus (noms Windows...) sont lus comme des heures locales
+		if l, err := time.LoadLocation(tzid); err == nil {
+			loc = l
+		}
+	}
+	t, err := time.ParseInLocation("20060102T150405", value, loc)
+	if err != nil {
+		return time.Time{}, false, models.Errorf(models.ErrInvalid, "date et heure invalides : %q", value)
+	}
+	return t, false, nil
+}
+
+// Durée RFC 5545 : P1W, P1D, PT1H30M, P1DT2H...
+func parseDuration(value string) (time.Duration, error) {
+	invalid := models.Errorf(models.ErrInvalid, "durée invalide : %q", value)
+	rest := strings.TrimPrefix(strings.TrimPrefix(value, "+"), "P")
+	if rest == value || rest == "" {
+		return 0, invalid
+	}
+	var total time.Duration
+	inTime := false
+	number := ""
+	for _, c := range rest {
+		switch {
+		case c >= '0' && c <= '9':
+			number += string(c)
+		case c == 'T':
+			inTime = true
+		default:
+			n, err := strconv.Atoi(number)
+			if err != nil {
+				return 0, invalid
+			}
+			number = ""
+			unit := map[rune]time.Duration{'W': 7 * 24 * time.Hour, 'D': 24 * time.Hour}
+			if inTime {
+				unit = map[rune]time.Duration{'H': time.Hour, 'M': time.Minute, 'S': time.Second}
+			}
+			u, ok := unit[c]
+			if !ok {
+				return 0, invalid
+			}
+			total += time.Duration(n) * u
+		}
+	}
+	if number != "" {
+		return 0, invalid
+	}
+	return total, nil
+}
+
+func unescapeText(value string) string {
+	return strings.NewReplacer(`\\`, `\`, `\;`, ";", `\,`, ",", `\n`, "\n", `\N`, "\n").Replace(value)
+}
+
+// Développe les événements en occurrences à l'heure locale : règles de répétition, dates
+// exclues et occurrences modifiées (même UID avec RECURRENCE-ID) ; triées par début
+func expand(events []icsEvent) []occurrence {
+	overrides := map[string]map[int64]bool{}
+	for _, e := range events {
+		if !e.RecurrenceID.IsZero() {
+			if overrides[e.UID] == nil {
+				overrides[e.UID] = map[int64]bool{}
+			}
+			overrides[e.UID][e.RecurrenceID.Unix()] = true
+		}
+	}
+
+	var occurrences []occurrence
+	for _, e := range events {
+		base := occurrence{UID: e.UID, Summary: e.Summary, Location: e.Location, Start: e.Start.In(time.Local), End: e.End.In(time.Local)}
+		switch {
+		case e.Start.IsZero():
+			base.Skip = "événement sans DTSTART"
+		case e.AllDay:
+			base.Skip = "événement sur la journée entière, sans horaire"
+		case !e.End.After(e.Start):
+			base.Skip = "événement sans heure de fin valide"
+		case base.End.Format("20060102") != base.Start.Format("20060102"):
+			base.Skip = "événement sur plusieurs jours"
+		case e.Status == "CANCELLED":
+			base.Skip = "événement annulé dans le fichier"
+		}
+		if e.RRule == "" || !e.RecurrenceID.IsZero() || base.Skip != "" {
+			occurrences = append(occurrences, base)
+			continue
+		}
+
+		starts, dropped, err := recurrences(e.Start, e.RRule)
+		if err != nil {
+			base.Skip = err.Error()
+			occurrences = append(occurrences, base)
+			continue
+		}
+		duration := e.End.Sub(e.Start)
+		for _, start := range starts {
+			if overrides[e.UID][start.Unix()] || e.excludes(start) {
+				continue
+			}
+			o := base
+			o.Start, o.End = start.In(time.Local), start.Add(duration).In(time.Local)
+			occurrences = append(occurrences, o)
+		}
+		// La répétition tronquée apparaît dans le rapport, à la date de la première occurrence écartée
+		if !dropped.IsZero() {
+			o := base
+			o.Start, o.End = dropped.In(time.Local), dropped.Add(duration).In(time.Local)
+			o.Skip = fmt.Sprintf("répétition limitée à %d occurrences : celle-ci et les suivantes ne sont pas importées", MaxOccurrences)
+			occurrences = append(occurrences, o)
+		}
+	}
+	sort.SliceStable(occurrences, func(i, j int) bool { return occurrences[i].Start.Before(occurrences[j].Start) })
+	return occurrences
+}
+
+func (e *icsEvent) excludes(start time.Time) bool {
+	for _, d := range e.ExDates {
+		if d.Equal(start) {
+			return true
+		}
+	}
+	day := start.Format("20060102")
+	for _, d := range e.ExDays {
+		if d == day {
+			return true
+		}
+	}
+	return false
+}
+
+var weekdays = map[string]time.Weekday{
+	"MO": time.Monday, "TU": time.Tuesday, "WE": time.Wednesday, "TH": time.Thursday,
+	"FR": time.Friday, "SA": time.Saturday, "SU": time.Sunday,
+}
+
+// Débuts des occurrences d'une règle RRULE : FREQ=DAILY, WEEKLY (avec BYDAY) ou MONTHLY,
+// INTERVAL, COUNT et UNTIL ; les autres parties de règle ne sont pas prises en charge.
+// Au-delà de MaxOccurrences, dropped est le premier début écarté (zéro sinon).
+func recurrences(first time.Time, rule string) (starts []time.Time, dropped time.Time, err error) {
+	unsupported := models.Errorf(models.ErrInvalid, "règle de répétition non prise en charge : %s", rule)
+	parts := map[string]string{}
+	for _, part := range strings.Split(rule, ";") {
+		name, value, _ := strings.Cut(part, "=")
+		parts[strings.ToUpper(name)] = strings.ToUpper(value)
+	}
+
+	interval := 1
+	count := 0
+	var until time.Time
+	var byDay []time.Weekday
+	for name, value := range parts {
+		var err error
+		switch name {
+		case "FREQ", "WKST":
+		case "INTERVAL":
+			if interval, err = strconv.Atoi(value); err != nil || interval < 1 {
+				return nil, time.Time{}, unsupported
+			}
+		case "COUNT":
+			if count, err = strconv.Atoi(value); err != nil || count < 1 {
+				return nil, time.Time{}, unsupported
+			}
+		case "UNTIL":
+			t, allDay, err := parseTime(value, map[string]string{})
+			if err != nil {
+				return nil, time.Time{}, unsupported
+			}
+			if allDay {
+				// UNTIL sous forme de date : toute la journée est incluse
+				t = t.AddDate(0, 0, 1).Add(-time.Second)
+			}
+			until = t
+		case "BYDAY":
+			for _, day := range strings.Split(value, ",") {
+				weekday, ok := weekdays[day]
+				if !ok {
+					return nil, time.Time{}, unsupported
+				}
+				byDay = append(byDay, weekday)
+			}
+		default:
+			return nil, time.Time{}, unsupported
+		}
+	}
+	switch {
+	case !until.IsZero():
+	case count == 0:
+		until = first.Add(unboundedHorizon)
+	default:
+		// COUNT seul : la règle s'arrête au nombre d'occurrences (ou à MaxOccurrences)
+		until = time.Date(9999, 12, 31, 0, 0, 0, 0, first.Location())
+	}
+
+	add := func(t time.Time) bool {
+		if t.After(until) || (count > 0 && len(starts) >= count) {
+			return false
+		}
+		if len(starts) >= MaxOccurrences {
+			dropped = t
+			return false
+		}
+		if !t.Before(first) {
+			starts = append(starts, t)
+		}
+		return true
+	}
+	at := func(day time.Time) time.Time {
+		return time.Date(day.Year(), day.Month(), day.Day(), first.Hour(), first.Minute(), first.Second(), 0, first.Location())
+	}
+
+	switch parts["FREQ"] {
+	case "DAILY":
+		if byDay != nil {
+			return nil, time.Time{}, unsupported
+		}
+		for i := 0; add(at(first.AddDate(0, 0, i*interval))); i++ {
+		}
+	case "WEEKLY":
+		if byDay == nil {
+			byDay = []time.Weekday{first.Weekday()}
+		}
+		sort.Slice(byDay, func(i, j int) bool { return mondayIndex(byDay[i]) < mondayIndex(byDay[j]) })
+		monday := first.AddDate(0, 0, -mondayIndex(first.Weekday()))
+		for week := 0; ; week += interval {
+			more := true
+			for _, day := range byDay {
+				if more = add(at(monday.AddDate(0, 0, 7*week+mondayIndex(day)))); !more {
+					break
+				}
+			}
+			if !more {
+				break
+			}
+		}
+	case "MONTHLY":
+		if byDay != nil {
+			return nil, time.Time{}, unsupported
+		}
+		for i := 0; ; i += interval {
+			month := time.Date(first.Year(), first.Month()+time.Month(i), 1, 0, 0, 0, 0, first.Location())
+			day := time.Date(month.Year(), month.Month(), first.Day(), 0, 0, 0, 0, first.Location())
+			// Les mois trop courts (31 du mois...) n'ont pas d'occurrence
+			if day.Month() != month.Month() {
+				continue
+			}
+			if !add(at(day)) {
+				break
+			}
+		}
+	default:
+		return nil, time.Time{}, unsupported
+	}
+	return starts, dropped, nil
+}
+
+// Lundi = 0 ... dimanche = 6
+func mondayIndex(day time.Weekday) int {
+	return (int(day) + 6) % 7
+}
@@ -0,0 +1,198 @@
+package importlogic
+
+import (
+	"Reserve-Go/models"
+	"Reserve-Go/quotalogic"
+	"Reserve-Go/reservationlogic"
+	"Reserve-Go/roomlogic"
+	"Reserve-Go/utils"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Sort de chaque occurrence du fichier importé
+const (
+	OutcomeCreate   = "create"
+	OutcomeSkip     = "skip"
+	OutcomeConflict = "conflict"
+)
+
+// Salle réservée par une autre requête entre la vérification d'une occurrence ou d'une
+// ligne et son enregistrement
+var errTakenMeanwhile = models.NewError(models.ErrConflict, "salle réservée entre-temps par une autre requête")
+
+// Une occurrence du fichier et ce que l'import en fait
+type Entry struct {
+	UID       string
+	Summary   string
+	Location  string
+	RoomID    int
+	Date      string
+	StartTime string
+	EndTime   string
+	Outcome   string
+	// Raison d'une occurrence ignorée ou en conflit
+	Reason string
+	// Réservation créée (import confirmé uniquement)
+	ReservationID int
+}
+
+type Report struct {
+	// Faux pour une simulation : rien n'a été écrit en base
+	Committed bool
+	Entries   []Entry
+	Created   int
+	Skipped   int
+	Conflicts int
+}
+
+// Importe les événements d'un fichier iCalendar au nom de user. Chaque occurrence est
+// rattachée à la salle dont le nom correspond à LOCATION et soumise aux mêmes
+// vérifications qu'une réservation (horaires, règles, périodes de gel, quotas, disponibilité),
+// les occurrences déjà retenues du fichier comptant comme des réservations.
+// Sans commit, rien n'est créé et le rapport décrit ce que l'import ferait ; avec commit,
+// les occurrences acceptées sont créées dans une seule transaction.
+func ImportICS(db *sql.DB, user *models.User, r io.Reader, commit bool) (*Report, error) {
+	events, err := parseCalendar(r)
+	if err != nil {
+		return nil, err
+	}
+	rooms, err := roomlogic.GetRooms(db)
+	if err != nil {
+		return nil, err
+	}
+	byName := make(map[string]models.Room, len(rooms))
+	for _, room := range rooms {
+		byName[roomKey(room.Name)] = room
+	}
+	// Occurrences déjà retenues, pour détecter les chevauchements internes au fichier ;
+	// accepted suit l'ordre des ajouts au lot
+	batch, err := roomlogic.NewBatch(db)
+	if err != nil {
+		return nil, err
+	}
+	var accepted []Entry
+
+	report := &Report{}
+	for _, o := range expand(events) {
+		entry := Entry{
+			UID:       o.UID,
+			Summary:   o.Summary,
+			Location:  o.Location,
+			Date:      o.Start.Format(utils.DateLayout),
+			StartTime: o.Start.Format("15:04:05"),
+			EndTime:   o.End.Format("15:04:05"),
+			Outcome:   OutcomeCreate,
+		}
+		room, found := byName[roomKey(o.Location)]
+		switch {
+		case o.Skip != "":
+			entry.Outcome, entry.Reason = OutcomeSkip, o.Skip
+		case strings.TrimSpace(o.Location) == "":
+			entry.Outcome, entry.Reason = OutcomeSkip, "événement sans LOCATION"
+		case !found:
+			entry.Outcome, entry.Reason = OutcomeSkip, fmt.Sprintf("aucune salle ne s'appelle « %s »", o.Location)
+		default:
+			entry.RoomID = room.ID
+			res := models.Reservation{RoomID: room.ID, UserID: user.ID, Date: entry.Date, StartTime: entry.StartTime, EndTime: entry.EndTime}
+			i, err := batch.Blocking(res)
+			if err != nil {
+				return nil, err
+			}
+			if i >= 0 {
+				other := accepted[i]
+				entry.Outcome = OutcomeConflict
+				entry.Reason = fmt.Sprintf("chevauche « %s » (%s, %s - %s) importé du même fichier", other.Summary, other.Location, other.StartTime, other.EndTime)
+			} else if err := checkEntry(db, user, batch, res); err != nil {
+				if !errors.Is(err, models.ErrConflict) && !errors.Is(err, models.ErrForbidden) && !errors.Is(err, models.ErrInvalid) && !errors.Is(err, models.ErrNotFound) {
+					return nil, err
+				}
+				entry.Outcome, entry.Reason = OutcomeSkip, err.Error()
+				if errors.Is(err, models.ErrConflict) {
+					entry.Outcome = OutcomeConflict
+				}
+			} else {
+				batch.Add(res)
+				accepted = append(accepted, entry)
+			}
+		}
+		report.add(entry)
+	}
+
+	if commit {
+		if err := create(db, user, report); err != nil {
+			return nil, err
+		}
+		report.Committed = true
+	}
+	return report, nil
+}
+
+func (r *Report) add(entry Entry) {
+	switch entry.Outcome {
+	case OutcomeCreate:
+		r.Created++
+	case OutcomeSkip:
+		r.Skipped++
+	case OutcomeConflict:
+		r.Conflicts++
+	}
+	r.Entries = append(r.Entries, entry)
+}
+
+// Crée les réservations retenues, toutes ou aucune en cas d'erreur. La disponibilité de
+// chacune est revérifiée dans la transaction : une occurrence dont la salle a été réservée
+// entre-temps passe en conflit au lieu d'être créée.
+func create(db *sql.DB, user *models.User, report *Report) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	query := `INSERT INTO reservations (room_id, user_id, date, start_time, end_time) VALUES (?, ?, ?, ?, ?)`
+	for i := range report.Entries {
+		e := &report.Entries[i]
+		if e.Outcome != OutcomeCreate {
+			continue
+		}
+		free, err := roomlogic.IsRoomFreeInTx(tx, e.RoomID, e.Date, e.StartTime, e.EndTime, 0)
+		if err != nil {
+			_ = tx.Rollback()
+			return fmt.Errorf("« %s » du %s : %w", e.Summary, e.Date, err)
+		}
+		if !free {
+			e.Outcome, e.Reason = OutcomeConflict, errTakenMeanwhile.Error()
+			report.Created--
+			report.Conflicts++
+			continue
+		}
+		result, err := tx.Exec(query, e.RoomID, user.ID, e.Date, e.StartTime, e.EndTime)
+		if err != nil {
+			_ = tx.Rollback()
+			return fmt.Errorf("« %s » du %s : %w", e.Summary, e.Date, err)
+		}
+		id, err := result.LastInsertId()
+		if err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+		e.ReservationID = int(id)
+	}
+	return tx.Commit()
+}
+
+// Vérifications d'une réservation en base, puis quotas de l'utilisateur en comptant
+// les occurrences déjà retenues du lot
+func checkEntry(db *sql.DB, user *models.User, batch *roomlogic.Batch, res models.Reservation) error {
+	if err := reservationlogic.CheckBooking(db, user, res.RoomID, res.Date, res.StartTime, res.EndTime); err != nil {
+		return err
+	}
+	return quotalogic.CheckQuotaWithPending(db, user, batch.Reservations(), res.RoomID, res.Date, res.StartTime, res.EndTime)
+}
+
+// Les noms de salle sont comparés sans tenir compte de la casse ni des espaces superflus
+func roomKey(name string) string {
+	return strings.ToLower(strings.Join(strings.Fields(name), " "))
+}
@@ -0,0 +1,75 @@
+package importlogic
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+var outcomeLabels = map[string]string{
+	OutcomeCreate:    "à créer",
+	OutcomeSkip:      "ignorée",
+	OutcomeConflict:  "conflit",
+	OutcomeInvalid:   "invalide",
+	OutcomeDuplicate: "doublon",
+}
+
+// Écrit le rapport pour un terminal : une ligne par occurrence, puis le bilan
+func WriteReport(w io.Writer, report *Report) error {
+	var b strings.Builder
+	for _, e := range report.Entries {
+		label := outcomeLabels[e.Outcome]
+		if e.Outcome == OutcomeCreate && report.Committed {
+			label = fmt.Sprintf("créée (%d)", e.ReservationID)
+		}
+		location := e.Location
+		if e.RoomID != 0 {
+			location = fmt.Sprintf("%s (salle %d)", e.Location, e.RoomID)
+		}
+		fmt.Fprintf(&b, "%-12s %s %s-%s  %s  %s", label, e.Date, strings.TrimSuffix(e.StartTime, ":00"),
+			strings.TrimSuffix(e.EndTime, ":00"), e.Summary, location)
+		if e.Reason != "" {
+			fmt.Fprintf(&b, " : %s", e.Reason)
+		}
+		b.WriteString("\n")
+	}
+	if report.Committed {
+		fmt.Fprintf(&b, "\n%d réservation(s) créée(s), %d ignorée(s), %d en conflit.\n", report.Created, report.Skipped, report.Conflicts)
+	} else {
+		fmt.Fprintf(&b, "\nSimulation : %d réservation(s) à créer, %d ignorée(s), %d en conflit. Rien n'a été enregistré.\n",
+			report.Created, report.Skipped, report.Conflicts)
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// Écrit le rapport d'un import en masse : une ligne par ligne du fichier, puis le bilan
+func WriteBulkReport(w io.Writer, report *BulkReport) error {
+	var b strings.Builder
+	for _, row := range report.Rows {
+		label := outcomeLabels[row.Outcome]
+		if row.Outcome == OutcomeCreate && report.Committed {
+			label = fmt.Sprintf("créée (%d)", row.CreatedID)
+		}
+		fmt.Fprintf(&b, "ligne %-5d %-12s", row.Row, label)
+		if row.SourceID != 0 {
+			fmt.Fprintf(&b, " ID %d", row.SourceID)
+		}
+		if row.Reason != "" {
+			fmt.Fprintf(&b, " : %s", row.Reason)
+		}
+		b.WriteString("\n")
+	}
+	fmt.Fprintf(&b, "\n%d à créer, %d invalide(s), %d doublon(s), %d en conflit, %d ignorée(s).\n",
+		report.Created, report.Invalid, report.Duplicates, report.Conflicts, report.Skipped)
+	switch {
+	case report.Committed:
+		fmt.Fprintf(&b, "%d ligne(s) enregistrée(s).\n", report.Created)
+	case report.Aborted:
+		b.WriteString("Import annulé (mode tout ou rien) : rien n'a été enregistré.\n")
+	default:
+		b.WriteString("Simulation : rien n'a été enregistré.\n")
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -1,12 +1,13 @@
 package main
 
 import (
+	"Reserve-Go/apilogic"
+	"Reserve-Go/clilogic"
 	"Reserve-Go/dtb"
-	"Reserve-Go/exportlogic"
 	"Reserve-Go/menulogic"
 	"Reserve-Go/reservationlogic"
-	"Reserve-Go/roomlogic"
 	"Reserve-Go/utils"
+	"Reserve-Go/weblogic"
 	"bufio"
 	"database/sql"
 	"fmt"
@@ -16,11 +17,18 @@ import (
 )
 
 func main() {
+	// Avec des arguments, le programme exécute une sous-commande sans menu interactif
+	// et garde stdout pour le résultat
+	interactive := len(os.Args) < 2
+	if !interactive {
+		utils.Output = os.Stderr
+	}
+
 	// Connexion à la base de données
 	db, err := dtb.ConnectToDB()
 	if err != nil {
 		utils.ColorLog(utils.ColorRed, "Erreur lors de la connexion à la base de données: "+err.Error())
-		return
+		os.Exit(clilogic.ExitError)
 	}
 	defer func(db *sql.DB) {
 		dbErr := db.Close()
@@ -30,58 +38,103 @@ func main() {
 	}(db)
 	utils.ColorLog(utils.ColorGreen, "Connexion à la base de données réussie.")
 
+	if !interactive {
+		code := clilogic.Run(db, os.Args[1:], os.Stdout, os.Stderr)
+		if dbErr := db.Close(); dbErr != nil {
+			log.Printf("Erreur: %v", dbErr)
+		}
+		os.Exit(code)
+	}
+
+	// Libération des réservations sans check-in
+	stopNoShowJob := reservationlogic.StartNoShowJob(db, reservationlogic.NoShowCheckInterval)
+	defer stopNoShowJob()
+
+	// Le serveur web tourne en arrière-plan pendant que le menu est affiché
+	go func() {
+		if err := apilogic.ListenAndServe(apilogic.DefaultAddr, weblogic.NewHandler(db)); err != nil {
+			log.Printf("Erreur du serveur web : %v", err)
+		}
+	}()
+
 	scanner := bufio.NewScanner(os.Stdin)
 
 	for {
 		menulogic.ShowMenu()
-		scanner.Scan()
-		choice := scanner.Text()
-		var date string
-		var startTime string
-		var endTime string
-		switch choice {
+		if !scanner.Scan() {
+			return
+		}
+		switch scanner.Text() {
 		case "1":
-			roomlogic.ListRooms(db, scanner)
+			menulogic.ListRooms(db)
 		case "2":
-			roomlogic.UpdateRoom(db, scanner)
+			menulogic.UpdateRoom(db, scanner)
 		case "3":
-			roomlogic.AddRoom(db, scanner)
+			menulogic.AddRoom(db, scanner)
 		case "4":
-			reservationlogic.CreateReservation(db, scanner)
+			menulogic.CreateReservation(db, scanner)
 		case "5":
-			reservationlogic.CancelReservation(db, scanner)
+			menulogic.CancelReservation(db, scanner)
 		case "6":
-			reservationlogic.ViewReservations(db, scanner)
+			menulogic.ViewReservations(db)
 		case "7":
-			reservationlogic.ViewReservationsByRoom(db, scanner)
+			menulogic.ViewReservationsByRoom(db, scanner)
 		case "8":
-			reservationlogic.ViewReservationsByDate(db, scanner)
+			menulogic.ViewReservationsByDate(db, scanner)
 		case "9":
 			menulogic.ShowHelp()
+			continue
 		case "10":
-			if err := exportlogic.ExportReservationsAsCSV(db, "reservations.csv", scanner); err != nil {
-				log.Printf("Failed to export reservations as CSV: %v", err)
-			}
+			menulogic.ExportCSV(db, scanner)
 		case "11":
-			if err := exportlogic.ExportReservationsAsJSON(db, "reservations.json", scanner); err != nil {
-				log.Printf("Failed to export reservations as JSON: %v", err)
-			}
+			menulogic.ExportJSON(db, scanner)
 		case "12":
-			fmt.Println("Saisissez la date ( AAAA-MM-JJ) ")
-			fmt.Scanln(&date)
-			fmt.Println("Saisissez l'heure de début ( HH:MM:SS) ")
-			fmt.Scanln(&startTime)
-			fmt.Println("Saisissez l'heure de fin (HH:MM:SS) ")
-			fmt.Scanln(&endTime)
-			_, listErr := roomlogic.ListAvailableRooms(db, date, startTime, endTime, scanner)
-			if listErr != nil {
-				log.Printf("Erreur: %v", listErr)
-			}
+			menulogic.ListAvailableRooms(db, scanner)
 		case "13":
+			menulogic.ManageOpeningHours(db, scanner)
+		case "14":
+			menulogic.ManageClosureDays(db, scanner)
+		case "15":
+			menulogic.ManageUsers(db, scanner)
+		case "16":
+			menulogic.ManageRules(db, scanner)
+		case "17":
+			menulogic.ManageQuotas(db, scanner)
+		case "18":
+			menulogic.FindFreeSlots(db, scanner)
+		case "19":
+			menulogic.CreateGroupReservation(db, scanner)
+		case "20":
+			menulogic.ManageComponents(db, scanner)
+		case "21":
+			menulogic.CheckInReservation(db, scanner)
+		case "22":
+			menulogic.ShowNoShowReport(db)
+		case "23":
+			menulogic.ManageBlackouts(db, scanner)
+		case "24":
+			menulogic.ShowPlanning(db, scanner)
+		case "25":
+			menulogic.ExportICS(db, scanner)
+		case "26":
+			menulogic.ManageFeeds(db, scanner)
+		case "27":
+			menulogic.ImportICS(db, scanner)
+		case "28":
+			menulogic.ExportXLSX(db, scanner)
+		case "29":
+			menulogic.PrintSchedules(db, scanner)
+		case "30":
 			fmt.Println("Merci d'avoir utilisé le service. À bientôt !")
 			return
 		default:
-			fmt.Println("Option non valide. Veuillez choisir une option entre 1 et 10.")
+			fmt.Println("Option non valide. Veuillez choisir une option entre 1 et 30.")
+			continue
+		}
+		// Chaque action se termine par le choix entre retour au menu et sortie
+		if !menulogic.NavigationOptions(scanner) {
+			fmt.Println("Merci d'avoir utilisé le service. À bientôt !")
+			return
 		}
 	}
 }
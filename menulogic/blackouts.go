@@ -0,0 +1,112 @@
+package menulogic
+
+import (
+	"Reserve-Go/blackoutlogic"
+	"Reserve-Go/models"
+	"Reserve-Go/utils"
+	"bufio"
+	"database/sql"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+)
+
+func ManageBlackouts(db *sql.DB, scanner *bufio.Scanner) {
+	blackouts, err := blackoutlogic.GetBlackouts(db)
+	if err != nil {
+		log.Printf("Erreur lors de la récupération des périodes de gel : %v", err)
+		return
+	}
+	fmt.Println("Périodes de gel :")
+	if len(blackouts) == 0 {
+		fmt.Println("Aucune période de gel.")
+	}
+	for _, b := range blackouts {
+		fmt.Printf("ID: %d, %s : du %s au %s (%s)\n", b.ID, b.Name, b.StartAt, b.EndAt, blackoutlogic.DescribeScope(b))
+	}
+
+	fmt.Println("\n1. Ajouter une période de gel")
+	fmt.Println("2. Supprimer une période de gel")
+	fmt.Println("3. Retour")
+	scanner.Scan()
+	switch scanner.Text() {
+	case "1":
+		addBlackout(db, scanner)
+	case "2":
+		fmt.Println("Entrez l'ID de la période à supprimer :")
+		scanner.Scan()
+		id, err := strconv.Atoi(scanner.Text())
+		if err != nil {
+			log.Printf("Erreur : ID invalide. %v", err)
+			return
+		}
+		if err := blackoutlogic.DeleteBlackout(db, id); err != nil {
+			log.Printf("Erreur lors de la suppression de la période : %v", err)
+		} else {
+			fmt.Println("Période de gel supprimée.")
+		}
+	default:
+		return
+	}
+}
+
+func addBlackout(db *sql.DB, scanner *bufio.Scanner) {
+	var b models.Blackout
+
+	fmt.Println("Entrez le nom de la période (ex : Examens de janvier) :")
+	scanner.Scan()
+	b.Name = scanner.Text()
+
+	fmt.Println("Entrez le début (AAAA-MM-JJ HH:MM, ou AAAA-MM-JJ pour minuit) :")
+	scanner.Scan()
+	b.StartAt = scanner.Text()
+
+	fmt.Println("Entrez la fin (AAAA-MM-JJ HH:MM, ou AAAA-MM-JJ pour inclure toute la journée) :")
+	scanner.Scan()
+	b.EndAt = strings.TrimSpace(scanner.Text())
+	if day, err := utils.ParseDate(b.EndAt); err == nil {
+		b.EndAt = day.AddDate(0, 0, 1).Format(utils.DateLayout)
+	}
+
+	fmt.Println("Entrez l'ID de la salle concernée (vide pour un bâtiment ou tout le site) :")
+	scanner.Scan()
+	if scanner.Text() != "" {
+		roomID, err := strconv.Atoi(scanner.Text())
+		if err != nil {
+			log.Printf("Erreur : ID invalide. %v", err)
+			return
+		}
+		b.RoomID = roomID
+	} else {
+		fmt.Println("Entrez le bâtiment concerné (vide pour tout le site) :")
+		scanner.Scan()
+		b.Building = strings.TrimSpace(scanner.Text())
+	}
+
+	id, err := blackoutlogic.InsertBlackout(db, b)
+	if err != nil {
+		fmt.Println("Erreur lors de l'ajout de la période :", err)
+		return
+	}
+	fmt.Println("Période de gel ajoutée avec l'ID", id)
+
+	// Les réservations déjà faites ne sont pas annulées mais signalées
+	start, _ := utils.ParseDateTime(b.StartAt)
+	end, _ := utils.ParseDateTime(b.EndAt)
+	b.StartAt, b.EndAt = start.Format(utils.DateTimeLayout), end.Format(utils.DateTimeLayout)
+	reservations, err := blackoutlogic.GetReservationsInBlackout(db, b)
+	if err != nil {
+		log.Printf("Erreur lors de la recherche des réservations concernées : %v", err)
+		return
+	}
+	if len(reservations) == 0 {
+		fmt.Println("Aucune réservation existante dans cette période.")
+		return
+	}
+	fmt.Println("Réservations existantes dans cette période :")
+	for _, r := range reservations {
+		fmt.Printf("ID: %d, Salle: %d, Utilisateur: %d, Date: %s, Début: %s, Fin: %s\n",
+			r.ID, r.RoomID, r.UserID, r.Date, r.StartTime, r.EndTime)
+	}
+}
@@ -0,0 +1,116 @@
+package menulogic
+
+import (
+	"Reserve-Go/calendarlogic"
+	"Reserve-Go/utils"
+	"bufio"
+	"database/sql"
+	"fmt"
+	"log"
+	"strconv"
+)
+
+func ManageOpeningHours(db *sql.DB, scanner *bufio.Scanner) {
+	fmt.Println("Entrez l'ID de la salle :")
+	scanner.Scan()
+	roomID, err := strconv.Atoi(scanner.Text())
+	if err != nil {
+		log.Printf("Erreur : ID invalide. %v", err)
+		return
+	}
+
+	printOpeningHours(db, roomID)
+
+	fmt.Println("Entrez le jour à modifier (1 = lundi ... 7 = dimanche, vide pour revenir) :")
+	scanner.Scan()
+	if scanner.Text() == "" {
+		return
+	}
+	weekday, err := strconv.Atoi(scanner.Text())
+	if err != nil {
+		log.Printf("Erreur : jour invalide. %v", err)
+		return
+	}
+
+	fmt.Println("Entrez l'heure d'ouverture (HH:MM, vide pour fermer ce jour) :")
+	scanner.Scan()
+	openTime := scanner.Text()
+	if openTime == "" {
+		if err := calendarlogic.ClearOpeningHours(db, roomID, weekday); err != nil {
+			log.Printf("Erreur lors de la suppression des horaires : %v", err)
+		} else {
+			fmt.Println("Horaires supprimés pour le", utils.WeekdayName(weekday))
+		}
+		return
+	}
+
+	fmt.Println("Entrez l'heure de fermeture (HH:MM) :")
+	scanner.Scan()
+	closeTime := scanner.Text()
+
+	if err := calendarlogic.SetOpeningHours(db, roomID, weekday, openTime, closeTime); err != nil {
+		log.Printf("Erreur lors de l'enregistrement des horaires : %v", err)
+	} else {
+		fmt.Println("Horaires enregistrés avec succès.")
+	}
+}
+
+func printOpeningHours(db *sql.DB, roomID int) {
+	hours, err := calendarlogic.GetOpeningHours(db, roomID)
+	if err != nil {
+		log.Printf("Erreur lors de la récupération des horaires : %v", err)
+		return
+	}
+	if len(hours) == 0 {
+		fmt.Println("Aucun horaire configuré : la salle est réservable à toute heure.")
+		return
+	}
+	fmt.Println("Horaires d'ouverture de la salle", roomID)
+	for _, h := range hours {
+		fmt.Printf("%s : %s - %s\n", utils.WeekdayName(h.Weekday), h.OpenTime, h.CloseTime)
+	}
+}
+
+func ManageClosureDays(db *sql.DB, scanner *bufio.Scanner) {
+	closures, err := calendarlogic.GetClosureDays(db)
+	if err != nil {
+		log.Printf("Erreur lors de la récupération des jours de fermeture : %v", err)
+		return
+	}
+	fmt.Println("Jours de fermeture :")
+	if len(closures) == 0 {
+		fmt.Println("Aucun jour de fermeture.")
+	}
+	for _, c := range closures {
+		fmt.Printf("%s : %s\n", c.Date, c.Label)
+	}
+
+	fmt.Println("\n1. Ajouter un jour de fermeture")
+	fmt.Println("2. Supprimer un jour de fermeture")
+	fmt.Println("3. Retour")
+	scanner.Scan()
+	switch scanner.Text() {
+	case "1":
+		fmt.Println("Entrez la date (AAAA-MM-JJ) :")
+		scanner.Scan()
+		date := scanner.Text()
+		fmt.Println("Entrez le libellé (ex : Noël) :")
+		scanner.Scan()
+		label := scanner.Text()
+		if err := calendarlogic.AddClosureDay(db, date, label); err != nil {
+			log.Printf("Erreur lors de l'ajout du jour de fermeture : %v", err)
+		} else {
+			fmt.Println("Jour de fermeture ajouté avec succès.")
+		}
+	case "2":
+		fmt.Println("Entrez la date à supprimer (AAAA-MM-JJ) :")
+		scanner.Scan()
+		if err := calendarlogic.DeleteClosureDay(db, scanner.Text()); err != nil {
+			log.Printf("Erreur lors de la suppression du jour de fermeture : %v", err)
+		} else {
+			fmt.Println("Jour de fermeture supprimé.")
+		}
+	default:
+		return
+	}
+}
@@ -0,0 +1,41 @@
+package menulogic
+
+import (
+	"Reserve-Go/reservationlogic"
+	"bufio"
+	"database/sql"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+)
+
+func CheckInReservation(db *sql.DB, scanner *bufio.Scanner) {
+	fmt.Print("Entrez l'identifiant de la réservation : ")
+	scanner.Scan()
+	reservationID, err := strconv.Atoi(scanner.Text())
+	if err != nil {
+		fmt.Println("Erreur : identifiant invalide.")
+		return
+	}
+	if err := reservationlogic.CheckIn(db, reservationID, time.Now()); err != nil {
+		fmt.Println("Check-in impossible :", err)
+	} else {
+		fmt.Println("Check-in enregistré, bonne séance !")
+	}
+}
+
+func ShowNoShowReport(db *sql.DB) {
+	counts, err := reservationlogic.GetNoShowCounts(db)
+	if err != nil {
+		log.Printf("Erreur lors de la récupération des réservations non honorées : %v", err)
+		return
+	}
+	fmt.Println("Réservations non honorées par utilisateur :")
+	if len(counts) == 0 {
+		fmt.Println("Aucune réservation non honorée.")
+	}
+	for _, c := range counts {
+		fmt.Printf("ID: %d, Nom: %s, Non honorées: %d\n", c.UserID, c.Name, c.Count)
+	}
+}
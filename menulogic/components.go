@@ -0,0 +1,60 @@
+package menulogic
+
+import (
+	"Reserve-Go/roomlogic"
+	"bufio"
+	"database/sql"
+	"fmt"
+	"log"
+	"strconv"
+)
+
+func ManageComponents(db *sql.DB, scanner *bufio.Scanner) {
+	components, err := roomlogic.GetComponents(db)
+	if err != nil {
+		log.Printf("Erreur lors de la récupération des salles composées : %v", err)
+		return
+	}
+	fmt.Println("Salles composées :")
+	if len(components) == 0 {
+		fmt.Println("Aucune salle composée.")
+	}
+	for _, c := range components {
+		fmt.Printf("La salle %d contient la sous-salle %d\n", c.ParentID, c.ChildID)
+	}
+
+	fmt.Println("\n1. Ajouter une sous-salle")
+	fmt.Println("2. Retirer une sous-salle")
+	fmt.Println("3. Retour")
+	scanner.Scan()
+	choice := scanner.Text()
+	if choice != "1" && choice != "2" {
+		return
+	}
+
+	fmt.Println("Entrez l'ID de la salle parente :")
+	scanner.Scan()
+	parentID, err := strconv.Atoi(scanner.Text())
+	if err != nil {
+		log.Printf("Erreur : ID invalide. %v", err)
+		return
+	}
+	fmt.Println("Entrez l'ID de la sous-salle :")
+	scanner.Scan()
+	childID, err := strconv.Atoi(scanner.Text())
+	if err != nil {
+		log.Printf("Erreur : ID invalide. %v", err)
+		return
+	}
+
+	if choice == "1" {
+		err = roomlogic.AddComponent(db, parentID, childID)
+	} else {
+		err = roomlogic.RemoveComponent(db, parentID, childID)
+	}
+	if err != nil {
+		fmt.Println("Erreur :", err)
+	} else {
+		fmt.Println("Salles composées mises à jour.")
+	}
+}
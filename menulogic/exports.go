@@ -0,0 +1,117 @@
+package menulogic
+
+import (
+	"Reserve-Go/exportlogic"
+	"Reserve-Go/icallogic"
+	"Reserve-Go/reservationlogic"
+	"bufio"
+	"database/sql"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+)
+
+func ExportCSV(db *sql.DB, scanner *bufio.Scanner) {
+	exportReservations(db, scanner, "csv")
+}
+
+func ExportJSON(db *sql.DB, scanner *bufio.Scanner) {
+	exportReservations(db, scanner, "json")
+}
+
+// Classeur Excel : une feuille par salle et une feuille de résumé
+func ExportXLSX(db *sql.DB, scanner *bufio.Scanner) {
+	exportReservations(db, scanner, "xlsx")
+}
+
+// Export des réservations, éventuellement limité à une période, des salles, un utilisateur ou des statuts
+func exportReservations(db *sql.DB, scanner *bufio.Scanner, format string) {
+	var filter reservationlogic.Filter
+	var err error
+
+	fmt.Println("Entrez la date de début (AAAA-MM-JJ, vide pour aucune limite) :")
+	scanner.Scan()
+	filter.From = strings.TrimSpace(scanner.Text())
+	fmt.Println("Entrez la date de fin (AAAA-MM-JJ, vide pour aucune limite) :")
+	scanner.Scan()
+	filter.To = strings.TrimSpace(scanner.Text())
+
+	fmt.Println("Entrez les IDs des salles séparés par des virgules (vide pour toutes) :")
+	scanner.Scan()
+	if filter.RoomIDs, err = reservationlogic.ParseRoomIDs(scanner.Text()); err != nil {
+		fmt.Println("Erreur :", err)
+		return
+	}
+	fmt.Println("Entrez l'ID de l'utilisateur (vide pour tous) :")
+	scanner.Scan()
+	if value := strings.TrimSpace(scanner.Text()); value != "" {
+		if filter.UserID, err = strconv.Atoi(value); err != nil {
+			fmt.Println("Erreur : ID invalide.")
+			return
+		}
+	}
+	fmt.Println("Entrez les statuts séparés par des virgules (confirmed, checked_in, no_show, cancelled ; vide pour tous) :")
+	scanner.Scan()
+	filter.Statuses = reservationlogic.ParseStatuses(scanner.Text())
+	// Le classeur Excel est toujours rangé par salle
+	if format != "xlsx" {
+		fmt.Printf("Entrez le tri (%s, précédé de - pour l'ordre décroissant ; vide pour date) :\n", strings.Join(reservationlogic.SortKeys, ", "))
+		scanner.Scan()
+		filter.Sort = strings.TrimSpace(scanner.Text())
+	}
+
+	fmt.Println("Entrez les colonnes séparées par des virgules (vide pour les colonnes habituelles, all pour toutes) :")
+	fmt.Println("  " + strings.Join(exportlogic.ColumnNames(exportlogic.AllColumns), ", "))
+	scanner.Scan()
+	columns, err := exportlogic.ParseColumns(scanner.Text())
+	if err != nil {
+		fmt.Println("Erreur :", err)
+		return
+	}
+
+	filename := "reservations." + format
+	fmt.Printf("Entrez le nom du fichier (vide pour %s) :\n", filename)
+	scanner.Scan()
+	if value := strings.TrimSpace(scanner.Text()); value != "" {
+		filename = value
+	}
+
+	if err := exportlogic.ExportReservations(db, filename, format, filter, columns); err != nil {
+		log.Printf("Erreur lors de l'exportation %s : %v", strings.ToUpper(format), err)
+		return
+	}
+	fmt.Println("Réservations exportées dans", filename)
+}
+
+// Export iCalendar de toutes les réservations, de celles d'une salle ou de celles d'un utilisateur
+func ExportICS(db *sql.DB, scanner *bufio.Scanner) {
+	fmt.Println("Exporter : 1 = toutes les réservations, 2 = une salle, 3 = un utilisateur")
+	scanner.Scan()
+	choice := strings.TrimSpace(scanner.Text())
+
+	var scope icallogic.Scope
+	filename := "reservations.ics"
+	if choice == "2" || choice == "3" {
+		fmt.Println("Entrez l'ID :")
+		scanner.Scan()
+		id, err := strconv.Atoi(strings.TrimSpace(scanner.Text()))
+		if err != nil {
+			fmt.Println("Erreur : ID invalide.")
+			return
+		}
+		if choice == "2" {
+			scope.RoomID = id
+			filename = fmt.Sprintf("salle-%d.ics", id)
+		} else {
+			scope.UserID = id
+			filename = fmt.Sprintf("utilisateur-%d.ics", id)
+		}
+	}
+
+	if err := exportlogic.ExportReservationsAsICS(db, filename, scope); err != nil {
+		log.Printf("Erreur lors de l'exportation iCalendar : %v", err)
+		return
+	}
+	fmt.Println("Réservations exportées dans", filename)
+}
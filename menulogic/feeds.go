@@ -0,0 +1,76 @@
+package menulogic
+
+import (
+	"Reserve-Go/apilogic"
+	"Reserve-Go/icallogic"
+	"bufio"
+	"database/sql"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+)
+
+// Abonnements iCalendar : URL à jeton secret par salle ou par utilisateur
+func ManageFeeds(db *sql.DB, scanner *bufio.Scanner) {
+	feeds, err := icallogic.GetFeeds(db)
+	if err != nil {
+		log.Printf("Erreur lors de la récupération des abonnements : %v", err)
+		return
+	}
+	fmt.Println("Abonnements iCalendar :")
+	if len(feeds) == 0 {
+		fmt.Println("Aucun abonnement.")
+	}
+	for _, f := range feeds {
+		scope := fmt.Sprintf("utilisateur %d", f.UserID)
+		if f.RoomID != 0 {
+			scope = fmt.Sprintf("salle %d", f.RoomID)
+		}
+		state := "actif"
+		if f.RevokedAt != "" {
+			state = "révoqué le " + f.RevokedAt
+		}
+		fmt.Printf("%s : %s, créé le %s (%s)\n", f.Token, scope, f.CreatedAt, state)
+	}
+
+	fmt.Println("\n1. Créer un abonnement pour une salle")
+	fmt.Println("2. Créer un abonnement pour un utilisateur")
+	fmt.Println("3. Révoquer un abonnement")
+	fmt.Println("4. Retour")
+	scanner.Scan()
+	switch scanner.Text() {
+	case "1", "2":
+		choice := scanner.Text()
+		fmt.Println("Entrez l'ID :")
+		scanner.Scan()
+		id, err := strconv.Atoi(strings.TrimSpace(scanner.Text()))
+		if err != nil {
+			log.Printf("Erreur : ID invalide. %v", err)
+			return
+		}
+		var scope icallogic.Scope
+		if choice == "1" {
+			scope.RoomID = id
+		} else {
+			scope.UserID = id
+		}
+		feed, err := icallogic.CreateFeed(db, scope)
+		if err != nil {
+			fmt.Println("Erreur lors de la création de l'abonnement :", err)
+			return
+		}
+		fmt.Println("Abonnement créé. URL à ajouter dans l'application de calendrier :")
+		fmt.Println("http://localhost" + apilogic.DefaultAddr + icallogic.FeedPath(feed.Token))
+	case "3":
+		fmt.Println("Entrez le jeton de l'abonnement à révoquer :")
+		scanner.Scan()
+		if err := icallogic.RevokeFeed(db, strings.TrimSpace(scanner.Text())); err != nil {
+			fmt.Println("Erreur lors de la révocation :", err)
+		} else {
+			fmt.Println("Abonnement révoqué : son URL ne répond plus.")
+		}
+	default:
+		return
+	}
+}
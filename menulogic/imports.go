@@ -0,0 +1,54 @@
+package menulogic
+
+import (
+	"Reserve-Go/importlogic"
+	"bufio"
+	"bytes"
+	"database/sql"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+)
+
+// Import d'un fichier iCalendar : simulation d'abord, puis confirmation
+func ImportICS(db *sql.DB, scanner *bufio.Scanner) {
+	user, err := askUser(db, scanner)
+	if err != nil {
+		log.Printf("Erreur : %v", err)
+		return
+	}
+	fmt.Println("Entrez le chemin du fichier .ics :")
+	scanner.Scan()
+	content, err := os.ReadFile(strings.TrimSpace(scanner.Text()))
+	if err != nil {
+		log.Printf("Erreur lors de la lecture du fichier : %v", err)
+		return
+	}
+
+	report, err := importlogic.ImportICS(db, user, bytes.NewReader(content), false)
+	if err != nil {
+		fmt.Println("Erreur lors de l'analyse du fichier :", err)
+		return
+	}
+	if err := importlogic.WriteReport(os.Stdout, report); err != nil {
+		log.Printf("Erreur: %v", err)
+	}
+	if report.Created == 0 {
+		return
+	}
+
+	fmt.Printf("Créer les %d réservation(s) ? (o/n) : ", report.Created)
+	scanner.Scan()
+	if !strings.EqualFold(scanner.Text(), "o") {
+		fmt.Println("Import abandonné.")
+		return
+	}
+	// Le fichier est réanalysé : une réservation faite entre-temps apparaît en conflit
+	report, err = importlogic.ImportICS(db, user, bytes.NewReader(content), true)
+	if err != nil {
+		fmt.Println("Erreur lors de l'import :", err)
+		return
+	}
+	fmt.Printf("%d réservation(s) créée(s), %d ignorée(s), %d en conflit.\n", report.Created, report.Skipped, report.Conflicts)
+}
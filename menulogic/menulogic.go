@@ -3,7 +3,6 @@ package menulogic
 import (
 	"Reserve-Go/utils"
 	"bufio"
-	"database/sql"
 	"fmt"
 	"os"
 	"strings"
@@ -27,7 +26,24 @@ func ShowMenu() {
 	fmt.Println("10. Exportation CSV ")
 	fmt.Println("11. Exportation JSON ")
 	fmt.Println("12. Lister les salles disponibles à un temps donné")
-	fmt.Println("13. Quitter")
+	fmt.Println("13. Gérer les horaires d'ouverture d'une salle")
+	fmt.Println("14. Gérer les jours de fermeture")
+	fmt.Println("15. Gérer les utilisateurs")
+	fmt.Println("16. Gérer les règles de réservation")
+	fmt.Println("17. Quotas et consommation des utilisateurs")
+	fmt.Println("18. Rechercher des créneaux libres")
+	fmt.Println("19. Créer une réservation multi-salles")
+	fmt.Println("20. Gérer les salles composées")
+	fmt.Println("21. Check-in d'une réservation")
+	fmt.Println("22. Rapport des réservations non honorées")
+	fmt.Println("23. Gérer les périodes de gel")
+	fmt.Println("24. Planning des salles (grille semaine / jour)")
+	fmt.Println("25. Exportation iCalendar (.ics)")
+	fmt.Println("26. Abonnements iCalendar")
+	fmt.Println("27. Importer un calendrier iCalendar (.ics)")
+	fmt.Println("28. Exportation Excel (.xlsx)")
+	fmt.Println("29. Affiches de la semaine (PDF/HTML)")
+	fmt.Println("30. Quitter")
 	fmt.Print("\nChoisissez une option : ")
 }
 
@@ -39,35 +55,52 @@ func ShowHelp() {
 	fmt.Println("1. Lister les salles - Affiche toutes les salles disponibles.")
 	fmt.Println("2. Modifier une salle - Nous pouvons modifier les salles existantes.")
 	fmt.Println("3. Créer une Salle - - Il faut entrer les informations nécessaires.")
-	fmt.Println("4. Créer une réservation - Il faut entrer les informations nécessaires.")
+	fmt.Println("4. Créer une réservation - Il faut entrer les informations nécessaires ; une réservation peut être répétée chaque semaine.")
 	fmt.Println("5. Annuler une réservation - Vous aurez besoin de l'ID de la réservation.")
 	fmt.Println("6. Visualiser les réservations - Pour voir les réservations existantes.")
 	fmt.Println("7. Récupérer les réservation par salle ")
 	fmt.Println("8. Récupérer les réservations par date")
 	fmt.Println("9. Aide -> C'est nous YOUPI ! ")
-	fmt.Println("10. Exportation CSV ")
-	fmt.Println("11. Exportation JSON ")
+	fmt.Println("10. Exportation CSV - Toutes les réservations ou une sélection (période, salles, utilisateur, statuts), colonnes au choix.")
+	fmt.Println("11. Exportation JSON - Mêmes choix que l'exportation CSV.")
 	fmt.Println("12. Lister les salles disponibles à un temps donné - Entrer une date et affiche les salles disponibles à ce moment")
-	fmt.Println("13. Quitter - Pour fermer l'application.")
+	fmt.Println("13. Horaires d'ouverture - Définir pour chaque jour de la semaine les heures où une salle est réservable.")
+	fmt.Println("14. Jours de fermeture - Jours fériés et fermetures du site, aucune réservation possible.")
+	fmt.Println("15. Utilisateurs - Lister et créer les utilisateurs (rôle user ou admin).")
+	fmt.Println("16. Règles de réservation - Durée min/max, alignement, délai et horizon, par salle ou par rôle.")
+	fmt.Println("17. Quotas - Heures par semaine, réservations à venir et réservations par salle et par jour, par utilisateur ou groupe.")
+	fmt.Println("18. Créneaux libres - Les premiers créneaux d'une durée donnée, toutes salles confondues, selon la capacité, les équipements et les heures préférées.")
+	fmt.Println("19. Réservation multi-salles - Le même créneau dans plusieurs salles, toutes réservées ou aucune ; annulables ensemble via l'option 5.")
+	fmt.Println("20. Salles composées - Une salle parente regroupe des sous-salles : réserver l'une bloque l'autre.")
+	fmt.Println("21. Check-in - À faire entre 15 min avant et 15 min après le début, sinon la réservation est libérée.")
+	fmt.Println("22. Non honorées - Nombre de réservations libérées faute de check-in, par utilisateur.")
+	fmt.Println("23. Périodes de gel - Sessions d'examens... pendant lesquelles seuls les administrateurs réservent, pour le site, un bâtiment ou une salle.")
+	fmt.Println("24. Planning - Grille salles × créneaux d'une semaine ou d'une journée, avec l'auteur de chaque réservation ; navigation vers la période suivante ou précédente.")
+	fmt.Println("25. Exportation iCalendar - Toutes les réservations, celles d'une salle ou celles d'un utilisateur, à importer dans une application de calendrier.")
+	fmt.Println("26. Abonnements iCalendar - URL secrète par salle ou par utilisateur, à laquelle une application de calendrier s'abonne ; révocable à tout moment.")
+	fmt.Println("27. Import iCalendar - Crée les réservations d'un fichier .ics (salle retrouvée par son nom dans LOCATION) après une simulation à confirmer.")
+	fmt.Println("28. Exportation Excel - Une feuille par salle et une feuille de résumé, dates et heures reconnues par Excel ; mêmes filtres que les exports CSV et JSON.")
+	fmt.Println("29. Affiches de la semaine - Planning imprimable d'une salle (réservations, horaires, organisateurs) ou livret de toutes les salles d'un bâtiment, en PDF ou HTML.")
+	fmt.Println("30. Quitter - Pour fermer l'application.")
 	fmt.Println("\nAppuyez sur 'Entrée' pour retourner au menu principal.")
 	bufio.NewReader(os.Stdin).ReadBytes('\n')
 }
 
-func NavigationOptions(db *sql.DB, scanner *bufio.Scanner) {
+// Proposé après chaque action ; renvoie false si l'utilisateur choisit de quitter
+func NavigationOptions(scanner *bufio.Scanner) bool {
 	for {
 		fmt.Println("\n1. Retourner au menu principal")
 		fmt.Println("2. Quitter")
 		fmt.Print("\nChoisissez une option : ")
 
-		scanner.Scan()
-		choice := scanner.Text()
-
-		switch choice {
+		if !scanner.Scan() {
+			return false
+		}
+		switch scanner.Text() {
 		case "1":
-			return
+			return true
 		case "2":
-			fmt.Println("Merci d'avoir utilisé le service. À bientôt !")
-			os.Exit(0)
+			return false
 		default:
 			fmt.Println("Option non valide. Veuillez choisir une option entre 1 et 2.")
 		}
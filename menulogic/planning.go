@@ -0,0 +1,55 @@
+package menulogic
+
+import (
+	"Reserve-Go/planninglogic"
+	"Reserve-Go/utils"
+	"bufio"
+	"database/sql"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+)
+
+// Grille d'occupation des salles, navigable semaine par semaine (ou jour par jour)
+func ShowPlanning(db *sql.DB, scanner *bufio.Scanner) {
+	fmt.Println("Entrez une date de la période à afficher (YYYY-MM-DD, vide pour aujourd'hui) :")
+	scanner.Scan()
+	date := strings.TrimSpace(scanner.Text())
+	if date == "" {
+		date = time.Now().Format(utils.DateLayout)
+	}
+
+	fmt.Println("Vue (1 = semaine, 2 = journée) :")
+	scanner.Scan()
+	view := planninglogic.WeekView
+	if strings.TrimSpace(scanner.Text()) == "2" {
+		view = planninglogic.DayView
+	}
+
+	for {
+		grid, err := planninglogic.BuildGrid(db, date, view)
+		if err != nil {
+			log.Printf("Erreur lors de la construction du planning : %v", err)
+			return
+		}
+		if err := planninglogic.WriteText(os.Stdout, grid); err != nil {
+			log.Printf("Erreur lors de l'affichage du planning : %v", err)
+			return
+		}
+
+		fmt.Println("\ns = suivant, p = précédent, vide pour revenir :")
+		if !scanner.Scan() {
+			return
+		}
+		switch strings.ToLower(strings.TrimSpace(scanner.Text())) {
+		case "s":
+			date = grid.Next
+		case "p":
+			date = grid.Previous
+		default:
+			return
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package menulogic
+
+import (
+	"Reserve-Go/models"
+	"Reserve-Go/quotalogic"
+	"Reserve-Go/utils"
+	"bufio"
+	"database/sql"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+)
+
+// Affiche la consommation de quotas d'un utilisateur pour la semaine en cours
+func ShowQuotaUsage(db *sql.DB, scanner *bufio.Scanner) {
+	user, err := askUser(db, scanner)
+	if err != nil {
+		fmt.Println("Erreur :", err)
+		return
+	}
+	today := time.Now().Format(utils.DateLayout)
+	usage, err := quotalogic.GetUsage(db, user.ID, today)
+	if err != nil {
+		log.Printf("Erreur lors du calcul des quotas : %v", err)
+		return
+	}
+	quotas, err := quotalogic.GetApplicableQuotas(db, user)
+	if err != nil {
+		log.Printf("Erreur lors de la récupération des quotas : %v", err)
+		return
+	}
+
+	fmt.Printf("Consommation de %s (semaine du %s au %s) :\n", user.Name, usage.WeekStart, usage.WeekEnd)
+	fmt.Printf("Heures réservées cette semaine : %s\n", quotalogic.FormatMinutes(usage.WeekMinutes))
+	fmt.Printf("Réservations à venir : %d\n", usage.FutureBookings)
+	for roomID, count := range usage.RoomDayCounts {
+		fmt.Printf("Réservations aujourd'hui dans la salle %d : %d\n", roomID, count)
+	}
+	if len(quotas) == 0 {
+		fmt.Println("Aucun quota ne s'applique à cet utilisateur.")
+	}
+	for _, q := range quotas {
+		fmt.Printf("Quota %d (%s) : %s h/semaine, %s réservations à venir, %s par salle et par jour\n",
+			q.ID, quotalogic.DescribeScope(q), quotalogic.DescribeLimit(q.MaxHoursPerWeek), quotalogic.DescribeLimit(q.MaxConcurrent), quotalogic.DescribeLimit(q.MaxPerRoomPerDay))
+	}
+}
+
+func ManageQuotas(db *sql.DB, scanner *bufio.Scanner) {
+	quotas, err := quotalogic.GetQuotas(db)
+	if err != nil {
+		log.Printf("Erreur lors de la récupération des quotas : %v", err)
+		return
+	}
+	fmt.Println("Quotas :")
+	if len(quotas) == 0 {
+		fmt.Println("Aucun quota.")
+	}
+	for _, q := range quotas {
+		fmt.Printf("ID: %d (%s) : %s h/semaine, %s réservations à venir, %s par salle et par jour\n",
+			q.ID, quotalogic.DescribeScope(q), quotalogic.DescribeLimit(q.MaxHoursPerWeek), quotalogic.DescribeLimit(q.MaxConcurrent), quotalogic.DescribeLimit(q.MaxPerRoomPerDay))
+	}
+
+	fmt.Println("\n1. Voir la consommation d'un utilisateur")
+	fmt.Println("2. Ajouter un quota")
+	fmt.Println("3. Supprimer un quota")
+	fmt.Println("4. Retour")
+	scanner.Scan()
+	switch scanner.Text() {
+	case "1":
+		ShowQuotaUsage(db, scanner)
+	case "2":
+		addQuota(db, scanner)
+	case "3":
+		fmt.Println("Entrez l'ID du quota à supprimer :")
+		scanner.Scan()
+		id, err := strconv.Atoi(scanner.Text())
+		if err != nil {
+			log.Printf("Erreur : ID invalide. %v", err)
+			return
+		}
+		if err := quotalogic.DeleteQuota(db, id); err != nil {
+			log.Printf("Erreur lors de la suppression du quota : %v", err)
+		} else {
+			fmt.Println("Quota supprimé.")
+		}
+	}
+}
+
+func addQuota(db *sql.DB, scanner *bufio.Scanner) {
+	var q models.Quota
+	var err error
+
+	fmt.Println("Entrez l'ID de l'utilisateur concerné (vide pour un groupe ou pour tout le monde) :")
+	scanner.Scan()
+	if scanner.Text() != "" {
+		if q.UserID, err = strconv.Atoi(scanner.Text()); err != nil {
+			log.Printf("Erreur : ID invalide. %v", err)
+			return
+		}
+	} else {
+		fmt.Println("Entrez le groupe concerné (vide pour tout le monde) :")
+		scanner.Scan()
+		q.Group = scanner.Text()
+	}
+
+	limits := []struct {
+		prompt string
+		value  *int
+	}{
+		{"Nombre d'heures maximum par semaine (0 = illimité) :", &q.MaxHoursPerWeek},
+		{"Nombre de réservations à venir maximum (0 = illimité) :", &q.MaxConcurrent},
+		{"Nombre de réservations maximum par salle et par jour (0 = illimité) :", &q.MaxPerRoomPerDay},
+	}
+	for _, limit := range limits {
+		fmt.Println(limit.prompt)
+		scanner.Scan()
+		if *limit.value, err = strconv.Atoi(scanner.Text()); err != nil || *limit.value < 0 {
+			log.Printf("Erreur : valeur invalide %q", scanner.Text())
+			return
+		}
+	}
+
+	if _, err := quotalogic.InsertQuota(db, q); err != nil {
+		log.Printf("Erreur lors de l'ajout du quota : %v", err)
+	} else {
+		fmt.Println("Quota ajouté avec succès.")
+	}
+}
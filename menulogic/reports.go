@@ -0,0 +1,82 @@
+package menulogic
+
+import (
+	"Reserve-Go/reportlogic"
+	"Reserve-Go/utils"
+	"bufio"
+	"bytes"
+	"database/sql"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Affiches de la semaine d'une salle, ou livret de toutes les salles d'un bâtiment, en PDF ou HTML
+func PrintSchedules(db *sql.DB, scanner *bufio.Scanner) {
+	fmt.Println("Imprimer : 1 = l'affiche d'une salle, 2 = le livret d'un bâtiment")
+	scanner.Scan()
+	choice := strings.TrimSpace(scanner.Text())
+
+	var roomID int
+	var building string
+	switch choice {
+	case "1":
+		fmt.Println("Entrez l'ID de la salle :")
+		scanner.Scan()
+		id, err := strconv.Atoi(strings.TrimSpace(scanner.Text()))
+		if err != nil {
+			fmt.Println("Erreur : ID invalide.")
+			return
+		}
+		roomID = id
+	case "2":
+		fmt.Println("Entrez le nom du bâtiment :")
+		scanner.Scan()
+		building = strings.TrimSpace(scanner.Text())
+	default:
+		fmt.Println("Choix non valide.")
+		return
+	}
+
+	fmt.Println("Entrez une date de la semaine à imprimer (YYYY-MM-DD, vide pour aujourd'hui) :")
+	scanner.Scan()
+	date := strings.TrimSpace(scanner.Text())
+	if date == "" {
+		date = time.Now().Format(utils.DateLayout)
+	}
+	fmt.Println("Format (1 = PDF, 2 = HTML) :")
+	scanner.Scan()
+	format := "pdf"
+	if strings.TrimSpace(scanner.Text()) == "2" {
+		format = "html"
+	}
+
+	booklet, err := reportlogic.Build(db, roomID, building, date)
+	if err != nil {
+		fmt.Println("Erreur :", err)
+		return
+	}
+	filename := fmt.Sprintf("affiches-%s.%s", booklet.From, format)
+	if roomID != 0 {
+		filename = fmt.Sprintf("affiche-salle-%d-%s.%s", roomID, booklet.From, format)
+	}
+	fmt.Printf("Entrez le nom du fichier (vide pour %s) :\n", filename)
+	scanner.Scan()
+	if value := strings.TrimSpace(scanner.Text()); value != "" {
+		filename = value
+	}
+
+	var buf bytes.Buffer
+	if err := reportlogic.Write(&buf, booklet, format, time.Now()); err != nil {
+		log.Printf("Erreur lors de la mise en page des affiches : %v", err)
+		return
+	}
+	if err := os.WriteFile(filename, buf.Bytes(), 0o644); err != nil {
+		log.Printf("Erreur lors de l'écriture des affiches : %v", err)
+		return
+	}
+	fmt.Println("Affiches enregistrées dans", filename)
+}
@@ -0,0 +1,273 @@
+package menulogic
+
+import (
+	"Reserve-Go/models"
+	"Reserve-Go/reservationlogic"
+	"Reserve-Go/roomlogic"
+	"Reserve-Go/utils"
+	"bufio"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+)
+
+func CreateReservation(db *sql.DB, scanner *bufio.Scanner) {
+	fmt.Println(utils.ColorString(utils.ColorBlue, strings.Repeat("-", 35)))
+	fmt.Println("Création d'une réservation...")
+	fmt.Println(utils.ColorString(utils.ColorBlue, strings.Repeat("-", 35)))
+
+	user, err := askUser(db, scanner)
+	if err != nil {
+		fmt.Println("Erreur :", err)
+		return
+	}
+
+	fmt.Println("Entrez l'ID de la salle :")
+	scanner.Scan()
+	roomID := scanner.Text()
+
+	fmt.Println("Entrez la date de réservation (YYYY-MM-DD) :")
+	scanner.Scan()
+	date := scanner.Text()
+
+	fmt.Println("Entrez l'heure de début (HH:MM:SS) :")
+	scanner.Scan()
+	startTime := scanner.Text()
+
+	fmt.Println("Entrez l'heure de fin (HH:MM:SS) :")
+	scanner.Scan()
+	endTime := scanner.Text()
+
+	fmt.Println("Nombre de semaines de répétition (vide ou 1 pour une réservation unique) :")
+	scanner.Scan()
+	count := 1
+	if value := strings.TrimSpace(scanner.Text()); value != "" {
+		if count, err = strconv.Atoi(value); err != nil {
+			fmt.Println("Erreur : nombre de semaines invalide.")
+			return
+		}
+	}
+
+	id, err := strconv.Atoi(roomID)
+	if err != nil {
+		fmt.Println("Erreur : ID de salle invalide. Veuillez entrer un nombre.")
+		return
+	}
+
+	if count > 1 {
+		series := models.Series{RoomID: id, StartDate: date, StartTime: startTime, EndTime: endTime,
+			Frequency: models.FrequencyWeekly, Interval: 1, Count: count}
+		seriesID, err := reservationlogic.BookSeries(db, user, series)
+		if err != nil {
+			fmt.Println("Série refusée :", err)
+			return
+		}
+		fmt.Printf("Série de %d réservations hebdomadaires créée avec succès (série %s).\n", count, seriesID)
+		return
+	}
+
+	reservationID, err := reservationlogic.BookReservation(db, user, id, date, startTime, endTime)
+	switch {
+	case err == nil:
+		fmt.Println("Réservation créée avec succès. Numéro de réservation :", reservationID)
+	case errors.Is(err, reservationlogic.ErrRoomUnavailable):
+		fmt.Println("La salle n'est pas disponible pour le créneau demandé.")
+		printAlternatives(db, id, date, startTime, endTime)
+	default:
+		fmt.Println("Réservation refusée :", err)
+	}
+}
+
+func printAlternatives(db *sql.DB, roomID int, date, startTime, endTime string) {
+	alternatives, err := reservationlogic.SuggestAlternatives(db, roomID, date, startTime, endTime)
+	if err != nil {
+		log.Printf("Erreur lors de la recherche d'alternatives : %v", err)
+		return
+	}
+	if len(alternatives.Conflicts) > 0 {
+		fmt.Println("Réservations en conflit :")
+		for _, r := range alternatives.Conflicts {
+			fmt.Printf("ID: %d, Utilisateur: %d, Début: %s, Fin: %s\n", r.ID, r.UserID, r.StartTime, r.EndTime)
+		}
+	}
+	if len(alternatives.SameRoom) > 0 {
+		fmt.Println("Créneaux libres dans la même salle ce jour-là :")
+		for _, slot := range alternatives.SameRoom {
+			fmt.Printf("%s - %s\n", slot.StartTime, slot.EndTime)
+		}
+	} else {
+		fmt.Println("Aucun autre créneau de cette durée dans la même salle ce jour-là.")
+	}
+	if len(alternatives.OtherRooms) > 0 {
+		fmt.Println("Autres salles libres au créneau demandé :")
+		for _, room := range alternatives.OtherRooms {
+			fmt.Printf("ID: %d, Nom: %s, Capacité: %d\n", room.ID, room.Name, room.Capacity)
+		}
+	} else {
+		fmt.Println("Aucune autre salle de capacité suffisante n'est libre à ce créneau.")
+	}
+}
+
+func ViewReservationsByRoom(db *sql.DB, scanner *bufio.Scanner) {
+	fmt.Print("Entrez l'ID de la salle (nombre entier) : ")
+	scanner.Scan()
+	roomIDStr := scanner.Text()
+
+	// Conversion de l'ID de la salle en int
+	roomID, err := strconv.Atoi(roomIDStr)
+	if err != nil {
+		fmt.Println("Erreur : ID de salle invalide. Veuillez entrer un nombre.")
+		return
+	}
+
+	// Vérifier si la salle existe
+	if !roomlogic.IsRoomExists(db, roomID) {
+		fmt.Println("La salle avec l'ID", roomID, "n'existe pas.")
+		return
+	}
+
+	// Appel à getReservationsByRoom avec l'ID de la salle
+	reservations, err := reservationlogic.GetReservationsByRoom(db, roomID)
+	if err != nil {
+		fmt.Println("Erreur lors de la récupération des réservations :", err)
+		return
+	}
+
+	// Affichage des réservations
+	if len(reservations) == 0 {
+		fmt.Println("Aucune réservation trouvée pour la salle", roomID)
+		return
+	}
+
+	fmt.Println("Réservations pour la salle", roomID)
+	for _, reservation := range reservations {
+		fmt.Printf("ID: %d, Date: %s, Début: %s, Fin: %s\n", reservation.ID, reservation.Date, reservation.StartTime, reservation.EndTime)
+	}
+}
+
+func CancelReservation(db *sql.DB, scanner *bufio.Scanner) {
+	fmt.Print("Entrez l'identifiant de la réservation à annuler : ")
+	scanner.Scan()
+	reservationID, err := strconv.Atoi(scanner.Text())
+	if err != nil {
+		fmt.Println("Erreur : identifiant invalide.")
+		return
+	}
+
+	reservation, err := reservationlogic.GetReservation(db, reservationID)
+	if err != nil {
+		fmt.Println("Erreur :", err)
+		return
+	}
+	// Une réservation multi-salles peut être annulée avec tout son groupe
+	withGroup := false
+	if reservation.GroupID != "" {
+		group, err := reservationlogic.GetReservationsByGroup(db, reservation.GroupID)
+		if err != nil {
+			fmt.Println("Erreur lors de la récupération du groupe :", err)
+			return
+		}
+		fmt.Printf("Cette réservation fait partie du groupe %s (%d salles). Annuler tout le groupe ? (o/n) : ", reservation.GroupID, len(group))
+		scanner.Scan()
+		withGroup = strings.EqualFold(scanner.Text(), "o")
+	}
+
+	count, err := reservationlogic.CancelReservation(db, reservationID, withGroup)
+	switch {
+	case err != nil:
+		fmt.Println("Erreur lors de l'annulation de la réservation :", err)
+	case withGroup:
+		fmt.Printf("Groupe de réservations annulé avec succès (%d réservations).\n", count)
+	default:
+		fmt.Println("Réservation annulée avec succès.")
+	}
+}
+
+func ViewReservations(db *sql.DB) {
+	fmt.Println("Visualisation des réservations:")
+
+	reservations, err := reservationlogic.GetAllReservations(db)
+	if err != nil {
+		log.Printf("Erreur lors de la récupération des réservations : %v", err)
+		return
+	}
+	for _, reservation := range reservations {
+		fmt.Printf("ID: %d, Salle: %d, Utilisateur: %d, Date: %s, Début: %s, Fin: %s, Statut: %s",
+			reservation.ID, reservation.RoomID, reservation.UserID, reservation.Date, reservation.StartTime, reservation.EndTime, reservation.Status)
+		if reservation.GroupID != "" {
+			fmt.Printf(", Groupe: %s", reservation.GroupID)
+		}
+		fmt.Println()
+	}
+}
+
+// Fonction pour récupérer et afficher les réservations par date
+func ViewReservationsByDate(db *sql.DB, scanner *bufio.Scanner) {
+	fmt.Print("Entrez la date pour laquelle vous souhaitez voir les réservations (format YYYY-MM-DD) : ")
+	scanner.Scan()
+	date := scanner.Text()
+
+	// Validation de la date (simple vérification de format)
+	if _, err := utils.ParseDate(date); err != nil {
+		fmt.Println("Erreur : Format de date invalide.")
+		return
+	}
+
+	// Appel à la fonction pour obtenir les réservations
+	reservations, err := reservationlogic.GetReservationsByDate(db, date)
+	if err != nil {
+		fmt.Println("Erreur lors de la récupération des réservations :", err)
+		return
+	}
+
+	// Affichage des réservations
+	if len(reservations) == 0 {
+		fmt.Println("Aucune réservation trouvée pour la date", date)
+		return
+	}
+
+	fmt.Println("Réservations pour la date", date)
+	for _, reservation := range reservations {
+		fmt.Printf("ID Réservation: %d, ID Salle: %d, Début: %s, Fin: %s\n", reservation.ID, reservation.RoomID, reservation.StartTime, reservation.EndTime)
+	}
+}
+
+func CreateGroupReservation(db *sql.DB, scanner *bufio.Scanner) {
+	fmt.Println("Création d'une réservation multi-salles...")
+
+	user, err := askUser(db, scanner)
+	if err != nil {
+		fmt.Println("Erreur :", err)
+		return
+	}
+
+	fmt.Println("Entrez les IDs des salles séparés par des virgules (ex : 1,2,5) :")
+	scanner.Scan()
+	roomIDs, err := reservationlogic.ParseRoomIDs(scanner.Text())
+	if err != nil {
+		fmt.Println("Erreur :", err)
+		return
+	}
+
+	fmt.Println("Entrez la date de réservation (YYYY-MM-DD) :")
+	scanner.Scan()
+	date := scanner.Text()
+
+	fmt.Println("Entrez l'heure de début (HH:MM:SS) :")
+	scanner.Scan()
+	startTime := scanner.Text()
+
+	fmt.Println("Entrez l'heure de fin (HH:MM:SS) :")
+	scanner.Scan()
+	endTime := scanner.Text()
+
+	groupID, err := reservationlogic.BookGroup(db, user, roomIDs, date, startTime, endTime)
+	if err != nil {
+		fmt.Println("Aucune salle n'a été réservée :", err)
+	} else {
+		fmt.Printf("%d salles réservées avec succès (groupe %s).\n", len(roomIDs), groupID)
+	}
+}
@@ -0,0 +1,187 @@
+package menulogic
+
+import (
+	"Reserve-Go/models"
+	"Reserve-Go/roomlogic"
+	"bufio"
+	"database/sql"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+)
+
+func AddRoom(db *sql.DB, scanner *bufio.Scanner) {
+	fmt.Println("Ajout d'une nouvelle salle...")
+
+	var room models.Room
+	fmt.Println("Entrez le nom de la salle :")
+	scanner.Scan()
+	room.Name = scanner.Text()
+
+	fmt.Println("Entrez la capacité de la salle :")
+	scanner.Scan()
+	capacity, err := strconv.Atoi(scanner.Text())
+	if err != nil {
+		log.Printf("Erreur : Capacité invalide. %v", err)
+		return
+	}
+	room.Capacity = capacity
+
+	fmt.Println("Entrez le temps de préparation avant chaque réservation, en minutes (vide pour 0) :")
+	scanner.Scan()
+	if room.BufferBefore, err = readMinutes(scanner.Text()); err != nil {
+		log.Printf("Erreur : Durée invalide. %v", err)
+		return
+	}
+
+	fmt.Println("Entrez le temps de nettoyage après chaque réservation, en minutes (vide pour 0) :")
+	scanner.Scan()
+	if room.BufferAfter, err = readMinutes(scanner.Text()); err != nil {
+		log.Printf("Erreur : Durée invalide. %v", err)
+		return
+	}
+
+	fmt.Println("Entrez les équipements de la salle, séparés par des virgules (vide si aucun) :")
+	scanner.Scan()
+	room.Features = roomlogic.ParseFeatures(scanner.Text())
+
+	fmt.Println("Entrez le bâtiment de la salle (vide si aucun) :")
+	scanner.Scan()
+	room.Building = scanner.Text()
+
+	if _, err := roomlogic.InsertRoom(db, room); err != nil {
+		log.Printf("Erreur lors de l'ajout de la salle : %v", err)
+	} else {
+		fmt.Println("Salle ajoutée avec succès.")
+	}
+}
+
+func ListAvailableRooms(db *sql.DB, scanner *bufio.Scanner) {
+	fmt.Println("Saisissez la date ( AAAA-MM-JJ) ")
+	scanner.Scan()
+	date := scanner.Text()
+	fmt.Println("Saisissez l'heure de début ( HH:MM:SS) ")
+	scanner.Scan()
+	startTime := scanner.Text()
+	fmt.Println("Saisissez l'heure de fin (HH:MM:SS) ")
+	scanner.Scan()
+	endTime := scanner.Text()
+
+	rooms, err := roomlogic.GetAvailableRooms(db, date, startTime, endTime)
+	if err != nil {
+		log.Printf("Erreur: %v", err)
+		return
+	}
+	fmt.Printf("Salles disponnibles:")
+	for _, room := range rooms {
+		fmt.Printf("ID: %d, Nom: %s, Capacité: %d\n", room.ID, room.Name, room.Capacity)
+	}
+}
+
+func UpdateRoom(db *sql.DB, scanner *bufio.Scanner) {
+	fmt.Println("Modification d'une salle existante...")
+
+	fmt.Println("Entrez l'ID de la salle à modifier :")
+	scanner.Scan()
+	id, err := strconv.Atoi(scanner.Text())
+	if err != nil {
+		log.Printf("Erreur : ID invalide. %v", err)
+		return
+	}
+
+	var update roomlogic.RoomUpdate
+	fmt.Println("Entrez le nouveau nom (laissez vide pour ne pas modifier) :")
+	scanner.Scan()
+	if name := scanner.Text(); name != "" {
+		update.Name = &name
+	}
+
+	fmt.Println("Entrez la nouvelle capacité (laissez vide pour ne pas modifier) :")
+	scanner.Scan()
+	if scanner.Text() != "" {
+		capacity, err := strconv.Atoi(scanner.Text())
+		if err != nil {
+			log.Printf("Erreur : Capacité invalide. %v", err)
+			return
+		}
+		update.Capacity = &capacity
+	}
+
+	fmt.Println("Entrez le nouveau temps de préparation en minutes (laissez vide pour ne pas modifier) :")
+	scanner.Scan()
+	if update.BufferBefore, err = readOptionalMinutes(scanner.Text()); err != nil {
+		log.Printf("Erreur : Durée invalide. %v", err)
+		return
+	}
+
+	fmt.Println("Entrez le nouveau temps de nettoyage en minutes (laissez vide pour ne pas modifier) :")
+	scanner.Scan()
+	if update.BufferAfter, err = readOptionalMinutes(scanner.Text()); err != nil {
+		log.Printf("Erreur : Durée invalide. %v", err)
+		return
+	}
+
+	fmt.Println("Entrez les nouveaux équipements séparés par des virgules (laissez vide pour ne pas modifier, \"-\" pour aucun) :")
+	scanner.Scan()
+	switch scanner.Text() {
+	case "":
+	case "-":
+		update.Features = &[]string{}
+	default:
+		features := roomlogic.ParseFeatures(scanner.Text())
+		update.Features = &features
+	}
+
+	fmt.Println("Entrez le nouveau bâtiment (laissez vide pour ne pas modifier) :")
+	scanner.Scan()
+	if building := strings.TrimSpace(scanner.Text()); building != "" {
+		update.Building = &building
+	}
+
+	if _, err := roomlogic.UpdateRoom(db, id, update); err != nil {
+		log.Printf("Erreur lors de la modification de la salle : %v", err)
+	} else {
+		fmt.Println("Salle modifiée avec succès.")
+	}
+}
+
+func ListRooms(db *sql.DB) {
+	fmt.Println("Liste des salles disponibles:")
+
+	rooms, err := roomlogic.GetRooms(db)
+	if err != nil {
+		log.Printf("Erreur lors de la récupération des salles : %v", err)
+		return
+	}
+
+	for _, room := range rooms {
+		fmt.Printf("ID: %d, Nom: %s, Bâtiment: %s, Capacité: %d, Préparation: %d min, Nettoyage: %d min, Équipements: %s\n",
+			room.ID, room.Name, room.Building, room.Capacity, room.BufferBefore, room.BufferAfter, roomlogic.JoinFeatures(room.Features))
+	}
+}
+
+func readMinutes(value string) (int, error) {
+	if value == "" {
+		return 0, nil
+	}
+	minutes, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, err
+	}
+	if minutes < 0 {
+		return 0, fmt.Errorf("durée négative : %d", minutes)
+	}
+	return minutes, nil
+}
+
+func readOptionalMinutes(value string) (*int, error) {
+	if value == "" {
+		return nil, nil
+	}
+	minutes, err := readMinutes(value)
+	if err != nil {
+		return nil, err
+	}
+	return &minutes, nil
+}
@@ -0,0 +1,95 @@
+package menulogic
+
+import (
+	"Reserve-Go/models"
+	"Reserve-Go/rulelogic"
+	"bufio"
+	"database/sql"
+	"fmt"
+	"log"
+	"strconv"
+)
+
+func ManageRules(db *sql.DB, scanner *bufio.Scanner) {
+	rules, err := rulelogic.GetRules(db)
+	if err != nil {
+		log.Printf("Erreur lors de la récupération des règles : %v", err)
+		return
+	}
+	fmt.Println("Règles de réservation :")
+	if len(rules) == 0 {
+		fmt.Println("Aucune règle.")
+	}
+	for _, r := range rules {
+		fmt.Printf("ID: %d, %s : %s = %d (%s)\n", r.ID, r.Name, rulelogic.RuleDescriptions[r.Type], r.Value, rulelogic.DescribeScope(r))
+	}
+
+	fmt.Println("\n1. Ajouter une règle")
+	fmt.Println("2. Supprimer une règle")
+	fmt.Println("3. Retour")
+	scanner.Scan()
+	switch scanner.Text() {
+	case "1":
+		addRule(db, scanner)
+	case "2":
+		fmt.Println("Entrez l'ID de la règle à supprimer :")
+		scanner.Scan()
+		id, err := strconv.Atoi(scanner.Text())
+		if err != nil {
+			log.Printf("Erreur : ID invalide. %v", err)
+			return
+		}
+		if err := rulelogic.DeleteRule(db, id); err != nil {
+			log.Printf("Erreur lors de la suppression de la règle : %v", err)
+		} else {
+			fmt.Println("Règle supprimée.")
+		}
+	default:
+		return
+	}
+}
+
+func addRule(db *sql.DB, scanner *bufio.Scanner) {
+	var rule models.BookingRule
+
+	fmt.Println("Entrez le nom de la règle :")
+	scanner.Scan()
+	rule.Name = scanner.Text()
+
+	fmt.Println("Types disponibles :")
+	for _, t := range []string{rulelogic.RuleMinDuration, rulelogic.RuleMaxDuration, rulelogic.RuleGranularity, rulelogic.RuleLeadTime, rulelogic.RuleHorizon} {
+		fmt.Printf("  %s : %s\n", t, rulelogic.RuleDescriptions[t])
+	}
+	fmt.Println("Entrez le type de la règle :")
+	scanner.Scan()
+	rule.Type = scanner.Text()
+
+	fmt.Println("Entrez la valeur :")
+	scanner.Scan()
+	value, err := strconv.Atoi(scanner.Text())
+	if err != nil {
+		log.Printf("Erreur : valeur invalide. %v", err)
+		return
+	}
+	rule.Value = value
+
+	fmt.Println("Entrez l'ID de la salle concernée (vide pour toutes) :")
+	scanner.Scan()
+	if scanner.Text() != "" {
+		rule.RoomID, err = strconv.Atoi(scanner.Text())
+		if err != nil {
+			log.Printf("Erreur : ID invalide. %v", err)
+			return
+		}
+	}
+
+	fmt.Println("Entrez le rôle concerné (vide pour tous) :")
+	scanner.Scan()
+	rule.Role = scanner.Text()
+
+	if _, err := rulelogic.InsertRule(db, rule); err != nil {
+		log.Printf("Erreur lors de l'ajout de la règle : %v", err)
+	} else {
+		fmt.Println("Règle ajoutée avec succès.")
+	}
+}
@@ -0,0 +1,97 @@
+package menulogic
+
+import (
+	"Reserve-Go/roomlogic"
+	"Reserve-Go/slotlogic"
+	"Reserve-Go/utils"
+	"bufio"
+	"database/sql"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+)
+
+func FindFreeSlots(db *sql.DB, scanner *bufio.Scanner) {
+	var search slotlogic.Search
+	var err error
+
+	fmt.Println("Entrez votre ID utilisateur (vide pour un utilisateur ordinaire) :")
+	scanner.Scan()
+	if scanner.Text() != "" {
+		if search.UserID, err = strconv.Atoi(scanner.Text()); err != nil {
+			log.Printf("Erreur : ID invalide. %v", err)
+			return
+		}
+	}
+
+	fmt.Println("Entrez la durée souhaitée en minutes :")
+	scanner.Scan()
+	if search.Duration, err = strconv.Atoi(scanner.Text()); err != nil {
+		log.Printf("Erreur : durée invalide. %v", err)
+		return
+	}
+
+	today := time.Now().Format(utils.DateLayout)
+	fmt.Printf("Entrez la date de début de recherche (AAAA-MM-JJ, vide pour %s) :\n", today)
+	scanner.Scan()
+	search.From = scanner.Text()
+	if search.From == "" {
+		search.From = today
+	}
+	fmt.Println("Entrez la date de fin de recherche (AAAA-MM-JJ, vide pour 7 jours plus tard) :")
+	scanner.Scan()
+	search.To = scanner.Text()
+	if search.To == "" {
+		from, err := utils.ParseDate(search.From)
+		if err != nil {
+			fmt.Println("Erreur :", err)
+			return
+		}
+		search.To = from.AddDate(0, 0, 7).Format(utils.DateLayout)
+	}
+
+	fmt.Println("Entrez la capacité minimale (vide pour aucune) :")
+	scanner.Scan()
+	if scanner.Text() != "" {
+		if search.MinCapacity, err = strconv.Atoi(scanner.Text()); err != nil {
+			log.Printf("Erreur : capacité invalide. %v", err)
+			return
+		}
+	}
+
+	fmt.Println("Entrez les équipements requis séparés par des virgules (vide pour aucun) :")
+	scanner.Scan()
+	search.Features = roomlogic.ParseFeatures(scanner.Text())
+
+	fmt.Println("Entrez l'heure de début préférée (HH:MM, vide pour aucune) :")
+	scanner.Scan()
+	search.PreferredStart = scanner.Text()
+	fmt.Println("Entrez l'heure de fin préférée (HH:MM, vide pour aucune) :")
+	scanner.Scan()
+	search.PreferredEnd = scanner.Text()
+
+	fmt.Printf("Nombre de créneaux à afficher (vide pour %d) :\n", slotlogic.DefaultLimit)
+	scanner.Scan()
+	if scanner.Text() != "" {
+		if search.Limit, err = strconv.Atoi(scanner.Text()); err != nil {
+			log.Printf("Erreur : nombre invalide. %v", err)
+			return
+		}
+	}
+
+	slots, err := slotlogic.FindFreeSlots(db, search, time.Now())
+	if err != nil {
+		fmt.Println("Erreur lors de la recherche :", err)
+		return
+	}
+	if len(slots) == 0 {
+		fmt.Println("Aucun créneau libre trouvé pour ces critères.")
+	} else {
+		fmt.Println("Créneaux libres :")
+	}
+	for _, slot := range slots {
+		fmt.Printf("Salle %d (%s, %d places) : %s de %s à %s\n",
+			slot.RoomID, slot.RoomName, slot.Capacity, slot.Date, slot.StartTime, slot.EndTime)
+	}
+}
@@ -0,0 +1,58 @@
+package menulogic
+
+import (
+	"Reserve-Go/models"
+	"Reserve-Go/userlogic"
+	"bufio"
+	"database/sql"
+	"fmt"
+	"log"
+	"strconv"
+)
+
+// Demande l'identifiant de l'utilisateur qui effectue l'opération
+func askUser(db *sql.DB, scanner *bufio.Scanner) (*models.User, error) {
+	fmt.Println("Entrez votre identifiant utilisateur :")
+	scanner.Scan()
+	id, err := strconv.Atoi(scanner.Text())
+	if err != nil {
+		return nil, fmt.Errorf("identifiant utilisateur invalide : %q", scanner.Text())
+	}
+	return userlogic.GetUser(db, id)
+}
+
+func ManageUsers(db *sql.DB, scanner *bufio.Scanner) {
+	users, err := userlogic.GetUsers(db)
+	if err != nil {
+		log.Printf("Erreur lors de la récupération des utilisateurs : %v", err)
+		return
+	}
+	fmt.Println("Utilisateurs :")
+	for _, u := range users {
+		fmt.Printf("ID: %d, Nom: %s, Rôle: %s, Groupe: %s\n", u.ID, u.Name, u.Role, u.Group)
+	}
+
+	fmt.Println("\n1. Créer un utilisateur")
+	fmt.Println("2. Retour")
+	scanner.Scan()
+	if scanner.Text() != "1" {
+		return
+	}
+
+	fmt.Println("Entrez le nom de l'utilisateur :")
+	scanner.Scan()
+	name := scanner.Text()
+	fmt.Printf("Entrez le rôle (%s ou %s, vide pour %s) :\n", userlogic.RoleUser, userlogic.RoleAdmin, userlogic.RoleUser)
+	scanner.Scan()
+	role := scanner.Text()
+	fmt.Println("Entrez le groupe (département, promotion... vide si aucun) :")
+	scanner.Scan()
+	group := scanner.Text()
+
+	id, err := userlogic.InsertUser(db, name, role, group)
+	if err != nil {
+		log.Printf("Erreur lors de la création de l'utilisateur : %v", err)
+	} else {
+		fmt.Println("Utilisateur créé avec l'ID", id)
+	}
+}
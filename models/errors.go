@@ -0,0 +1,39 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Catégories d'erreurs renvoyées par les packages métier. Les interfaces (menu,
+// sous-commandes, API) les testent avec errors.Is pour choisir leur réponse.
+var (
+	ErrNotFound  = errors.New("élément introuvable")
+	ErrInvalid   = errors.New("donnée invalide")
+	ErrConflict  = errors.New("conflit avec l'existant")
+	ErrForbidden = errors.New("opération non autorisée")
+	// Jeton d'API absent, inconnu ou révoqué
+	ErrUnauthorized = errors.New("authentification requise")
+)
+
+// Erreur métier : un message destiné à l'utilisateur, rattaché à une catégorie
+type Error struct {
+	Kind    error
+	Message string
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Kind
+}
+
+func NewError(kind error, message string) error {
+	return &Error{Kind: kind, Message: message}
+}
+
+func Errorf(kind error, format string, args ...interface{}) error {
+	return &Error{Kind: kind, Message: fmt.Sprintf(format, args...)}
+}
@@ -4,12 +4,129 @@ type Room struct {
 	ID       int
 	Name     string
 	Capacity int
+	// Minutes réservées au nettoyage / à l'installation avant et après chaque réservation
+	BufferBefore int
+	BufferAfter  int
+	// Équipements de la salle (projecteur, visio...)
+	Features  []string
+	Available bool
+	Building  string
 }
 
 type Reservation struct {
 	ID        int
 	RoomID    int
+	UserID    int
 	Date      string
 	StartTime string
 	EndTime   string
+	// Identifiant commun aux réservations d'un même groupe multi-salles (vide sinon)
+	GroupID string
+	// Identifiant de la série récurrente dont la réservation est une occurrence (vide sinon)
+	SeriesID string
+	Status   string
+}
+
+// Statuts d'une réservation. Une réservation « no_show » ou « cancelled » ne bloque plus la salle.
+const (
+	StatusConfirmed = "confirmed"
+	StatusCheckedIn = "checked_in"
+	StatusNoShow    = "no_show"
+	StatusCancelled = "cancelled"
+)
+
+// Série de réservations récurrentes (cours hebdomadaire...) : Count occurrences à partir
+// de StartDate, tous les Interval jours ou semaines selon Frequency
+type Series struct {
+	ID        string
+	RoomID    int
+	UserID    int
+	StartDate string
+	StartTime string
+	EndTime   string
+	Frequency string
+	Interval  int
+	Count     int
+}
+
+const (
+	FrequencyDaily  = "daily"
+	FrequencyWeekly = "weekly"
+)
+
+type OpeningHours struct {
+	ID        int
+	RoomID    int
+	Weekday   int
+	OpenTime  string
+	CloseTime string
+}
+
+type ClosureDay struct {
+	ID    int
+	Date  string
+	Label string
+}
+
+type User struct {
+	ID    int
+	Name  string
+	Role  string
+	Group string
+}
+
+// RoomID à 0 et Role vide : la règle s'applique à toutes les salles / tous les rôles
+type BookingRule struct {
+	ID     int
+	Name   string
+	Type   string
+	Value  int
+	RoomID int
+	Role   string
+}
+
+// UserID à 0 et Group vide : quota valable pour tout le monde. Une limite à 0 est illimitée.
+type Quota struct {
+	ID               int
+	UserID           int
+	Group            string
+	MaxHoursPerWeek  int
+	MaxConcurrent    int
+	MaxPerRoomPerDay int
+}
+
+// Période pendant laquelle seuls les administrateurs peuvent réserver.
+// RoomID à 0 et Building vide : tout le site est concerné.
+type Blackout struct {
+	ID       int
+	Name     string
+	StartAt  string
+	EndAt    string
+	RoomID   int
+	Building string
+}
+
+// Abonnement iCalendar à une salle (RoomID) ou à un utilisateur (UserID)
+type Feed struct {
+	Token     string
+	RoomID    int
+	UserID    int
+	CreatedAt string
+	// Vide tant que le flux n'est pas révoqué
+	RevokedAt string
+}
+
+// Jeton d'API : il identifie l'utilisateur au nom duquel les requêtes sont faites
+type APIToken struct {
+	Token     string
+	UserID    int
+	CreatedAt string
+	// Vide tant que le jeton n'est pas révoqué
+	RevokedAt string
+}
+
+// Une salle parente (grand amphi) est composée de sous-salles (ses moitiés)
+type RoomComponent struct {
+	ParentID int
+	ChildID  int
 }
@@ -0,0 +1,224 @@
+package planninglogic
+
+import (
+	"Reserve-Go/calendarlogic"
+	"Reserve-Go/models"
+	"Reserve-Go/reservationlogic"
+	"Reserve-Go/roomlogic"
+	"Reserve-Go/slotlogic"
+	"Reserve-Go/userlogic"
+	"Reserve-Go/utils"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+const (
+	// Durée d'une case de la grille, en minutes
+	SlotMinutes = 30
+	// Plage horaire affichée, élargie si des réservations en sortent
+	DefaultDayStart = 8 * 60
+	DefaultDayEnd   = 20 * 60
+)
+
+// Nombre de jours affichés par vue
+const (
+	DayView  = 1
+	WeekView = 7
+)
+
+// Cases consécutives d'une salle ayant le même occupant
+type Block struct {
+	StartTime string
+	EndTime   string
+	Slots     int
+	// Réservation qui occupe le bloc (nil si libre ou fermé)
+	Reservation *models.Reservation
+	UserName    string
+	// Jour de fermeture ou hors des horaires d'ouverture de la salle
+	Closed bool
+}
+
+type Row struct {
+	Room   models.Room
+	Blocks []Block
+}
+
+type Day struct {
+	Date  string
+	Label string
+	// Libellé du jour de fermeture (vide si le site est ouvert)
+	Closure string
+	Rows    []Row
+}
+
+// Grille salles × créneaux sur un jour ou une semaine
+type Grid struct {
+	From string
+	To   string
+	// Premier jour des vues précédente et suivante
+	Previous string
+	Next     string
+	// Heure de début de chaque case
+	Times []string
+	Days  []Day
+}
+
+// Lundi de la semaine d'une date
+func WeekStart(date time.Time) time.Time {
+	return date.AddDate(0, 0, 1-utils.IsoWeekday(date))
+}
+
+// Construit la grille d'occupation de toutes les salles ; une vue semaine commence au lundi de la semaine de la date
+func BuildGrid(db *sql.DB, date string, view int) (*Grid, error) {
+	if view != DayView && view != WeekView {
+		return nil, models.Errorf(models.ErrInvalid, "vue inconnue : %d jour(s)", view)
+	}
+	from, err := utils.ParseDate(date)
+	if err != nil {
+		return nil, err
+	}
+	if view == WeekView {
+		from = WeekStart(from)
+	}
+	to := from.AddDate(0, 0, view-1)
+	grid := &Grid{
+		From:     from.Format(utils.DateLayout),
+		To:       to.Format(utils.DateLayout),
+		Previous: from.AddDate(0, 0, -view).Format(utils.DateLayout),
+		Next:     from.AddDate(0, 0, view).Format(utils.DateLayout),
+	}
+
+	rooms, err := roomlogic.GetRooms(db)
+	if err != nil {
+		return nil, err
+	}
+	reservations, err := reservationlogic.GetReservationsBetween(db, grid.From, grid.To)
+	if err != nil {
+		return nil, err
+	}
+	users, err := userlogic.GetUsers(db)
+	if err != nil {
+		return nil, err
+	}
+	closures, err := calendarlogic.GetClosureDays(db)
+	if err != nil {
+		return nil, err
+	}
+	hours := make(map[int][]models.OpeningHours, len(rooms))
+	for _, room := range rooms {
+		if hours[room.ID], err = calendarlogic.GetOpeningHours(db, room.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	userNames := make(map[int]string, len(users))
+	for _, u := range users {
+		userNames[u.ID] = u.Name
+	}
+	closureLabels := make(map[string]string)
+	for _, c := range closures {
+		closureLabels[c.Date] = c.Label
+	}
+
+	// Seules les réservations qui bloquent la salle sont affichées
+	var active []models.Reservation
+	dayStart, dayEnd := DefaultDayStart, DefaultDayEnd
+	for _, r := range reservations {
+		if r.Status != models.StatusConfirmed && r.Status != models.StatusCheckedIn {
+			continue
+		}
+		start, startErr := utils.ParseClock(r.StartTime)
+		end, endErr := utils.ParseClock(r.EndTime)
+		if startErr != nil || endErr != nil {
+			continue
+		}
+		dayStart = min(dayStart, start/60*60)
+		dayEnd = max(dayEnd, (end+59)/60*60)
+		active = append(active, r)
+	}
+	for t := dayStart; t < dayEnd; t += SlotMinutes {
+		grid.Times = append(grid.Times, utils.FormatClock(t))
+	}
+
+	for day := from; !day.After(to); day = day.AddDate(0, 0, 1) {
+		d := Day{
+			Date:    day.Format(utils.DateLayout),
+			Label:   DayLabel(day),
+			Closure: closureLabels[day.Format(utils.DateLayout)],
+		}
+		for _, room := range rooms {
+			cells := make([]*models.Reservation, len(grid.Times))
+			for i := range active {
+				r := &active[i]
+				if r.RoomID != room.ID || r.Date != d.Date {
+					continue
+				}
+				start, _ := utils.ParseClock(r.StartTime)
+				end, _ := utils.ParseClock(r.EndTime)
+				for slot := range cells {
+					slotStart := dayStart + slot*SlotMinutes
+					if cells[slot] == nil && start < slotStart+SlotMinutes && end > slotStart {
+						cells[slot] = r
+					}
+				}
+			}
+			windows := slotlogic.OpenWindows(hours[room.ID], utils.IsoWeekday(day))
+			d.Rows = append(d.Rows, Row{Room: room, Blocks: buildBlocks(cells, windows, d.Closure != "", dayStart, userNames)})
+		}
+		grid.Days = append(grid.Days, d)
+	}
+	return grid, nil
+}
+
+// Regroupe les cases d'une salle en blocs de même occupant
+func buildBlocks(cells []*models.Reservation, windows []roomlogic.Interval, closedDay bool, dayStart int, userNames map[int]string) []Block {
+	var blocks []Block
+	for slot, r := range cells {
+		slotStart := dayStart + slot*SlotMinutes
+		closed := r == nil && (closedDay || !isOpen(windows, slotStart, slotStart+SlotMinutes))
+		if n := len(blocks); n > 0 && blocks[n-1].Reservation == r && blocks[n-1].Closed == closed {
+			blocks[n-1].Slots++
+			blocks[n-1].EndTime = utils.FormatClock(slotStart + SlotMinutes)
+			continue
+		}
+		block := Block{
+			StartTime:   utils.FormatClock(slotStart),
+			EndTime:     utils.FormatClock(slotStart + SlotMinutes),
+			Slots:       1,
+			Reservation: r,
+			Closed:      closed,
+		}
+		if r != nil {
+			block.UserName = UserLabel(r.UserID, userNames)
+		}
+		blocks = append(blocks, block)
+	}
+	return blocks
+}
+
+// Une case est ouverte si elle tient entièrement dans une plage d'ouverture
+func isOpen(windows []roomlogic.Interval, start, end int) bool {
+	for _, w := range windows {
+		if start >= w.Start && end <= w.End {
+			return true
+		}
+	}
+	return false
+}
+
+// Nom affiché pour l'auteur d'une réservation
+func UserLabel(userID int, userNames map[int]string) string {
+	if name, ok := userNames[userID]; ok {
+		return name
+	}
+	if userID == 0 {
+		return "anonyme"
+	}
+	return fmt.Sprintf("utilisateur %d", userID)
+}
+
+// "lundi 13/05/2024"
+func DayLabel(day time.Time) string {
+	return utils.WeekdayName(utils.IsoWeekday(day)) + " " + day.Format("02/01/2006")
+}
@@ -0,0 +1,96 @@
+package planninglogic
+
+import (
+	"Reserve-Go/models"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Largeur d'une case dans la grille texte
+const cellWidth = 2
+
+// Lettres repérant les réservations d'une journée, reprises dans la légende
+const blockLetters = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+// Écrit la grille pour un terminal : une ligne par salle, une case par créneau, puis la légende de chaque jour
+func WriteText(w io.Writer, grid *Grid) error {
+	var b strings.Builder
+	if grid.From == grid.To {
+		fmt.Fprintf(&b, "Journée du %s\n", grid.Days[0].Label)
+	} else {
+		fmt.Fprintf(&b, "Semaine du %s au %s\n", grid.Days[0].Label, grid.Days[len(grid.Days)-1].Label)
+	}
+	fmt.Fprintf(&b, "Légende : %s libre, %s fermé, une lettre par réservation\n",
+		strings.Repeat(".", cellWidth), strings.Repeat("#", cellWidth))
+
+	nameWidth := len("Salle")
+	for _, row := range grid.Days[0].Rows {
+		nameWidth = max(nameWidth, len([]rune(row.Room.Name)))
+	}
+
+	for _, day := range grid.Days {
+		b.WriteString("\n")
+		b.WriteString(day.Label)
+		if day.Closure != "" {
+			fmt.Fprintf(&b, " - fermé : %s", day.Closure)
+		}
+		b.WriteString("\n")
+
+		fmt.Fprintf(&b, "%-*s ", nameWidth, "Salle")
+		for _, t := range grid.Times {
+			label := ""
+			if strings.HasSuffix(t, ":00:00") {
+				label = t[:2]
+			}
+			fmt.Fprintf(&b, "%-*s", cellWidth, label)
+		}
+		b.WriteString("\n")
+
+		var legend []string
+		for _, row := range day.Rows {
+			fmt.Fprintf(&b, "%-*s ", nameWidth, row.Room.Name)
+			for _, block := range row.Blocks {
+				mark := "."
+				switch {
+				case block.Reservation != nil:
+					mark = "*"
+					if len(legend) < len(blockLetters) {
+						mark = blockLetters[len(legend) : len(legend)+1]
+					}
+					legend = append(legend, fmt.Sprintf("  %s  %s-%s  %s  réservation %d, %s (%s)",
+						mark, strings.TrimSuffix(block.Reservation.StartTime, ":00"), strings.TrimSuffix(block.Reservation.EndTime, ":00"), row.Room.Name,
+						block.Reservation.ID, block.UserName, StatusLabel(block.Reservation.Status)))
+				case block.Closed:
+					mark = "#"
+				}
+				b.WriteString(strings.Repeat(mark, block.Slots*cellWidth))
+			}
+			b.WriteString("\n")
+		}
+		if len(legend) == 0 {
+			b.WriteString("  Aucune réservation.\n")
+		}
+		for _, line := range legend {
+			b.WriteString(line + "\n")
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// Libellé français du statut d'une réservation
+func StatusLabel(status string) string {
+	switch status {
+	case models.StatusConfirmed:
+		return "confirmée"
+	case models.StatusCheckedIn:
+		return "présence enregistrée"
+	case models.StatusNoShow:
+		return "non honorée"
+	case models.StatusCancelled:
+		return "annulée"
+	}
+	return status
+}
@@ -0,0 +1,413 @@
+package quotalogic
+
+import (
+	"Reserve-Go/models"
+	"Reserve-Go/utils"
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+// Consommation d'un utilisateur, calculée pour une date de référence
+type Usage struct {
+	WeekStart      string
+	WeekEnd        string
+	WeekMinutes    int
+	FutureBookings int
+	// Nombre de réservations de l'utilisateur par salle à la date de référence
+	RoomDayCounts map[int]int
+}
+
+// Erreur renvoyée lorsqu'une réservation dépasserait un quota
+type QuotaError struct {
+	Messages []string
+}
+
+func (e *QuotaError) Error() string {
+	return "quota dépassé : " + strings.Join(e.Messages, " ; ")
+}
+
+func (e *QuotaError) Unwrap() error {
+	return models.ErrForbidden
+}
+
+// Quotas qui s'appliquent à l'utilisateur : seuls les plus spécifiques comptent. Ses quotas
+// personnels remplacent ceux de son groupe, qui remplacent les quotas globaux, ce qui permet
+// aussi bien d'assouplir que de resserrer le quota global pour une personne ou un groupe.
+func GetApplicableQuotas(db *sql.DB, user *models.User) ([]models.Quota, error) {
+	query := `SELECT id, COALESCE(user_id, 0), COALESCE(group_name, ''), max_hours_per_week, max_concurrent, max_per_room_per_day
+              FROM quotas
+              WHERE user_id = ?
+                 OR (user_id IS NULL AND group_name = ?)
+                 OR (user_id IS NULL AND group_name IS NULL)
+              ORDER BY user_id IS NULL, group_name IS NULL, id`
+	rows, err := db.Query(query, user.ID, user.Group)
+	if err != nil {
+		return nil, err
+	}
+	quotas, err := scanQuotas(rows)
+	if err != nil {
+		return nil, err
+	}
+	return mostSpecific(quotas), nil
+}
+
+// Garde les quotas du niveau le plus spécifique (utilisateur, puis groupe, puis global) ;
+// quotas est trié du plus spécifique au plus général
+func mostSpecific(quotas []models.Quota) []models.Quota {
+	for i, q := range quotas {
+		if scopeLevel(q) != scopeLevel(quotas[0]) {
+			return quotas[:i]
+		}
+	}
+	return quotas
+}
+
+func scopeLevel(q models.Quota) int {
+	switch {
+	case q.UserID != 0:
+		return 0
+	case q.Group != "":
+		return 1
+	default:
+		return 2
+	}
+}
+
+func GetQuotas(db *sql.DB) ([]models.Quota, error) {
+	query := `SELECT id, COALESCE(user_id, 0), COALESCE(group_name, ''), max_hours_per_week, max_concurrent, max_per_room_per_day
+              FROM quotas ORDER BY id`
+	rows, err := db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	return scanQuotas(rows)
+}
+
+func scanQuotas(rows *sql.Rows) ([]models.Quota, error) {
+	var quotas []models.Quota
+	defer func(rows *sql.Rows) {
+		rowErr := rows.Close()
+		if rowErr != nil {
+			log.Printf("Erreur: %v", rowErr)
+		}
+	}(rows)
+
+	for rows.Next() {
+		var q models.Quota
+		if err := rows.Scan(&q.ID, &q.UserID, &q.Group, &q.MaxHoursPerWeek, &q.MaxConcurrent, &q.MaxPerRoomPerDay); err != nil {
+			return nil, err
+		}
+		quotas = append(quotas, q)
+	}
+	return quotas, rows.Err()
+}
+
+func InsertQuota(db *sql.DB, q models.Quota) (int, error) {
+	if q.MaxHoursPerWeek < 0 || q.MaxConcurrent < 0 || q.MaxPerRoomPerDay < 0 {
+		return 0, models.NewError(models.ErrInvalid, "les limites d'un quota ne peuvent pas être négatives")
+	}
+	var userID sql.NullInt64
+	if q.UserID != 0 {
+		userID = sql.NullInt64{Int64: int64(q.UserID), Valid: true}
+	}
+	var group sql.NullString
+	if q.Group != "" {
+		group = sql.NullString{String: q.Group, Valid: true}
+	}
+	query := `INSERT INTO quotas (user_id, group_name, max_hours_per_week, max_concurrent, max_per_room_per_day)
+              VALUES (?, ?, ?, ?, ?)`
+	result, err := db.Exec(query, userID, group, q.MaxHoursPerWeek, q.MaxConcurrent, q.MaxPerRoomPerDay)
+	if err != nil {
+		return 0, err
+	}
+	id, err := result.LastInsertId()
+	return int(id), err
+}
+
+func DeleteQuota(db *sql.DB, id int) error {
+	_, err := db.Exec("DELETE FROM quotas WHERE id = ?", id)
+	return err
+}
+
+// Semaine ISO (lundi - dimanche) contenant la date
+func weekBounds(day time.Time) (time.Time, time.Time) {
+	monday := day.AddDate(0, 0, 1-utils.IsoWeekday(day))
+	return monday, monday.AddDate(0, 0, 6)
+}
+
+// Calcule la consommation de l'utilisateur pour la semaine et le jour de la date donnée
+func GetUsage(db *sql.DB, userID int, date string) (*Usage, error) {
+	day, err := utils.ParseDate(date)
+	if err != nil {
+		return nil, err
+	}
+	monday, sunday := weekBounds(day)
+	usage := &Usage{
+		WeekStart:     monday.Format(utils.DateLayout),
+		WeekEnd:       sunday.Format(utils.DateLayout),
+		RoomDayCounts: map[int]int{},
+	}
+
+	// Les réservations annulées ou libérées faute de check-in ne comptent pas
+	query := `SELECT COALESCE(SUM(TIME_TO_SEC(TIMEDIFF(end_time, start_time))), 0) DIV 60 FROM reservations
+              WHERE user_id = ? AND date BETWEEN ? AND ? AND status IN ('confirmed', 'checked_in')`
+	if err := db.QueryRow(query, userID, usage.WeekStart, usage.WeekEnd).Scan(&usage.WeekMinutes); err != nil {
+		return nil, err
+	}
+
+	query = `SELECT COUNT(*) FROM reservations WHERE user_id = ? AND TIMESTAMP(date, end_time) > NOW() AND status IN ('confirmed', 'checked_in')`
+	if err := db.QueryRow(query, userID).Scan(&usage.FutureBookings); err != nil {
+		return nil, err
+	}
+
+	rows, err := db.Query("SELECT room_id, COUNT(*) FROM reservations WHERE user_id = ? AND date = ? AND status IN ('confirmed', 'checked_in') GROUP BY room_id", userID, date)
+	if err != nil {
+		return nil, err
+	}
+	defer func(rows *sql.Rows) {
+		rowErr := rows.Close()
+		if rowErr != nil {
+			log.Printf("Erreur: %v", rowErr)
+		}
+	}(rows)
+	for rows.Next() {
+		var roomID, count int
+		if err := rows.Scan(&roomID, &count); err != nil {
+			return nil, err
+		}
+		usage.RoomDayCounts[roomID] = count
+	}
+	return usage, rows.Err()
+}
+
+// Vérifie qu'une nouvelle réservation de la durée donnée reste dans tous les quotas
+func Evaluate(quotas []models.Quota, usage *Usage, roomID, minutes int) []string {
+	var messages []string
+	for _, q := range quotas {
+		if q.MaxHoursPerWeek > 0 && usage.WeekMinutes+minutes > q.MaxHoursPerWeek*60 {
+			messages = append(messages, fmt.Sprintf("%d h maximum par semaine (déjà %s réservées du %s au %s)",
+				q.MaxHoursPerWeek, FormatMinutes(usage.WeekMinutes), usage.WeekStart, usage.WeekEnd))
+		}
+		if q.MaxConcurrent > 0 && usage.FutureBookings+1 > q.MaxConcurrent {
+			messages = append(messages, fmt.Sprintf("%d réservations à venir maximum (déjà %d)",
+				q.MaxConcurrent, usage.FutureBookings))
+		}
+		if q.MaxPerRoomPerDay > 0 && usage.RoomDayCounts[roomID]+1 > q.MaxPerRoomPerDay {
+			messages = append(messages, fmt.Sprintf("%d réservations maximum par salle et par jour (déjà %d dans la salle %d)",
+				q.MaxPerRoomPerDay, usage.RoomDayCounts[roomID], roomID))
+		}
+	}
+	return messages
+}
+
+// Vérifie les quotas de l'utilisateur pour une nouvelle réservation ; renvoie un *QuotaError en cas de dépassement
+func CheckQuota(db *sql.DB, user *models.User, roomID int, date, startTime, endTime string) error {
+	return CheckQuotaForRooms(db, user, []int{roomID}, date, startTime, endTime)
+}
+
+// Vérifie les quotas pour un même créneau réservé dans plusieurs salles, chaque salle comptant comme une réservation
+func CheckQuotaForRooms(db *sql.DB, user *models.User, roomIDs []int, date, startTime, endTime string) error {
+	start, err := utils.ParseClock(startTime)
+	if err != nil {
+		return err
+	}
+	end, err := utils.ParseClock(endTime)
+	if err != nil {
+		return err
+	}
+	quotas, err := GetApplicableQuotas(db, user)
+	if err != nil {
+		return err
+	}
+	if len(quotas) == 0 {
+		return nil
+	}
+	usage, err := GetUsage(db, user.ID, date)
+	if err != nil {
+		return err
+	}
+	for _, roomID := range roomIDs {
+		if messages := Evaluate(quotas, usage, roomID, end-start); len(messages) > 0 {
+			return &QuotaError{Messages: messages}
+		}
+		usage.WeekMinutes += end - start
+		usage.FutureBookings++
+		usage.RoomDayCounts[roomID]++
+	}
+	return nil
+}
+
+// Vérifie les quotas pour les occurrences d'une série dans une même salle, chaque
+// occurrence comptant en plus des précédentes de la série
+func CheckQuotaForSeries(db *sql.DB, user *models.User, roomID int, dates []string, startTime, endTime string) error {
+	start, err := utils.ParseClock(startTime)
+	if err != nil {
+		return err
+	}
+	end, err := utils.ParseClock(endTime)
+	if err != nil {
+		return err
+	}
+	quotas, err := GetApplicableQuotas(db, user)
+	if err != nil {
+		return err
+	}
+	if len(quotas) == 0 {
+		return nil
+	}
+	for i, date := range dates {
+		usage, err := GetUsage(db, user.ID, date)
+		if err != nil {
+			return err
+		}
+		for _, previous := range dates[:i] {
+			if previous >= usage.WeekStart && previous <= usage.WeekEnd {
+				usage.WeekMinutes += end - start
+			}
+			if previous == date {
+				usage.RoomDayCounts[roomID]++
+			}
+		}
+		usage.FutureBookings += i
+		if messages := Evaluate(quotas, usage, roomID, end-start); len(messages) > 0 {
+			return fmt.Errorf("occurrence du %s : %w", date, &QuotaError{Messages: messages})
+		}
+	}
+	return nil
+}
+
+// Vérifie les quotas pour une réservation d'un import : les réservations de l'utilisateur
+// déjà retenues par l'import (pending) comptent en plus de celles de la base
+func CheckQuotaWithPending(db *sql.DB, user *models.User, pending []models.Reservation, roomID int, date, startTime, endTime string) error {
+	start, err := utils.ParseClock(startTime)
+	if err != nil {
+		return err
+	}
+	end, err := utils.ParseClock(endTime)
+	if err != nil {
+		return err
+	}
+	quotas, err := GetApplicableQuotas(db, user)
+	if err != nil {
+		return err
+	}
+	if len(quotas) == 0 {
+		return nil
+	}
+	usage, err := GetUsage(db, user.ID, date)
+	if err != nil {
+		return err
+	}
+	now := time.Now().Format(utils.DateTimeLayout)
+	for _, p := range pending {
+		if p.UserID != user.ID {
+			continue
+		}
+		pStart, err := utils.ParseClock(p.StartTime)
+		if err != nil {
+			return err
+		}
+		pEnd, err := utils.ParseClock(p.EndTime)
+		if err != nil {
+			return err
+		}
+		if p.Date >= usage.WeekStart && p.Date <= usage.WeekEnd {
+			usage.WeekMinutes += pEnd - pStart
+		}
+		if p.Date == date {
+			usage.RoomDayCounts[p.RoomID]++
+		}
+		if p.Date+" "+p.EndTime > now {
+			usage.FutureBookings++
+		}
+	}
+	if messages := Evaluate(quotas, usage, roomID, end-start); len(messages) > 0 {
+		return &QuotaError{Messages: messages}
+	}
+	return nil
+}
+
+// Vérifie les quotas pour le déplacement d'une réservation existante : elle ne
+// compte plus dans la consommation à la place de laquelle le nouveau créneau est évalué
+func CheckQuotaForMove(db *sql.DB, user *models.User, current models.Reservation, roomID int, date, startTime, endTime string) error {
+	start, err := utils.ParseClock(startTime)
+	if err != nil {
+		return err
+	}
+	end, err := utils.ParseClock(endTime)
+	if err != nil {
+		return err
+	}
+	quotas, err := GetApplicableQuotas(db, user)
+	if err != nil {
+		return err
+	}
+	if len(quotas) == 0 {
+		return nil
+	}
+	usage, err := GetUsage(db, user.ID, date)
+	if err != nil {
+		return err
+	}
+	if err := usage.release(current, date); err != nil {
+		return err
+	}
+	if messages := Evaluate(quotas, usage, roomID, end-start); len(messages) > 0 {
+		return &QuotaError{Messages: messages}
+	}
+	return nil
+}
+
+// Retire de la consommation une réservation comptée par GetUsage pour la date donnée
+func (u *Usage) release(r models.Reservation, date string) error {
+	if r.Status != models.StatusConfirmed && r.Status != models.StatusCheckedIn {
+		return nil
+	}
+	start, err := utils.ParseClock(r.StartTime)
+	if err != nil {
+		return err
+	}
+	end, err := utils.ParseClock(r.EndTime)
+	if err != nil {
+		return err
+	}
+	if r.Date >= u.WeekStart && r.Date <= u.WeekEnd {
+		u.WeekMinutes -= end - start
+	}
+	day, err := time.ParseInLocation(utils.DateLayout, r.Date, time.Local)
+	if err != nil {
+		return err
+	}
+	if day.Add(time.Duration(end) * time.Minute).After(time.Now()) {
+		u.FutureBookings--
+	}
+	if r.Date == date {
+		u.RoomDayCounts[r.RoomID]--
+	}
+	return nil
+}
+
+func FormatMinutes(minutes int) string {
+	return fmt.Sprintf("%dh%02d", minutes/60, minutes%60)
+}
+
+func DescribeLimit(value int) string {
+	if value == 0 {
+		return "illimité"
+	}
+	return fmt.Sprint(value)
+}
+
+func DescribeScope(q models.Quota) string {
+	switch {
+	case q.UserID != 0:
+		return fmt.Sprintf("utilisateur %d", q.UserID)
+	case q.Group != "":
+		return "groupe " + q.Group
+	default:
+		return "tout le monde"
+	}
+}
@@ -0,0 +1,21 @@
+package reportlogic
+
+import (
+	"embed"
+	"html/template"
+	"io"
+	"time"
+)
+
+//go:embed templates/schedule.html
+var templateFiles embed.FS
+
+var scheduleTemplate = template.Must(template.ParseFS(templateFiles, "templates/schedule.html"))
+
+// Écrit les affiches en une page HTML autonome (styles intégrés), une salle par page imprimée
+func WriteHTML(w io.Writer, booklet *Booklet, now time.Time) error {
+	return scheduleTemplate.Execute(w, struct {
+		*Booklet
+		Printed string
+	}{booklet, printedLabel(now)})
+}
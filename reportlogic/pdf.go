@@ -0,0 +1,254 @@
+package reportlogic
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Format A4 portrait, en points
+const (
+	pageWidth  = 595
+	pageHeight = 842
+	marginLeft = 50
+	// En dessous de cette hauteur, le contenu passe à la page suivante (le pied de page est au-dessous)
+	marginBottom = 60
+	lineHeight   = 15
+)
+
+// Polices standard du PDF : aucune police à embarquer
+const (
+	fontRegular = "F1"
+	fontBold    = "F2"
+)
+
+// Document en cours de mise en page : un flux de contenu par page
+type pdfDoc struct {
+	pages []*bytes.Buffer
+	page  *bytes.Buffer
+	// Position verticale de la prochaine ligne
+	y float64
+}
+
+func (d *pdfDoc) newPage() {
+	d.page = &bytes.Buffer{}
+	d.pages = append(d.pages, d.page)
+	d.y = pageHeight - 52
+}
+
+func (d *pdfDoc) text(x, y float64, font string, size float64, value string) {
+	fmt.Fprintf(d.page, "BT /%s %g Tf %g %g Td (%s) Tj ET\n", font, size, x, y, pdfString(value))
+}
+
+// Texte grisé, pour les mentions secondaires
+func (d *pdfDoc) grey(x, y float64, font string, size float64, value string) {
+	d.page.WriteString("0.45 g\n")
+	d.text(x, y, font, size, value)
+	d.page.WriteString("0 g\n")
+}
+
+func (d *pdfDoc) rule(y float64) {
+	fmt.Fprintf(d.page, "0.5 w %d %g m %d %g l S\n", marginLeft, y, pageWidth-marginLeft, y)
+}
+
+// Passe à la page suivante s'il reste moins de height points ; renvoie true si la page a changé
+func (d *pdfDoc) ensure(height float64, continued func()) bool {
+	if d.y-height >= marginBottom {
+		return false
+	}
+	d.newPage()
+	continued()
+	return true
+}
+
+// Écrit les affiches en PDF : une salle par page (ou plus si la semaine est chargée),
+// précédées d'une page de garde pour le livret d'un bâtiment
+func WritePDF(w io.Writer, booklet *Booklet, now time.Time) error {
+	d := &pdfDoc{}
+	if booklet.Building != "" {
+		d.cover(booklet)
+	}
+	for _, schedule := range booklet.Schedules {
+		d.schedule(booklet.Week, schedule)
+	}
+
+	footer := printedLabel(now)
+	for i, page := range d.pages {
+		d.page = page
+		d.grey(marginLeft, 30, fontRegular, 8, fmt.Sprintf("%s — Page %d/%d", footer, i+1, len(d.pages)))
+	}
+	return d.write(w)
+}
+
+func (d *pdfDoc) cover(booklet *Booklet) {
+	d.newPage()
+	d.text(marginLeft, d.y, fontBold, 22, truncate(booklet.Building, 40))
+	d.y -= 22
+	d.text(marginLeft, d.y, fontBold, 11, booklet.Week)
+	d.y -= 10
+	d.rule(d.y)
+	d.y -= 25
+
+	header := func() {
+		d.text(marginLeft, d.y, fontBold, 11, "Salle")
+		d.text(350, d.y, fontBold, 11, "Capacité")
+		d.text(450, d.y, fontBold, 11, "Réservations")
+		d.y -= lineHeight + 3
+	}
+	header()
+	for _, schedule := range booklet.Schedules {
+		d.ensure(lineHeight, header)
+		d.text(marginLeft, d.y, fontRegular, 11, truncate(schedule.Room.Name, 50))
+		d.text(350, d.y, fontRegular, 11, strconv.Itoa(schedule.Room.Capacity))
+		d.text(450, d.y, fontRegular, 11, strconv.Itoa(schedule.Count()))
+		d.y -= lineHeight
+	}
+}
+
+func (d *pdfDoc) schedule(week string, schedule Schedule) {
+	room := schedule.Room
+	d.newPage()
+	d.text(marginLeft, d.y, fontBold, 20, truncate(room.Name, 45))
+	d.y -= 20
+	subtitle := strconv.Itoa(room.Capacity) + " places"
+	if room.Building != "" {
+		subtitle = room.Building + " · " + subtitle
+	}
+	d.grey(marginLeft, d.y, fontRegular, 11, subtitle)
+	d.y -= 17
+	d.text(marginLeft, d.y, fontBold, 11, week)
+	d.y -= 10
+	d.rule(d.y)
+	d.y -= 25
+
+	// En-tête réduit des pages de suite
+	continued := func() {
+		d.text(marginLeft, d.y, fontBold, 14, truncate(room.Name, 45)+" (suite)")
+		d.y -= 15
+		d.grey(marginLeft, d.y, fontRegular, 10, week)
+		d.y -= 8
+		d.rule(d.y)
+		d.y -= 22
+	}
+
+	for _, day := range schedule.Days {
+		// Le libellé du jour reste avec au moins sa première ligne
+		d.ensure(18+lineHeight, continued)
+		d.dayTitle(day, "")
+		if len(day.Bookings) == 0 {
+			d.grey(70, d.y, fontRegular, 10, "Aucune réservation")
+			d.y -= lineHeight
+		}
+		for _, booking := range day.Bookings {
+			if d.ensure(lineHeight, continued) {
+				d.dayTitle(day, " (suite)")
+			}
+			d.text(70, d.y, fontRegular, 11, booking.StartTime+" - "+booking.EndTime)
+			d.text(160, d.y, fontRegular, 11, truncate(booking.Organiser, 32))
+			d.grey(360, d.y, fontRegular, 11, truncate(booking.Group, 30))
+			d.y -= lineHeight
+		}
+		d.y -= 8
+	}
+}
+
+func (d *pdfDoc) dayTitle(day Day, suffix string) {
+	d.text(marginLeft, d.y, fontBold, 13, day.Label+suffix)
+	switch {
+	case day.Closure != "":
+		d.grey(250, d.y, fontRegular, 10, truncate("fermé : "+day.Closure, 55))
+	case day.Closed:
+		d.grey(250, d.y, fontRegular, 10, "salle fermée")
+	case day.Opening != "":
+		d.grey(250, d.y, fontRegular, 10, truncate(day.Opening, 55))
+	}
+	d.y -= 18
+}
+
+// Assemble le fichier : catalogue, arbre des pages, polices, puis chaque page et son contenu
+func (d *pdfDoc) write(w io.Writer) error {
+	var out bytes.Buffer
+	var offsets []int
+	object := func(body string) {
+		offsets = append(offsets, out.Len())
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	out.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	// Les objets de la page n sont 5+2n (page) et 6+2n (contenu)
+	kids := make([]string, len(d.pages))
+	for i := range d.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 5+2*i)
+	}
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages)))
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+	for i, page := range d.pages {
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] "+
+			"/Resources << /Font << /%s 3 0 R /%s 4 0 R >> >> /Contents %d 0 R >>",
+			pageWidth, pageHeight, fontRegular, fontBold, 6+2*i))
+
+		var compressed bytes.Buffer
+		zw := zlib.NewWriter(&compressed)
+		if _, err := zw.Write(page.Bytes()); err != nil {
+			return err
+		}
+		if err := zw.Close(); err != nil {
+			return err
+		}
+		object(fmt.Sprintf("<< /Length %d /Filter /FlateDecode >>\nstream\n%s\nendstream", compressed.Len(), compressed.Bytes()))
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+	_, err := w.Write(out.Bytes())
+	return err
+}
+
+// Caractères de Windows-1252 hors Latin-1, pour les textes saisis en français
+var winAnsiSpecials = map[rune]byte{
+	'€': 0x80, '‚': 0x82, '„': 0x84, '…': 0x85, '‹': 0x8B, 'Œ': 0x8C, '‘': 0x91, '’': 0x92,
+	'“': 0x93, '”': 0x94, '•': 0x95, '–': 0x96, '—': 0x97, '›': 0x9B, 'œ': 0x9C, 'Ÿ': 0x9F,
+}
+
+// Chaîne PDF littérale en WinAnsiEncoding ; les caractères sans équivalent deviennent « ? »
+func pdfString(value string) string {
+	var b bytes.Buffer
+	for _, r := range value {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r == '\u202f':
+			// Espace fine insécable, absente de WinAnsiEncoding
+			b.WriteByte(' ')
+		case r >= 0x20 && r < 0x7f:
+			b.WriteRune(r)
+		case r >= 0xa0 && r <= 0xff:
+			b.WriteByte(byte(r))
+		case winAnsiSpecials[r] != 0:
+			b.WriteByte(winAnsiSpecials[r])
+		default:
+			b.WriteByte('?')
+		}
+	}
+	return b.String()
+}
+
+// Coupe les textes trop longs pour leur colonne
+func truncate(value string, max int) string {
+	runes := []rune(value)
+	if len(runes) <= max {
+		return value
+	}
+	return string(runes[:max-1]) + "…"
+}
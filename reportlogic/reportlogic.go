@@ -0,0 +1,236 @@
+package reportlogic
+
+import (
+	"Reserve-Go/calendarlogic"
+	"Reserve-Go/models"
+	"Reserve-Go/planninglogic"
+	"Reserve-Go/reservationlogic"
+	"Reserve-Go/roomlogic"
+	"Reserve-Go/slotlogic"
+	"Reserve-Go/userlogic"
+	"Reserve-Go/utils"
+	"database/sql"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Réservation telle qu'affichée sur la porte de la salle
+type Booking struct {
+	ReservationID int
+	// Heures au format HH:MM
+	StartTime string
+	EndTime   string
+	Organiser string
+	Group     string
+}
+
+type Day struct {
+	Date  string
+	Label string
+	// Libellé du jour de fermeture du site (vide s'il est ouvert)
+	Closure string
+	// Plages d'ouverture de la salle ce jour-là ; vide si la salle n'a pas d'horaires
+	Opening string
+	// La salle a des horaires mais n'ouvre pas ce jour-là
+	Closed   bool
+	Bookings []Booking
+}
+
+// Semaine d'une salle, du lundi au dimanche
+type Schedule struct {
+	Room models.Room
+	Days []Day
+}
+
+// Une ou plusieurs affiches de la même semaine ; Building est renseigné pour le livret d'un bâtiment
+type Booklet struct {
+	Building string
+	From     string
+	To       string
+	// "Semaine du lundi 13/05/2024 au dimanche 19/05/2024"
+	Week      string
+	Schedules []Schedule
+}
+
+// Formats d'impression proposés
+var Formats = []string{"html", "pdf"}
+
+// Affiches d'une salle (roomID) ou livret d'un bâtiment, l'un ou l'autre
+func Build(db *sql.DB, roomID int, building, date string) (*Booklet, error) {
+	if (roomID == 0) == (strings.TrimSpace(building) == "") {
+		return nil, models.NewError(models.ErrInvalid, "indiquer une salle ou un bâtiment (un seul des deux)")
+	}
+	if roomID != 0 {
+		return RoomSchedule(db, roomID, date)
+	}
+	return BuildingSchedule(db, building, date)
+}
+
+// Écrit les affiches au format demandé (html ou pdf)
+func Write(w io.Writer, booklet *Booklet, format string, now time.Time) error {
+	switch format {
+	case "html":
+		return WriteHTML(w, booklet, now)
+	case "pdf":
+		return WritePDF(w, booklet, now)
+	}
+	return models.Errorf(models.ErrInvalid, "format d'impression inconnu : %q (html ou pdf)", format)
+}
+
+// Affiche de la semaine d'une salle ; la semaine commence au lundi de la semaine de date
+func RoomSchedule(db *sql.DB, roomID int, date string) (*Booklet, error) {
+	room, err := roomlogic.GetRoom(db, roomID)
+	if err != nil {
+		return nil, err
+	}
+	return build(db, []models.Room{*room}, "", date)
+}
+
+// Livret d'un bâtiment : l'affiche de la semaine de chacune de ses salles
+func BuildingSchedule(db *sql.DB, building, date string) (*Booklet, error) {
+	if strings.TrimSpace(building) == "" {
+		return nil, models.NewError(models.ErrInvalid, "le bâtiment est obligatoire")
+	}
+	rooms, err := roomlogic.GetRooms(db)
+	if err != nil {
+		return nil, err
+	}
+	var selected []models.Room
+	for _, room := range rooms {
+		if buildingKey(room.Building) == buildingKey(building) {
+			selected = append(selected, room)
+		}
+	}
+	if len(selected) == 0 {
+		return nil, models.Errorf(models.ErrNotFound, "aucune salle dans le bâtiment « %s »", building)
+	}
+	return build(db, selected, selected[0].Building, date)
+}
+
+// Les noms de bâtiment sont comparés sans tenir compte de la casse ni des espaces superflus
+func buildingKey(name string) string {
+	return strings.ToLower(strings.Join(strings.Fields(name), " "))
+}
+
+func build(db *sql.DB, rooms []models.Room, building, date string) (*Booklet, error) {
+	day, err := utils.ParseDate(date)
+	if err != nil {
+		return nil, err
+	}
+	from := planninglogic.WeekStart(day)
+	to := from.AddDate(0, 0, 6)
+	booklet := &Booklet{
+		Building: building,
+		From:     from.Format(utils.DateLayout),
+		To:       to.Format(utils.DateLayout),
+		Week:     "Semaine du " + planninglogic.DayLabel(from) + " au " + planninglogic.DayLabel(to),
+	}
+
+	roomIDs := make([]int, len(rooms))
+	for i, room := range rooms {
+		roomIDs[i] = room.ID
+	}
+	// Seules les réservations qui occupent la salle sont affichées
+	reservations, err := reservationlogic.FindReservations(db, reservationlogic.Filter{
+		From:     booklet.From,
+		To:       booklet.To,
+		RoomIDs:  roomIDs,
+		Statuses: []string{models.StatusConfirmed, models.StatusCheckedIn},
+	})
+	if err != nil {
+		return nil, err
+	}
+	users, err := userlogic.GetUsers(db)
+	if err != nil {
+		return nil, err
+	}
+	closures, err := calendarlogic.GetClosureDays(db)
+	if err != nil {
+		return nil, err
+	}
+
+	userNames := make(map[int]string, len(users))
+	userGroups := make(map[int]string, len(users))
+	for _, u := range users {
+		userNames[u.ID] = u.Name
+		userGroups[u.ID] = u.Group
+	}
+	closureLabels := make(map[string]string)
+	for _, c := range closures {
+		closureLabels[c.Date] = c.Label
+	}
+	// Réservations par salle et par date, déjà triées par heure de début
+	byDay := make(map[string][]Booking)
+	for _, r := range reservations {
+		key := dayKey(r.RoomID, r.Date)
+		byDay[key] = append(byDay[key], Booking{
+			ReservationID: r.ID,
+			StartTime:     clock(r.StartTime),
+			EndTime:       clock(r.EndTime),
+			Organiser:     planninglogic.UserLabel(r.UserID, userNames),
+			Group:         userGroups[r.UserID],
+		})
+	}
+
+	for _, room := range rooms {
+		hours, err := calendarlogic.GetOpeningHours(db, room.ID)
+		if err != nil {
+			return nil, err
+		}
+		schedule := Schedule{Room: room}
+		for d := from; !d.After(to); d = d.AddDate(0, 0, 1) {
+			date := d.Format(utils.DateLayout)
+			entry := Day{
+				Date:     date,
+				Label:    planninglogic.DayLabel(d),
+				Closure:  closureLabels[date],
+				Bookings: byDay[dayKey(room.ID, date)],
+			}
+			if len(hours) > 0 {
+				windows := slotlogic.OpenWindows(hours, utils.IsoWeekday(d))
+				entry.Closed = len(windows) == 0
+				entry.Opening = formatWindows(windows)
+			}
+			schedule.Days = append(schedule.Days, entry)
+		}
+		booklet.Schedules = append(booklet.Schedules, schedule)
+	}
+	return booklet, nil
+}
+
+func dayKey(roomID int, date string) string {
+	return fmt.Sprintf("%d/%s", roomID, date)
+}
+
+// "08:00 - 12:00, 14:00 - 18:00"
+func formatWindows(windows []roomlogic.Interval) string {
+	parts := make([]string, len(windows))
+	for i, w := range windows {
+		parts[i] = clock(utils.FormatClock(w.Start)) + " - " + clock(utils.FormatClock(w.End))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// "09:30:00" -> "09:30"
+func clock(value string) string {
+	if len(value) == len("15:04:05") {
+		return value[:len("15:04")]
+	}
+	return value
+}
+
+// Nombre de réservations de la semaine, pour la page de garde du livret
+func (s Schedule) Count() int {
+	n := 0
+	for _, day := range s.Days {
+		n += len(day.Bookings)
+	}
+	return n
+}
+
+// "Imprimé le 13/05/2024 à 09:30"
+func printedLabel(now time.Time) string {
+	return "Imprimé le " + now.Format("02/01/2006") + " à " + now.Format("15:04")
+}
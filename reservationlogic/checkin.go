@@ -0,0 +1,155 @@
+package reservationlogic
+
+import (
+	"Reserve-Go/models"
+	"Reserve-Go/utils"
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+)
+
+const (
+	// Le check-in est possible à partir de CheckInOpensBefore avant le début...
+	CheckInOpensBefore = 15 * time.Minute
+	// ...et jusqu'à NoShowGracePeriod après ; passé ce délai la réservation est libérée
+	NoShowGracePeriod = 15 * time.Minute
+	// Fréquence de passage de la tâche de libération des réservations
+	NoShowCheckInterval = time.Minute
+	// Seules les réservations commencées depuis moins de NoShowWindow (délai de grâce
+	// compris) sont libérées : l'historique antérieur au check-in n'est pas touché
+	NoShowWindow = NoShowGracePeriod + 24*time.Hour
+)
+
+var (
+	ErrReservationNotFound = models.NewError(models.ErrNotFound, "aucune réservation trouvée avec cet identifiant")
+	ErrCheckInClosed       = models.NewError(models.ErrConflict, "le check-in n'est pas ouvert pour cette réservation")
+)
+
+func GetReservation(db *sql.DB, reservationID int) (*models.Reservation, error) {
+	rows, err := db.Query("SELECT "+reservationColumns+" FROM reservations WHERE id = ?", reservationID)
+	if err != nil {
+		return nil, err
+	}
+	reservations, err := scanReservations(rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(reservations) == 0 {
+		return nil, ErrReservationNotFound
+	}
+	return &reservations[0], nil
+}
+
+// Début de la réservation dans le fuseau de l'application
+func startsAt(r *models.Reservation, loc *time.Location) (time.Time, error) {
+	day, err := time.ParseInLocation(utils.DateLayout, r.Date, loc)
+	if err != nil {
+		return time.Time{}, err
+	}
+	start, err := utils.ParseClock(r.StartTime)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return day.Add(time.Duration(start) * time.Minute), nil
+}
+
+// Enregistre l'arrivée dans la salle, possible autour de l'heure de début
+func CheckIn(db *sql.DB, reservationID int, now time.Time) error {
+	reservation, err := GetReservation(db, reservationID)
+	if err != nil {
+		return err
+	}
+	if reservation.Status != models.StatusConfirmed {
+		return fmt.Errorf("%w (statut : %s)", ErrCheckInClosed, reservation.Status)
+	}
+	start, err := startsAt(reservation, now.Location())
+	if err != nil {
+		return err
+	}
+	opens, closes := start.Add(-CheckInOpensBefore), start.Add(NoShowGracePeriod)
+	if now.Before(opens) || now.After(closes) {
+		return fmt.Errorf("%w (possible de %s à %s)", ErrCheckInClosed, opens.Format("15:04"), closes.Format("15:04"))
+	}
+	query := "UPDATE reservations SET status = ?, checked_in_at = ? WHERE id = ? AND status = ?"
+	_, err = db.Exec(query, models.StatusCheckedIn, now.Format(utils.DateTimeLayout), reservationID, models.StatusConfirmed)
+	return err
+}
+
+// Marque comme « no_show » les réservations sans check-in dont le délai de grâce est
+// écoulé ; le reste de leur créneau redevient réservable. Seules les réservations
+// commencées dans la fenêtre NoShowWindow sont concernées.
+func ReleaseNoShows(db *sql.DB, now time.Time) (int, error) {
+	from := now.Add(-NoShowWindow)
+	cutoff := now.Add(-NoShowGracePeriod).Format(utils.DateTimeLayout)
+	query := `UPDATE reservations SET status = ?
+              WHERE status = ? AND date >= ?
+              AND TIMESTAMP(date, start_time) >= ? AND TIMESTAMP(date, start_time) < ?`
+	result, err := db.Exec(query, models.StatusNoShow, models.StatusConfirmed,
+		from.Format(utils.DateLayout), from.Format(utils.DateTimeLayout), cutoff)
+	if err != nil {
+		return 0, err
+	}
+	count, err := result.RowsAffected()
+	return int(count), err
+}
+
+// Lance la libération périodique des réservations non honorées ; la fonction
+// renvoyée arrête la tâche.
+func StartNoShowJob(db *sql.DB, interval time.Duration) func() {
+	ticker := time.NewTicker(interval)
+	done := make(chan struct{})
+	go func() {
+		for {
+			if count, err := ReleaseNoShows(db, time.Now()); err != nil {
+				log.Printf("Erreur lors de la libération des réservations non honorées : %v", err)
+			} else if count > 0 {
+				log.Printf("%d réservation(s) sans check-in libérée(s)", count)
+			}
+			select {
+			case <-ticker.C:
+			case <-done:
+				return
+			}
+		}
+	}()
+	return func() {
+		ticker.Stop()
+		close(done)
+	}
+}
+
+type NoShowCount struct {
+	UserID int
+	Name   string
+	Count  int
+}
+
+// Nombre de réservations non honorées par utilisateur, du plus grand au plus petit
+func GetNoShowCounts(db *sql.DB) ([]NoShowCount, error) {
+	query := `SELECT u.id, u.name, COUNT(*) FROM reservations r
+              JOIN users u ON u.id = r.user_id
+              WHERE r.status = ?
+              GROUP BY u.id, u.name
+              ORDER BY COUNT(*) DESC, u.id`
+	rows, err := db.Query(query, models.StatusNoShow)
+	if err != nil {
+		return nil, err
+	}
+	defer func(rows *sql.Rows) {
+		rowErr := rows.Close()
+		if rowErr != nil {
+			log.Printf("Erreur: %v", rowErr)
+		}
+	}(rows)
+
+	var counts []NoShowCount
+	for rows.Next() {
+		var c NoShowCount
+		if err := rows.Scan(&c.UserID, &c.Name, &c.Count); err != nil {
+			return nil, err
+		}
+		counts = append(counts, c)
+	}
+	return counts, rows.Err()
+}
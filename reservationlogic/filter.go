@@ -0,0 +1,186 @@
+package reservationlogic
+
+import (
+	"Reserve-Go/models"
+	"Reserve-Go/utils"
+	"database/sql"
+	"log"
+	"strings"
+)
+
+// Critères de sélection des réservations ; un champ vide ne filtre pas
+type Filter struct {
+	// Période, bornes incluses (AAAA-MM-JJ)
+	From string
+	To   string
+	// Salles retenues (toutes si vide)
+	RoomIDs []int
+	// Propriétaire des réservations
+	UserID   int
+	Statuses []string
+	// Clé de tri (voir SortKeys), précédée de « - » pour l'ordre décroissant ;
+	// par date et heure de début par défaut
+	Sort string
+}
+
+// Tris proposés, chacun complété par la date, l'heure et l'identifiant
+var sortColumns = map[string][]string{
+	"date": {"r.date", "r.start_time", "r.id"},
+	"room": {"r.room_id", "r.date", "r.start_time", "r.id"},
+	"user": {"r.user_id", "r.date", "r.start_time", "r.id"},
+	"id":   {"r.id"},
+}
+
+// Clés de tri acceptées par Filter.Sort
+var SortKeys = []string{"date", "room", "user", "id"}
+
+// Vérifie les critères : dates valides et dans l'ordre, statuts et tri connus
+func (f Filter) Validate() error {
+	for _, date := range []string{f.From, f.To} {
+		if date == "" {
+			continue
+		}
+		if _, err := utils.ParseDate(date); err != nil {
+			return err
+		}
+	}
+	if f.From != "" && f.To != "" && f.To < f.From {
+		return models.NewError(models.ErrInvalid, "la date de fin doit être après la date de début")
+	}
+	for _, status := range f.Statuses {
+		switch status {
+		case models.StatusConfirmed, models.StatusCheckedIn, models.StatusNoShow, models.StatusCancelled:
+		default:
+			return models.Errorf(models.ErrInvalid, "statut inconnu : %q (confirmed, checked_in, no_show ou cancelled)", status)
+		}
+	}
+	if _, ok := sortColumns[strings.TrimPrefix(f.Sort, "-")]; f.Sort != "" && !ok {
+		return models.Errorf(models.ErrInvalid, "tri inconnu : %q (%s, éventuellement précédé de -)", f.Sort, strings.Join(SortKeys, ", "))
+	}
+	return nil
+}
+
+// Requête SQL correspondant aux critères, avec ses paramètres ; selectFrom lit la table
+// reservations sous l'alias r
+func (f Filter) query(selectFrom string) (string, []interface{}) {
+	var conditions []string
+	var args []interface{}
+	if f.From != "" {
+		conditions = append(conditions, "r.date >= ?")
+		args = append(args, f.From)
+	}
+	if f.To != "" {
+		conditions = append(conditions, "r.date <= ?")
+		args = append(args, f.To)
+	}
+	if len(f.RoomIDs) > 0 {
+		conditions = append(conditions, "r.room_id IN ("+placeholders(len(f.RoomIDs))+")")
+		for _, id := range f.RoomIDs {
+			args = append(args, id)
+		}
+	}
+	if f.UserID != 0 {
+		conditions = append(conditions, "r.user_id = ?")
+		args = append(args, f.UserID)
+	}
+	if len(f.Statuses) > 0 {
+		conditions = append(conditions, "r.status IN ("+placeholders(len(f.Statuses))+")")
+		for _, status := range f.Statuses {
+			args = append(args, status)
+		}
+	}
+
+	query := selectFrom
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	key, desc := strings.TrimPrefix(f.Sort, "-"), strings.HasPrefix(f.Sort, "-")
+	columns, ok := sortColumns[key]
+	if !ok {
+		columns = sortColumns["date"]
+	}
+	order := make([]string, len(columns))
+	for i, column := range columns {
+		order[i] = column
+		if desc {
+			order[i] += " DESC"
+		}
+	}
+	return query + " ORDER BY " + strings.Join(order, ", "), args
+}
+
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", n), ", ")
+}
+
+// Réservations qui vérifient les critères, dans l'ordre demandé
+func FindReservations(db *sql.DB, filter Filter) ([]models.Reservation, error) {
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+	query, args := filter.query("SELECT " + reservationColumns + " FROM reservations r")
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	return scanReservations(rows)
+}
+
+// Découpe une liste de statuts séparés par des virgules
+func ParseStatuses(value string) []string {
+	var statuses []string
+	for _, part := range strings.Split(value, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			statuses = append(statuses, strings.ToLower(part))
+		}
+	}
+	return statuses
+}
+
+// Réservation accompagnée de sa salle et de son propriétaire, pour les exports
+type ReservationDetail struct {
+	models.Reservation
+	RoomName     string
+	RoomCapacity int
+	Building     string
+	UserName     string
+	UserGroup    string
+}
+
+// Colonnes lues par EachReservationDetail ; salle ou utilisateur supprimés donnent des valeurs vides
+const detailColumns = "r.id, r.room_id, COALESCE(r.user_id, 0), r.date, r.start_time, r.end_time, " +
+	"COALESCE(r.group_id, ''), COALESCE(r.series_id, ''), r.status, " +
+	"COALESCE(rm.name, ''), COALESCE(rm.capacity, 0), COALESCE(rm.building, ''), COALESCE(u.name, ''), COALESCE(u.group_name, '')"
+
+// Appelle fn pour chaque réservation qui vérifie les critères, avec sa salle et son
+// propriétaire, au fil de la lecture du curseur : la mémoire utilisée ne dépend pas du
+// nombre de réservations. Une erreur renvoyée par fn arrête la lecture.
+func EachReservationDetail(db *sql.DB, filter Filter, fn func(ReservationDetail) error) error {
+	if err := filter.Validate(); err != nil {
+		return err
+	}
+	query, args := filter.query("SELECT " + detailColumns + " FROM reservations r" +
+		" LEFT JOIN rooms rm ON rm.id = r.room_id LEFT JOIN users u ON u.id = r.user_id")
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return err
+	}
+	defer func(rows *sql.Rows) {
+		rowErr := rows.Close()
+		if rowErr != nil {
+			log.Printf("Erreur: %v", rowErr)
+		}
+	}(rows)
+
+	var d ReservationDetail
+	for rows.Next() {
+		if err := rows.Scan(&d.ID, &d.RoomID, &d.UserID, &d.Date, &d.StartTime, &d.EndTime, &d.GroupID, &d.SeriesID, &d.Status,
+			&d.RoomName, &d.RoomCapacity, &d.Building, &d.UserName, &d.UserGroup); err != nil {
+			return err
+		}
+		if err := fn(d); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
@@ -0,0 +1,97 @@
+package reservationlogic
+
+import (
+	"Reserve-Go/models"
+	"Reserve-Go/quotalogic"
+	"Reserve-Go/roomlogic"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var ErrRoomUnavailable = models.NewError(models.ErrConflict, "la salle n'est pas disponible pour le créneau demandé")
+
+// Réserve le même créneau dans plusieurs salles : soit toutes les réservations
+// sont créées avec un identifiant de groupe commun, soit aucune. La disponibilité
+// des salles est vérifiée dans la transaction d'insertion.
+func BookGroup(db *sql.DB, user *models.User, roomIDs []int, date, startTime, endTime string) (string, error) {
+	if len(roomIDs) == 0 {
+		return "", models.NewError(models.ErrInvalid, "aucune salle demandée")
+	}
+	components, err := roomlogic.GetComponents(db)
+	if err != nil {
+		return "", err
+	}
+	seen := map[int]bool{}
+	for _, roomID := range roomIDs {
+		if seen[roomID] {
+			return "", models.Errorf(models.ErrInvalid, "la salle %d est demandée plusieurs fois", roomID)
+		}
+		// Une salle composée et l'une de ses sous-salles se bloquent mutuellement
+		for _, related := range roomlogic.RelatedRoomIDs(components, roomID) {
+			if related != roomID && seen[related] {
+				return "", models.Errorf(models.ErrConflict, "les salles %d et %d ne peuvent pas être réservées ensemble (salle composée)", related, roomID)
+			}
+		}
+		seen[roomID] = true
+
+		if err := checkAllowed(db, user, roomID, date, startTime, endTime); err != nil {
+			return "", fmt.Errorf("salle %d : %w", roomID, err)
+		}
+	}
+	if err := quotalogic.CheckQuotaForRooms(db, user, roomIDs, date, startTime, endTime); err != nil {
+		return "", err
+	}
+
+	groupID, err := newGroupID()
+	if err != nil {
+		return "", err
+	}
+	tx, err := db.Begin()
+	if err != nil {
+		return "", err
+	}
+	query := `INSERT INTO reservations (room_id, user_id, date, start_time, end_time, group_id) VALUES (?, ?, ?, ?, ?, ?)`
+	for _, roomID := range roomIDs {
+		if err := checkFreeInTx(tx, roomID, date, startTime, endTime, 0); err != nil {
+			_ = tx.Rollback()
+			return "", fmt.Errorf("salle %d : %w", roomID, err)
+		}
+		if _, err := tx.Exec(query, roomID, user.ID, date, startTime, endTime, groupID); err != nil {
+			_ = tx.Rollback()
+			return "", fmt.Errorf("salle %d : %w", roomID, err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return "", err
+	}
+	return groupID, nil
+}
+
+func newGroupID() (string, error) {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// Découpe une liste d'identifiants de salles séparés par des virgules
+func ParseRoomIDs(value string) ([]int, error) {
+	var roomIDs []int
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		id, err := strconv.Atoi(part)
+		if err != nil {
+			return nil, models.Errorf(models.ErrInvalid, "ID de salle invalide : %q", part)
+		}
+		roomIDs = append(roomIDs, id)
+	}
+	return roomIDs, nil
+}
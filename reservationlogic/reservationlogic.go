@@ -1,236 +1,389 @@
 package reservationlogic
 
 import (
-	"Reserve-Go/menulogic"
+	"Reserve-Go/blackoutlogic"
+	"Reserve-Go/calendarlogic"
 	"Reserve-Go/models"
+	"Reserve-Go/quotalogic"
 	"Reserve-Go/roomlogic"
+	"Reserve-Go/rulelogic"
+	"Reserve-Go/slotlogic"
+	"Reserve-Go/userlogic"
 	"Reserve-Go/utils"
-	"bufio"
 	"database/sql"
-	"fmt"
 	"log"
+	"sort"
 	"strconv"
-	"strings"
 	"time"
 )
 
-func CreateReservation(db *sql.DB, scanner *bufio.Scanner) {
-	fmt.Println(utils.ColorString(utils.ColorBlue, strings.Repeat("-", 35)))
-	fmt.Println("Création d'une réservation...")
-	fmt.Println(utils.ColorString(utils.ColorBlue, strings.Repeat("-", 35)))
-
-	fmt.Println("Entrez l'ID de la salle :")
-	scanner.Scan()
-	roomID := scanner.Text()
-
-	fmt.Println("Entrez la date de réservation (YYYY-MM-DD) :")
-	scanner.Scan()
-	date := scanner.Text()
-
-	fmt.Println("Entrez l'heure de début (HH:MM:SS) :")
-	scanner.Scan()
-	startTime := scanner.Text()
-
-	fmt.Println("Entrez l'heure de fin (HH:MM:SS) :")
-	scanner.Scan()
-	endTime := scanner.Text()
-
-	if roomlogic.IsRoomAvailable(db, roomID, date, startTime, endTime) {
-		InsertReservation(db, roomID, date, startTime, endTime)
-		fmt.Println("Réservation créée avec succès.")
-	} else {
-		fmt.Println("La salle n'est pas disponible pour le créneau demandé.")
+// Vérifie toutes les conditions d'une réservation dans une salle, hors quotas ;
+// exceptID désigne une réservation existante à ignorer (0 pour aucune) lorsqu'elle est déplacée
+func checkBooking(db *sql.DB, user *models.User, roomID int, date, startTime, endTime string, exceptID int) error {
+	if err := checkAllowed(db, user, roomID, date, startTime, endTime); err != nil {
+		return err
+	}
+	if exceptID == 0 {
+		if !roomlogic.IsRoomAvailable(db, strconv.Itoa(roomID), date, startTime, endTime) {
+			return ErrRoomUnavailable
+		}
+		return nil
+	}
+	conflicts, err := roomlogic.GetConflictingReservations(db, roomID, date, startTime, endTime)
+	if err != nil {
+		return err
+	}
+	for _, r := range conflicts {
+		if r.ID != exceptID {
+			return ErrRoomUnavailable
+		}
 	}
-	menulogic.NavigationOptions(db, scanner)
+	return nil
 }
 
-func ViewReservationsByRoom(db *sql.DB, scanner *bufio.Scanner) {
-	fmt.Print("Entrez l'ID de la salle (nombre entier) : ")
-	scanner.Scan()
-	roomIDStr := scanner.Text()
-
-	// Conversion de l'ID de la salle en int
-	roomID, err := strconv.Atoi(roomIDStr)
+// Revérifie la disponibilité dans la transaction d'écriture : une réservation validée par
+// une requête concurrente depuis checkBooking ne peut pas être chevauchée
+func checkFreeInTx(tx *sql.Tx, roomID int, date, startTime, endTime string, exceptID int) error {
+	free, err := roomlogic.IsRoomFreeInTx(tx, roomID, date, startTime, endTime, exceptID)
 	if err != nil {
-		fmt.Println("Erreur : ID de salle invalide. Veuillez entrer un nombre.")
-		return
+		return err
+	}
+	if !free {
+		return ErrRoomUnavailable
 	}
+	return nil
+}
 
-	// Vérifier si la salle existe
+// Conditions d'une réservation qui ne dépendent pas des autres réservations : salle,
+// horaires d'ouverture, règles et périodes de gel
+func checkAllowed(db *sql.DB, user *models.User, roomID int, date, startTime, endTime string) error {
 	if !roomlogic.IsRoomExists(db, roomID) {
-		fmt.Println("La salle avec l'ID", roomID, "n'existe pas.")
-		return
+		return models.Errorf(models.ErrNotFound, "la salle avec l'ID %d n'existe pas", roomID)
+	}
+	// Horaires d'ouverture et jours de fermeture
+	if err := calendarlogic.CheckBookable(db, roomID, date, startTime, endTime); err != nil {
+		return err
 	}
+	// Règles de réservation (durée, alignement, délai, horizon)
+	req := rulelogic.Request{RoomID: roomID, Role: user.Role, Date: date, StartTime: startTime, EndTime: endTime}
+	if err := rulelogic.CheckRules(db, req); err != nil {
+		return err
+	}
+	// Périodes de gel réservées aux administrateurs
+	return blackoutlogic.CheckBlackout(db, user, roomID, date, startTime, endTime)
+}
 
-	// Appel à getReservationsByRoom avec l'ID de la salle
-	reservations, err := GetReservationsByRoom(db, roomID)
-	if err != nil {
-		fmt.Println("Erreur lors de la récupération des réservations :", err)
-		return
+// Vérifie qu'une nouvelle réservation serait acceptée (horaires, règles, périodes de gel
+// et disponibilité), sans la créer ni tenir compte des quotas
+func CheckBooking(db *sql.DB, user *models.User, roomID int, date, startTime, endTime string) error {
+	return checkBooking(db, user, roomID, date, startTime, endTime, 0)
+}
+
+// Crée une réservation après avoir vérifié horaires, règles, périodes de gel, quotas
+// et disponibilité (revérifiée dans la transaction d'insertion) ; renvoie l'identifiant
+// de la réservation créée
+func BookReservation(db *sql.DB, user *models.User, roomID int, date, startTime, endTime string) (int, error) {
+	if err := checkBooking(db, user, roomID, date, startTime, endTime, 0); err != nil {
+		return 0, err
+	}
+	// Quotas de l'utilisateur (heures par semaine, réservations à venir, par salle et par jour)
+	if err := quotalogic.CheckQuota(db, user, roomID, date, startTime, endTime); err != nil {
+		return 0, err
 	}
 
-	// Affichage des réservations
-	if len(reservations) == 0 {
-		fmt.Println("Aucune réservation trouvée pour la salle", roomID)
-		return
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	if err := checkFreeInTx(tx, roomID, date, startTime, endTime, 0); err != nil {
+		_ = tx.Rollback()
+		return 0, err
+	}
+	query := `INSERT INTO reservations (room_id, user_id, date, start_time, end_time) VALUES (?, ?, ?, ?, ?)`
+	result, err := tx.Exec(query, roomID, user.ID, date, startTime, endTime)
+	if err != nil {
+		_ = tx.Rollback()
+		log.Printf("Erreur lors de la création de la réservation : %v", err)
+		return 0, err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		_ = tx.Rollback()
+		return 0, err
 	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return int(id), nil
+}
 
-	fmt.Println("Réservations pour la salle", roomID)
-	for _, reservation := range reservations {
-		fmt.Printf("ID: %d, Date: %s, Début: %s, Fin: %s\n", reservation.ID, reservation.Date, reservation.StartTime, reservation.EndTime)
+// Seuls l'auteur d'une réservation et les administrateurs peuvent la modifier ou l'annuler
+func CheckOwner(db *sql.DB, user *models.User, reservationID int) error {
+	if user.Role == userlogic.RoleAdmin {
+		return nil
 	}
+	reservation, err := GetReservation(db, reservationID)
+	if err != nil {
+		return err
+	}
+	if reservation.UserID != user.ID {
+		return models.Errorf(models.ErrForbidden, "la réservation %d appartient à un autre utilisateur", reservationID)
+	}
+	return nil
 }
 
-func GetReservationsByRoom(db *sql.DB, roomID int) ([]models.Reservation, error) {
-	var reservations []models.Reservation
-	query := "SELECT id, room_id, date, start_time, end_time FROM reservations WHERE room_id = ?"
-	rows, err := db.Query(query, roomID)
+// Modifications d'une réservation : les champs nil restent inchangés
+type ReservationUpdate struct {
+	RoomID    *int
+	Date      *string
+	StartTime *string
+	EndTime   *string
+}
+
+// Déplace une réservation confirmée vers une autre salle ou un autre créneau, avec
+// les mêmes vérifications qu'une nouvelle réservation (disponibilité revérifiée dans
+// la transaction de mise à jour)
+func UpdateReservation(db *sql.DB, reservationID int, update ReservationUpdate) (*models.Reservation, error) {
+	reservation, err := GetReservation(db, reservationID)
 	if err != nil {
 		return nil, err
 	}
-	defer func(rows *sql.Rows) {
-		rowErr := rows.Close()
-		if rowErr != nil {
-			log.Printf("Erreur: %v", rowErr)
-		}
-	}(rows)
-
-	for rows.Next() {
-		var r models.Reservation
-		if err := rows.Scan(&r.ID, &r.RoomID, &r.Date, &r.StartTime, &r.EndTime); err != nil {
+	if reservation.Status != models.StatusConfirmed {
+		return nil, models.Errorf(models.ErrConflict, "seule une réservation confirmée peut être modifiée (statut : %s)", reservation.Status)
+	}
+	// Les réservations antérieures aux utilisateurs n'ont pas de propriétaire
+	user := &models.User{Role: userlogic.RoleUser}
+	if reservation.UserID != 0 {
+		if user, err = userlogic.GetUser(db, reservation.UserID); err != nil {
 			return nil, err
 		}
-		reservations = append(reservations, r)
 	}
-	return reservations, nil
-}
 
-func GetReservationsByDate(db *sql.DB, date string) ([]models.Reservation, error) {
-	var reservations []models.Reservation
-	query := "SELECT id, room_id, date, start_time, end_time FROM reservations WHERE date = ?"
-	rows, err := db.Query(query, date)
-	if err != nil {
+	moved := *reservation
+	if update.RoomID != nil {
+		moved.RoomID = *update.RoomID
+	}
+	if update.Date != nil {
+		moved.Date = *update.Date
+	}
+	if update.StartTime != nil {
+		moved.StartTime = *update.StartTime
+	}
+	if update.EndTime != nil {
+		moved.EndTime = *update.EndTime
+	}
+	if err := checkBooking(db, user, moved.RoomID, moved.Date, moved.StartTime, moved.EndTime, reservationID); err != nil {
+		return nil, err
+	}
+	if err := quotalogic.CheckQuotaForMove(db, user, *reservation, moved.RoomID, moved.Date, moved.StartTime, moved.EndTime); err != nil {
 		return nil, err
 	}
-	defer func(rows *sql.Rows) {
-		rowErr := rows.Close()
-		if rowErr != nil {
-			log.Printf("Erreur: %v", rowErr)
-		}
-	}(rows)
 
-	for rows.Next() {
-		var r models.Reservation
-		if err := rows.Scan(&r.ID, &r.RoomID, &r.Date, &r.StartTime, &r.EndTime); err != nil {
-			return nil, err
-		}
-		reservations = append(reservations, r)
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	if err := checkFreeInTx(tx, moved.RoomID, moved.Date, moved.StartTime, moved.EndTime, reservationID); err != nil {
+		_ = tx.Rollback()
+		return nil, err
+	}
+	query := "UPDATE reservations SET room_id = ?, date = ?, start_time = ?, end_time = ? WHERE id = ?"
+	if _, err := tx.Exec(query, moved.RoomID, moved.Date, moved.StartTime, moved.EndTime, reservationID); err != nil {
+		_ = tx.Rollback()
+		return nil, err
 	}
-	return reservations, nil
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return &moved, nil
 }
 
-func InsertReservation(db *sql.DB, roomID, date, startTime, endTime string) {
-	query := `INSERT INTO reservations (room_id, date, start_time, end_time) VALUES (?, ?, ?, ?)`
+// Nombre de créneaux proposés dans la même salle en cas de conflit
+const sameRoomSuggestions = 3
 
-	_, err := db.Exec(query, roomID, date, startTime, endTime)
+// Propositions faites lorsqu'un créneau est déjà pris
+type Alternatives struct {
+	Conflicts []models.Reservation
+	// Créneaux libres de même durée dans la même salle, du plus proche au plus éloigné de l'heure demandée
+	SameRoom []slotlogic.Slot
+	// Salles de capacité égale ou supérieure libres au créneau demandé
+	OtherRooms []models.Room
+}
+
+func SuggestAlternatives(db *sql.DB, roomID int, date, startTime, endTime string) (*Alternatives, error) {
+	start, err := utils.ParseClock(startTime)
 	if err != nil {
-		log.Printf("Erreur lors de la création de la réservation : %v", err)
-	} else {
+		return nil, err
+	}
+	end, err := utils.ParseClock(endTime)
+	if err != nil {
+		return nil, err
+	}
+	room, err := roomlogic.GetRoom(db, roomID)
+	if err != nil {
+		return nil, err
+	}
 
+	alternatives := &Alternatives{}
+	if alternatives.Conflicts, err = roomlogic.GetConflictingReservations(db, roomID, date, startTime, endTime); err != nil {
+		return nil, err
 	}
 
-}
+	// Tous les débuts libres de la journée, y compris juste avant une réservation (au plus
+	// 24 h / 15 min), triés par distance à l'heure demandée
+	search := slotlogic.Search{Duration: end - start, From: date, To: date, RoomIDs: []int{roomID},
+		EveryStart: true, Limit: 24 * 60 / slotlogic.DefaultStep}
+	slots, err := slotlogic.FindFreeSlots(db, search, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	alternatives.SameRoom = nearestSlots(slots, start, sameRoomSuggestions)
 
-func CancelReservation(db *sql.DB, scanner *bufio.Scanner) {
-	fmt.Print("Entrez l'identifiant de la réservation à annuler : ")
-	scanner.Scan()
-	reservationID := scanner.Text()
+	rooms, err := roomlogic.GetAvailableRooms(db, date, startTime, endTime)
+	if err != nil {
+		return nil, err
+	}
+	for _, other := range rooms {
+		if other.ID != roomID && other.Capacity >= room.Capacity {
+			alternatives.OtherRooms = append(alternatives.OtherRooms, other)
+		}
+	}
+	return alternatives, nil
+}
 
-	// Vérification de l'existence de la réservation avant de tenter de l'annuler
-	if ReservationExists(db, reservationID) {
-		err := DeleteReservation(db, reservationID)
+// Les n créneaux les plus proches de l'heure demandée, dont toujours le plus proche avant
+// et le plus proche après s'ils existent, du plus proche au plus éloigné
+func nearestSlots(slots []slotlogic.Slot, start, n int) []slotlogic.Slot {
+	sort.SliceStable(slots, func(i, j int) bool {
+		return distance(slots[i], start) < distance(slots[j], start)
+	})
+	if len(slots) <= n {
+		return slots
+	}
+	before, after := -1, -1
+	for i, slot := range slots {
+		slotStart, err := utils.ParseClock(slot.StartTime)
 		if err != nil {
-			fmt.Println("Erreur lors de l'annulation de la réservation :", err)
-		} else {
-			fmt.Println("Réservation annulée avec succès.")
+			continue
+		}
+		if slotStart < start && before < 0 {
+			before = i
+		}
+		if slotStart >= start && after < 0 {
+			after = i
 		}
-	} else {
-		fmt.Println("Aucune réservation trouvée avec cet identifiant.")
 	}
-	menulogic.NavigationOptions(db, scanner)
+	picked := map[int]bool{}
+	for _, i := range []int{before, after} {
+		if i >= 0 {
+			picked[i] = true
+		}
+	}
+	for i := 0; len(picked) < n; i++ {
+		picked[i] = true
+	}
+	nearest := make([]slotlogic.Slot, 0, n)
+	for i, slot := range slots {
+		if picked[i] {
+			nearest = append(nearest, slot)
+		}
+	}
+	return nearest
 }
 
-func DeleteReservation(db *sql.DB, reservationID string) error {
-	query := "DELETE FROM reservations WHERE id = ?"
-	_, err := db.Exec(query, reservationID)
-	return err
+func distance(slot slotlogic.Slot, start int) int {
+	slotStart, err := utils.ParseClock(slot.StartTime)
+	if err != nil {
+		return 24 * 60
+	}
+	if slotStart < start {
+		return start - slotStart
+	}
+	return slotStart - start
 }
 
-func ViewReservations(db *sql.DB, scanner *bufio.Scanner) {
-	fmt.Println("Visualisation des réservations:")
-
-	query := `SELECT r.id, r.room_id, r.date, r.start_time, r.end_time 
-              FROM reservations r
-              ORDER BY r.date, r.start_time`
-	rows, err := db.Query(query)
+func GetReservationsByRoom(db *sql.DB, roomID int) ([]models.Reservation, error) {
+	query := "SELECT " + reservationColumns + " FROM reservations WHERE room_id = ?"
+	rows, err := db.Query(query, roomID)
 	if err != nil {
-		log.Printf("Erreur lors de la récupération des réservations : %v", err)
-		return
+		return nil, err
 	}
-	defer func(rows *sql.Rows) {
-		rowErr := rows.Close()
-		if rowErr != nil {
-			log.Printf("Erreur: %v", rowErr)
-		}
-	}(rows)
+	return scanReservations(rows)
+}
 
-	for rows.Next() {
-		var reservation models.Reservation
-		if err := rows.Scan(&reservation.ID, &reservation.RoomID, &reservation.Date, &reservation.StartTime, &reservation.EndTime); err != nil {
-			log.Printf("Erreur lors de la lecture des données de la réservation : %v", err)
-			continue
-		}
-		fmt.Printf("ID: %d, Salle: %d, Date: %s, Début: %s, Fin: %s\n",
-			reservation.ID, reservation.RoomID, reservation.Date, reservation.StartTime, reservation.EndTime)
+func GetReservationsByDate(db *sql.DB, date string) ([]models.Reservation, error) {
+	query := "SELECT " + reservationColumns + " FROM reservations WHERE date = ?"
+	rows, err := db.Query(query, date)
+	if err != nil {
+		return nil, err
 	}
+	return scanReservations(rows)
+}
 
-	if err := rows.Err(); err != nil {
-		log.Printf("Erreur lors de l'itération sur les réservations : %v", err)
+// Réservations dont la date est comprise entre from et to inclus
+func GetReservationsBetween(db *sql.DB, from, to string) ([]models.Reservation, error) {
+	query := "SELECT " + reservationColumns + " FROM reservations WHERE date BETWEEN ? AND ? ORDER BY date, start_time, id"
+	rows, err := db.Query(query, from, to)
+	if err != nil {
+		return nil, err
 	}
-
-	// Offre des options de navigation après avoir visualisé les réservations.
-	menulogic.NavigationOptions(db, scanner)
+	return scanReservations(rows)
 }
 
-// Fonction pour récupérer et afficher les réservations par date
-func ViewReservationsByDate(db *sql.DB, scanner *bufio.Scanner) {
-	fmt.Print("Entrez la date pour laquelle vous souhaitez voir les réservations (format YYYY-MM-DD) : ")
-	scanner.Scan()
-	date := scanner.Text()
+func InsertReservation(db *sql.DB, roomID string, userID int, date, startTime, endTime string) (int, error) {
+	query := `INSERT INTO reservations (room_id, user_id, date, start_time, end_time) VALUES (?, ?, ?, ?, ?)`
 
-	// Validation de la date (simple vérification de format)
-	if _, err := time.Parse("2006-01-02", date); err != nil {
-		fmt.Println("Erreur : Format de date invalide.")
-		return
+	result, err := db.Exec(query, roomID, userID, date, startTime, endTime)
+	if err != nil {
+		log.Printf("Erreur lors de la création de la réservation : %v", err)
+		return 0, err
 	}
+	id, err := result.LastInsertId()
+	return int(id), err
+}
 
-	// Appel à la fonction pour obtenir les réservations
-	reservations, err := GetReservationsByDate(db, date)
+// Annule une réservation, ou tout son groupe multi-salles si withGroup est vrai ;
+// renvoie le nombre de réservations annulées. Les réservations annulées sont conservées
+// avec le statut « cancelled » pour être publiées comme telles dans les calendriers.
+func CancelReservation(db *sql.DB, reservationID int, withGroup bool) (int, error) {
+	reservation, err := GetReservation(db, reservationID)
 	if err != nil {
-		fmt.Println("Erreur lors de la récupération des réservations :", err)
-		return
+		return 0, err
 	}
+	if reservation.Status != models.StatusConfirmed && reservation.Status != models.StatusCheckedIn {
+		return 0, models.Errorf(models.ErrConflict, "la réservation %d ne peut pas être annulée (statut : %s)", reservationID, reservation.Status)
+	}
+	if withGroup && reservation.GroupID != "" {
+		return cancelReservations(db, "group_id = ?", reservation.GroupID)
+	}
+	return cancelReservations(db, "id = ?", reservationID)
+}
 
-	// Affichage des réservations
-	if len(reservations) == 0 {
-		fmt.Println("Aucune réservation trouvée pour la date", date)
-		return
+// Passe au statut « cancelled » les réservations actives qui vérifient la condition
+func cancelReservations(db *sql.DB, condition string, args ...interface{}) (int, error) {
+	query := "UPDATE reservations SET status = ? WHERE status IN (?, ?) AND " + condition
+	args = append([]interface{}{models.StatusCancelled, models.StatusConfirmed, models.StatusCheckedIn}, args...)
+	result, err := db.Exec(query, args...)
+	if err != nil {
+		return 0, err
 	}
+	count, err := result.RowsAffected()
+	return int(count), err
+}
 
-	fmt.Println("Réservations pour la date", date)
-	for _, reservation := range reservations {
-		fmt.Printf("ID Réservation: %d, ID Salle: %d, Début: %s, Fin: %s\n", reservation.ID, reservation.RoomID, reservation.StartTime, reservation.EndTime)
+func GetReservationGroup(db *sql.DB, reservationID string) (string, error) {
+	var groupID string
+	err := db.QueryRow("SELECT COALESCE(group_id, '') FROM reservations WHERE id = ?", reservationID).Scan(&groupID)
+	return groupID, err
+}
+
+func GetReservationsByGroup(db *sql.DB, groupID string) ([]models.Reservation, error) {
+	query := "SELECT " + reservationColumns + " FROM reservations WHERE group_id = ? ORDER BY room_id"
+	rows, err := db.Query(query, groupID)
+	if err != nil {
+		return nil, err
 	}
+	return scanReservations(rows)
 }
 
 func ReservationExists(db *sql.DB, reservationID string) bool {
@@ -244,12 +397,19 @@ func ReservationExists(db *sql.DB, reservationID string) bool {
 }
 
 func GetAllReservations(db *sql.DB) ([]models.Reservation, error) {
-	var reservations []models.Reservation
-	query := "SELECT id, room_id, date, start_time, end_time FROM reservations"
+	query := "SELECT " + reservationColumns + " FROM reservations ORDER BY date, start_time, id"
 	rows, err := db.Query(query)
 	if err != nil {
 		return nil, err
 	}
+	return scanReservations(rows)
+}
+
+// Colonnes lues par scanReservations, dans l'ordre des champs de models.Reservation
+const reservationColumns = "id, room_id, COALESCE(user_id, 0), date, start_time, end_time, COALESCE(group_id, ''), COALESCE(series_id, ''), status"
+
+func scanReservations(rows *sql.Rows) ([]models.Reservation, error) {
+	var reservations []models.Reservation
 	defer func(rows *sql.Rows) {
 		rowErr := rows.Close()
 		if rowErr != nil {
@@ -259,10 +419,10 @@ func GetAllReservations(db *sql.DB) ([]models.Reservation, error) {
 
 	for rows.Next() {
 		var r models.Reservation
-		if err := rows.Scan(&r.ID, &r.RoomID, &r.Date, &r.StartTime, &r.EndTime); err != nil {
+		if err := rows.Scan(&r.ID, &r.RoomID, &r.UserID, &r.Date, &r.StartTime, &r.EndTime, &r.GroupID, &r.SeriesID, &r.Status); err != nil {
 			return nil, err
 		}
 		reservations = append(reservations, r)
 	}
-	return reservations, nil
+	return reservations, rows.Err()
 }
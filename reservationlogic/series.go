@@ -0,0 +1,140 @@
+package reservationlogic
+
+import (
+	"Reserve-Go/models"
+	"Reserve-Go/quotalogic"
+	"Reserve-Go/utils"
+	"database/sql"
+	"fmt"
+	"log"
+)
+
+// Nombre maximum d'occurrences d'une série (deux ans de cours hebdomadaires)
+const MaxSeriesOccurrences = 104
+
+// Dates des occurrences prévues par la règle de la série, dans l'ordre
+func SeriesDates(series models.Series) ([]string, error) {
+	first, err := utils.ParseDate(series.StartDate)
+	if err != nil {
+		return nil, err
+	}
+	if series.Interval < 1 {
+		return nil, models.NewError(models.ErrInvalid, "l'intervalle de répétition doit être d'au moins 1")
+	}
+	if series.Count < 1 || series.Count > MaxSeriesOccurrences {
+		return nil, models.Errorf(models.ErrInvalid, "une série compte de 1 à %d occurrences", MaxSeriesOccurrences)
+	}
+	var step int
+	switch series.Frequency {
+	case models.FrequencyDaily:
+		step = series.Interval
+	case models.FrequencyWeekly:
+		step = 7 * series.Interval
+	default:
+		return nil, models.Errorf(models.ErrInvalid, "fréquence inconnue : %q (daily ou weekly)", series.Frequency)
+	}
+
+	dates := make([]string, series.Count)
+	for i := range dates {
+		dates[i] = first.AddDate(0, 0, i*step).Format(utils.DateLayout)
+	}
+	return dates, nil
+}
+
+// Réserve toutes les occurrences d'une série dans la même salle : soit toutes les
+// réservations sont créées, soit aucune ; la disponibilité de chaque occurrence est
+// vérifiée dans la transaction d'insertion. Renvoie l'identifiant de la série.
+func BookSeries(db *sql.DB, user *models.User, series models.Series) (string, error) {
+	if series.Interval == 0 {
+		series.Interval = 1
+	}
+	dates, err := SeriesDates(series)
+	if err != nil {
+		return "", err
+	}
+	for _, date := range dates {
+		if err := checkAllowed(db, user, series.RoomID, date, series.StartTime, series.EndTime); err != nil {
+			return "", fmt.Errorf("occurrence du %s : %w", date, err)
+		}
+	}
+	if err := quotalogic.CheckQuotaForSeries(db, user, series.RoomID, dates, series.StartTime, series.EndTime); err != nil {
+		return "", err
+	}
+
+	if series.ID, err = newGroupID(); err != nil {
+		return "", err
+	}
+	tx, err := db.Begin()
+	if err != nil {
+		return "", err
+	}
+	query := `INSERT INTO reservation_series (id, room_id, user_id, start_date, start_time, end_time, frequency, interval_count, occurrences)
+              VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	if _, err := tx.Exec(query, series.ID, series.RoomID, user.ID, series.StartDate, series.StartTime, series.EndTime,
+		series.Frequency, series.Interval, series.Count); err != nil {
+		_ = tx.Rollback()
+		return "", err
+	}
+	query = `INSERT INTO reservations (room_id, user_id, date, start_time, end_time, series_id, series_date) VALUES (?, ?, ?, ?, ?, ?, ?)`
+	for _, date := range dates {
+		if err := checkFreeInTx(tx, series.RoomID, date, series.StartTime, series.EndTime, 0); err != nil {
+			_ = tx.Rollback()
+			return "", fmt.Errorf("occurrence du %s : %w", date, err)
+		}
+		if _, err := tx.Exec(query, series.RoomID, user.ID, date, series.StartTime, series.EndTime, series.ID, date); err != nil {
+			_ = tx.Rollback()
+			return "", fmt.Errorf("occurrence du %s : %w", date, err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return "", err
+	}
+	return series.ID, nil
+}
+
+const seriesColumns = "id, room_id, COALESCE(user_id, 0), start_date, start_time, end_time, frequency, interval_count, occurrences"
+
+func GetSeries(db *sql.DB, seriesID string) (*models.Series, error) {
+	var s models.Series
+	err := db.QueryRow("SELECT "+seriesColumns+" FROM reservation_series WHERE id = ?", seriesID).
+		Scan(&s.ID, &s.RoomID, &s.UserID, &s.StartDate, &s.StartTime, &s.EndTime, &s.Frequency, &s.Interval, &s.Count)
+	if err == sql.ErrNoRows {
+		return nil, models.Errorf(models.ErrNotFound, "la série %s n'existe pas", seriesID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// Réservation d'une série avec la date que la règle lui avait attribuée
+type Occurrence struct {
+	Reservation models.Reservation
+	SeriesDate  string
+}
+
+// Toutes les réservations d'une série, y compris annulées ou déplacées, dans l'ordre de la règle
+func GetSeriesOccurrences(db *sql.DB, seriesID string) ([]Occurrence, error) {
+	query := "SELECT " + reservationColumns + ", COALESCE(series_date, date) FROM reservations WHERE series_id = ? ORDER BY series_date, id"
+	rows, err := db.Query(query, seriesID)
+	if err != nil {
+		return nil, err
+	}
+	defer func(rows *sql.Rows) {
+		rowErr := rows.Close()
+		if rowErr != nil {
+			log.Printf("Erreur: %v", rowErr)
+		}
+	}(rows)
+
+	var occurrences []Occurrence
+	for rows.Next() {
+		var o Occurrence
+		r := &o.Reservation
+		if err := rows.Scan(&r.ID, &r.RoomID, &r.UserID, &r.Date, &r.StartTime, &r.EndTime, &r.GroupID, &r.SeriesID, &r.Status, &o.SeriesDate); err != nil {
+			return nil, err
+		}
+		occurrences = append(occurrences, o)
+	}
+	return occurrences, rows.Err()
+}
@@ -0,0 +1,75 @@
+package roomlogic
+
+import (
+	"Reserve-Go/models"
+	"Reserve-Go/utils"
+	"database/sql"
+	"slices"
+)
+
+// Réservations retenues par un import mais pas encore enregistrées : elles se bloquent
+// entre elles selon les mêmes règles que blockingCondition (même salle, sous-salle ou
+// salle parente, temps de préparation compris)
+type Batch struct {
+	rooms        map[int]models.Room
+	components   []models.RoomComponent
+	reservations []models.Reservation
+}
+
+func NewBatch(db *sql.DB) (*Batch, error) {
+	rooms, err := GetRooms(db)
+	if err != nil {
+		return nil, err
+	}
+	components, err := GetComponents(db)
+	if err != nil {
+		return nil, err
+	}
+	b := &Batch{rooms: make(map[int]models.Room, len(rooms)), components: components}
+	for _, room := range rooms {
+		b.rooms[room.ID] = room
+	}
+	return b, nil
+}
+
+// Ajoute une réservation retenue ; ses heures sont au format HH:MM ou HH:MM:SS
+func (b *Batch) Add(res models.Reservation) {
+	b.reservations = append(b.reservations, res)
+}
+
+// Réservations retenues, dans l'ordre des appels à Add
+func (b *Batch) Reservations() []models.Reservation {
+	return b.reservations
+}
+
+// Indice (dans l'ordre des Add) de la première réservation retenue qui bloque res ; -1 si aucune
+func (b *Batch) Blocking(res models.Reservation) (int, error) {
+	start, err := utils.ParseClock(res.StartTime)
+	if err != nil {
+		return -1, err
+	}
+	end, err := utils.ParseClock(res.EndTime)
+	if err != nil {
+		return -1, err
+	}
+	candidate := b.rooms[res.RoomID]
+	related := RelatedRoomIDs(b.components, res.RoomID)
+	for i, other := range b.reservations {
+		if other.Date != res.Date || !slices.Contains(related, other.RoomID) {
+			continue
+		}
+		otherStart, err := utils.ParseClock(other.StartTime)
+		if err != nil {
+			return -1, err
+		}
+		otherEnd, err := utils.ParseClock(other.EndTime)
+		if err != nil {
+			return -1, err
+		}
+		booked := b.rooms[other.RoomID]
+		if otherStart-(booked.BufferBefore+candidate.BufferAfter) < end && otherEnd+(booked.BufferAfter+candidate.BufferBefore) > start {
+			return i, nil
+		}
+	}
+	return -1, nil
+}
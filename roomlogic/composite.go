@@ -0,0 +1,89 @@
+package roomlogic
+
+import (
+	"Reserve-Go/models"
+	"database/sql"
+	"log"
+)
+
+// Condition SQL indiquant qu'une réservation r (dans la salle b) bloque la salle
+// candidate c : réservation active, même salle, sous-salle ou salle parente, et
+// chevauchement une fois les temps de préparation et de nettoyage pris en compte.
+// Paramètres : fin puis début du créneau demandé.
+const blockingCondition = activeReservation + `
+                AND (r.room_id = c.id
+                    OR r.room_id IN (SELECT child_id FROM room_components WHERE parent_id = c.id)
+                    OR r.room_id IN (SELECT parent_id FROM room_components WHERE child_id = c.id))
+                AND SUBTIME(r.start_time, SEC_TO_TIME((b.buffer_before + c.buffer_after) * 60)) < ?
+                AND ADDTIME(r.end_time, SEC_TO_TIME((b.buffer_after + c.buffer_before) * 60)) > ?`
+
+// Seules les réservations confirmées ou honorées occupent la salle
+const activeReservation = `r.status IN ('confirmed', 'checked_in')`
+
+func GetComponents(db *sql.DB) ([]models.RoomComponent, error) {
+	rows, err := db.Query("SELECT parent_id, child_id FROM room_components ORDER BY parent_id, child_id")
+	if err != nil {
+		return nil, err
+	}
+	defer func(rows *sql.Rows) {
+		rowErr := rows.Close()
+		if rowErr != nil {
+			log.Printf("Erreur: %v", rowErr)
+		}
+	}(rows)
+
+	var components []models.RoomComponent
+	for rows.Next() {
+		var c models.RoomComponent
+		if err := rows.Scan(&c.ParentID, &c.ChildID); err != nil {
+			return nil, err
+		}
+		components = append(components, c)
+	}
+	return components, rows.Err()
+}
+
+// La salle elle-même, ses sous-salles et ses salles parentes
+func RelatedRoomIDs(components []models.RoomComponent, roomID int) []int {
+	related := []int{roomID}
+	for _, c := range components {
+		if c.ParentID == roomID {
+			related = append(related, c.ChildID)
+		}
+		if c.ChildID == roomID {
+			related = append(related, c.ParentID)
+		}
+	}
+	return related
+}
+
+// Déclare childID comme sous-salle de parentID
+func AddComponent(db *sql.DB, parentID, childID int) error {
+	if parentID == childID {
+		return models.NewError(models.ErrInvalid, "une salle ne peut pas être sa propre sous-salle")
+	}
+	for _, id := range []int{parentID, childID} {
+		if !IsRoomExists(db, id) {
+			return models.Errorf(models.ErrNotFound, "la salle avec l'ID %d n'existe pas", id)
+		}
+	}
+	components, err := GetComponents(db)
+	if err != nil {
+		return err
+	}
+	for _, c := range components {
+		if c.ChildID == parentID {
+			return models.Errorf(models.ErrConflict, "la salle %d est déjà une sous-salle de %d et ne peut pas être composée", parentID, c.ParentID)
+		}
+		if c.ParentID == childID {
+			return models.Errorf(models.ErrConflict, "la salle %d est déjà composée et ne peut pas être une sous-salle", childID)
+		}
+	}
+	_, err = db.Exec("INSERT INTO room_components (parent_id, child_id) VALUES (?, ?)", parentID, childID)
+	return err
+}
+
+func RemoveComponent(db *sql.DB, parentID, childID int) error {
+	_, err := db.Exec("DELETE FROM room_components WHERE parent_id = ? AND child_id = ?", parentID, childID)
+	return err
+}
@@ -1,116 +1,192 @@
 package roomlogic
 
 import (
-	"Reserve-Go/menulogic"
+	"Reserve-Go/calendarlogic"
 	"Reserve-Go/models"
-	"bufio"
+	"Reserve-Go/utils"
 	"database/sql"
-	"fmt"
 	"log"
 	"strconv"
+	"strings"
 )
 
-func AddRoom(db *sql.DB, scanner *bufio.Scanner) {
-	fmt.Println("Ajout d'une nouvelle salle...")
-
-	fmt.Println("Entrez le nom de la salle :")
-	scanner.Scan()
-	name := scanner.Text()
-
-	fmt.Println("Entrez la capacité de la salle :")
-	scanner.Scan()
-	capacity, err := strconv.Atoi(scanner.Text())
-	if err != nil {
-		log.Printf("Erreur : Capacité invalide. %v", err)
-		return
+// Vérifie les champs d'une salle avant son enregistrement
+func ValidateRoom(room models.Room) error {
+	switch {
+	case strings.TrimSpace(room.Name) == "":
+		return models.NewError(models.ErrInvalid, "le nom de la salle est obligatoire")
+	case room.Capacity <= 0:
+		return models.Errorf(models.ErrInvalid, "capacité invalide : %d", room.Capacity)
+	case room.BufferBefore < 0 || room.BufferAfter < 0:
+		return models.NewError(models.ErrInvalid, "les temps de préparation et de nettoyage ne peuvent pas être négatifs")
 	}
+	return nil
+}
 
-	query := "INSERT INTO rooms (name, capacity) VALUES (?, ?)"
-	_, err = db.Exec(query, name, capacity)
+func InsertRoom(db *sql.DB, room models.Room) (int, error) {
+	if err := ValidateRoom(room); err != nil {
+		return 0, err
+	}
+	query := "INSERT INTO rooms (name, capacity, buffer_before, buffer_after, features, building) VALUES (?, ?, ?, ?, ?, ?)"
+	result, err := db.Exec(query, strings.TrimSpace(room.Name), room.Capacity, room.BufferBefore, room.BufferAfter,
+		JoinFeatures(room.Features), strings.TrimSpace(room.Building))
 	if err != nil {
-		log.Printf("Erreur lors de l'ajout de la salle : %v", err)
-	} else {
-		fmt.Println("Salle ajoutée avec succès.")
+		return 0, err
 	}
-	menulogic.NavigationOptions(db, scanner)
+	id, err := result.LastInsertId()
+	return int(id), err
 }
 
-func ListAvailableRooms(db *sql.DB, date string, startTime string, endTime string, scanner *bufio.Scanner) ([]models.Room, error) {
-	var rooms []models.Room
-	query := `SELECT id, name, capacity FROM rooms WHERE id NOT IN (
-				SELECT room_id FROM reservations WHERE date = ? AND NOT (end_time <= ? OR start_time >= ?)
-			) AND available = TRUE`
-	rows, err := db.Query(query, date, startTime, endTime)
+// Salles libres sur le créneau, ouvertes ce jour-là et à ces horaires
+func GetAvailableRooms(db *sql.DB, date string, startTime string, endTime string) ([]models.Room, error) {
+	// Les salles fermées ce jour-là ou hors de leurs horaires d'ouverture sont exclues
+	// Les temps de préparation / nettoyage élargissent chaque réservation existante, et
+	// une salle est bloquée par les réservations de ses sous-salles ou de sa salle parente
+	query := `SELECT ` + roomColumns + ` FROM rooms c WHERE NOT EXISTS (
+				SELECT 1 FROM reservations r JOIN rooms b ON b.id = r.room_id
+				WHERE r.date = ? AND ` + blockingCondition + `
+			) AND c.available = TRUE
+			AND NOT EXISTS (SELECT 1 FROM closure_days WHERE date = ?)
+			AND (NOT EXISTS (SELECT 1 FROM room_opening_hours h WHERE h.room_id = c.id)
+				OR EXISTS (SELECT 1 FROM room_opening_hours h WHERE h.room_id = c.id
+					AND h.weekday = WEEKDAY(?) + 1 AND h.open_time <= ? AND h.close_time >= ?))
+			ORDER BY c.capacity, c.id`
+	rows, err := db.Query(query, date, endTime, startTime, date, date, startTime, endTime)
 	if err != nil {
 		return nil, err
 	}
-	defer func(rows *sql.Rows) {
-		rowErr := rows.Close()
-		if rowErr != nil {
-			log.Printf("Erreur: %v", rowErr)
-		}
-	}(rows)
-	fmt.Printf("Salles disponnibles:")
-	for rows.Next() {
-		var room models.Room
-		if err := rows.Scan(&room.ID, &room.Name, &room.Capacity); err != nil {
-			return rooms, err
-		}
-		rooms = append(rooms, room)
-		fmt.Printf("ID: %d, Nom: %s, Capacité: %d\n", room.ID, room.Name, room.Capacity)
-	}
-	menulogic.NavigationOptions(db, scanner)
-	return rooms, nil
+	return scanRooms(rows)
 }
 
-func UpdateRoom(db *sql.DB, scanner *bufio.Scanner) {
-	fmt.Println("Modification d'une salle existante...")
+// Modifications d'une salle : les champs nil restent inchangés
+type RoomUpdate struct {
+	Name         *string
+	Capacity     *int
+	BufferBefore *int
+	BufferAfter  *int
+	Features     *[]string
+	Building     *string
+}
 
-	fmt.Println("Entrez l'ID de la salle à modifier :")
-	scanner.Scan()
-	id, err := strconv.Atoi(scanner.Text())
+func UpdateRoom(db *sql.DB, id int, update RoomUpdate) (*models.Room, error) {
+	room, err := GetRoom(db, id)
 	if err != nil {
-		log.Printf("Erreur : ID invalide. %v", err)
-		return
+		return nil, err
+	}
+	if update.Name != nil {
+		room.Name = *update.Name
+	}
+	if update.Capacity != nil {
+		room.Capacity = *update.Capacity
+	}
+	if update.BufferBefore != nil {
+		room.BufferBefore = *update.BufferBefore
+	}
+	if update.BufferAfter != nil {
+		room.BufferAfter = *update.BufferAfter
+	}
+	if update.Features != nil {
+		room.Features = *update.Features
+	}
+	if update.Building != nil {
+		room.Building = strings.TrimSpace(*update.Building)
+	}
+	if err := ValidateRoom(*room); err != nil {
+		return nil, err
 	}
 
-	fmt.Println("Entrez le nouveau nom (laissez vide pour ne pas modifier) :")
-	scanner.Scan()
-	name := scanner.Text()
+	query := `UPDATE rooms SET name = ?, capacity = ?, buffer_before = ?, buffer_after = ?, features = ?, building = ? WHERE id = ?`
+	_, err = db.Exec(query, strings.TrimSpace(room.Name), room.Capacity, room.BufferBefore, room.BufferAfter,
+		JoinFeatures(room.Features), room.Building, id)
+	if err != nil {
+		return nil, err
+	}
+	return room, nil
+}
 
-	fmt.Println("Entrez la nouvelle capacité (laissez vide pour ne pas modifier) :")
-	scanner.Scan()
-	capacityStr := scanner.Text()
-	var capacity int
-	if capacityStr != "" {
-		capacity, err = strconv.Atoi(capacityStr)
-		if err != nil {
-			log.Printf("Erreur : Capacité invalide. %v", err)
-			return
-		}
+// Supprime une salle sans réservation, avec ses horaires, ses liens de salle
+// composée, ses flux iCalendar et les règles et périodes de gel qui ne concernaient qu'elle. Les
+// réservations annulées et les séries dont toutes les occurrences le sont ne
+// comptent pas : elles sont supprimées avec la salle.
+func DeleteRoom(db *sql.DB, id int) error {
+	if !IsRoomExists(db, id) {
+		return models.Errorf(models.ErrNotFound, "la salle avec l'ID %d n'existe pas", id)
+	}
+	var reservations int
+	err := db.QueryRow("SELECT COUNT(*) FROM reservations WHERE room_id = ? AND status <> ?", id, models.StatusCancelled).Scan(&reservations)
+	if err != nil {
+		return err
+	}
+	if reservations > 0 {
+		return models.Errorf(models.ErrConflict, "la salle %d a %d réservation(s) et ne peut pas être supprimée", id, reservations)
+	}
+	// Une occurrence déplacée dans une autre salle garde sa série, rattachée à celle-ci
+	var series int
+	err = db.QueryRow(`SELECT COUNT(*) FROM reservation_series s WHERE s.room_id = ?
+                       AND EXISTS (SELECT 1 FROM reservations r WHERE r.series_id = s.id AND r.status <> ?)`,
+		id, models.StatusCancelled).Scan(&series)
+	if err != nil {
+		return err
+	}
+	if series > 0 {
+		return models.Errorf(models.ErrConflict, "la salle %d porte %d série(s) en cours et ne peut pas être supprimée", id, series)
 	}
 
-	query := "UPDATE rooms SET name = COALESCE(NULLIF(?, ''), name), capacity = COALESCE(NULLIF(?, 0), capacity) WHERE id = ?"
-	_, err = db.Exec(query, name, capacity, id)
+	tx, err := db.Begin()
 	if err != nil {
-		log.Printf("Erreur lors de la modification de la salle : %v", err)
-	} else {
-		fmt.Println("Salle modifiée avec succès.")
+		return err
+	}
+	queries := []string{
+		"DELETE FROM room_opening_hours WHERE room_id = ?",
+		"DELETE FROM room_components WHERE parent_id = ? OR child_id = ?",
+		"DELETE FROM booking_rules WHERE room_id = ?",
+		"DELETE FROM blackouts WHERE room_id = ?",
+		"DELETE FROM calendar_feeds WHERE room_id = ?",
+		"DELETE FROM reservations WHERE room_id = ? AND status = '" + models.StatusCancelled + "'",
+		"DELETE FROM reservation_series WHERE room_id = ?",
+		"DELETE FROM rooms WHERE id = ?",
 	}
-	menulogic.NavigationOptions(db, scanner)
+	for _, query := range queries {
+		args := []interface{}{id}
+		if strings.Count(query, "?") == 2 {
+			args = append(args, id)
+		}
+		if _, err := tx.Exec(query, args...); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
 }
 
-func ListRooms(db *sql.DB, scanner *bufio.Scanner) {
-	fmt.Println("Liste des salles disponibles:")
+// Colonnes lues par scanRooms, dans l'ordre des champs de models.Room
+const roomColumns = "id, name, capacity, buffer_before, buffer_after, features, COALESCE(available, TRUE), building"
 
-	query := "SELECT id, name, capacity FROM rooms"
-	rows, err := db.Query(query)
+func GetRooms(db *sql.DB) ([]models.Room, error) {
+	rows, err := db.Query("SELECT " + roomColumns + " FROM rooms ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	return scanRooms(rows)
+}
 
+func GetRoom(db *sql.DB, id int) (*models.Room, error) {
+	rows, err := db.Query("SELECT "+roomColumns+" FROM rooms WHERE id = ?", id)
 	if err != nil {
-		log.Printf("Erreur lors de la récupération des salles : %v", err)
-		return
+		return nil, err
 	}
+	rooms, err := scanRooms(rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(rooms) == 0 {
+		return nil, models.Errorf(models.ErrNotFound, "la salle avec l'ID %d n'existe pas", id)
+	}
+	return &rooms[0], nil
+}
 
+func scanRooms(rows *sql.Rows) ([]models.Room, error) {
+	var rooms []models.Room
 	defer func(rows *sql.Rows) {
 		rowErr := rows.Close()
 		if rowErr != nil {
@@ -120,35 +196,199 @@ func ListRooms(db *sql.DB, scanner *bufio.Scanner) {
 
 	for rows.Next() {
 		var room models.Room
-		if err := rows.Scan(&room.ID, &room.Name, &room.Capacity); err != nil {
-			log.Printf("Erreur lors de la lecture des données de la salle : %v", err)
-			continue
+		var features string
+		if err := rows.Scan(&room.ID, &room.Name, &room.Capacity, &room.BufferBefore, &room.BufferAfter, &features, &room.Available, &room.Building); err != nil {
+			return nil, err
 		}
-		fmt.Printf("ID: %d, Nom: %s, Capacité: %d\n", room.ID, room.Name, room.Capacity)
+		room.Features = ParseFeatures(features)
+		rooms = append(rooms, room)
 	}
+	return rooms, rows.Err()
+}
 
-	if err := rows.Err(); err != nil {
-		log.Printf("Erreur lors de l'itération sur les salles : %v", err)
+// Découpe une liste d'équipements séparés par des virgules
+func ParseFeatures(value string) []string {
+	var features []string
+	for _, f := range strings.Split(value, ",") {
+		if f = strings.ToLower(strings.TrimSpace(f)); f != "" {
+			features = append(features, f)
+		}
+	}
+	return features
+}
+
+func JoinFeatures(features []string) string {
+	return strings.Join(features, ",")
+}
+
+// Indique si la salle possède tous les équipements demandés
+func HasFeatures(room models.Room, wanted []string) bool {
+	for _, w := range wanted {
+		found := false
+		for _, f := range room.Features {
+			if strings.EqualFold(f, w) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
 	}
-	menulogic.NavigationOptions(db, scanner)
+	return true
 }
 
 func IsRoomAvailable(db *sql.DB, roomID, date, startTime, endTime string) bool {
-	query := `SELECT COUNT(*) FROM reservations 
-              WHERE room_id = ? 
-                AND date = ?
-                AND NOT (start_time >= ? OR end_time <= ?)`
+	id, err := strconv.Atoi(roomID)
+	if err != nil {
+		log.Printf("Erreur : ID de salle invalide. %v", err)
+		return false
+	}
+	if err := calendarlogic.CheckBookable(db, id, date, startTime, endTime); err != nil {
+		log.Printf("Créneau non réservable : %v", err)
+		return false
+	}
+
+	// Deux réservations doivent être séparées par le temps de nettoyage de la
+	// première et le temps de préparation de la suivante ; les réservations des
+	// sous-salles et de la salle parente comptent aussi
+	query := `SELECT COUNT(*) FROM reservations r
+              JOIN rooms b ON b.id = r.room_id
+              JOIN rooms c ON c.id = ?
+              WHERE r.date = ?
+                AND ` + blockingCondition
 
 	var count int
-	err := db.QueryRow(query, roomID, date, endTime, startTime).Scan(&count)
+	err = db.QueryRow(query, roomID, date, endTime, startTime).Scan(&count)
 	if err != nil {
 		log.Printf("Erreur lors de la vérification de la disponibilité : %v", err)
 		return false
 	}
 	return count == 0
 }
+
+// Même vérification que IsRoomAvailable (hors horaires d'ouverture), dans la transaction
+// qui crée ou déplace les réservations : les lignes lues, dont celle de la salle, sont
+// verrouillées (FOR UPDATE) pour qu'une réservation concurrente ne s'intercale pas avant
+// la validation. exceptID désigne la réservation déplacée, à ignorer (0 pour aucune).
+func IsRoomFreeInTx(tx *sql.Tx, roomID int, date, startTime, endTime string, exceptID int) (bool, error) {
+	query := `SELECT COUNT(*) FROM reservations r
+              JOIN rooms b ON b.id = r.room_id
+              JOIN rooms c ON c.id = ?
+              WHERE r.date = ? AND r.id <> ?
+                AND ` + blockingCondition + `
+              FOR UPDATE`
+	var count int
+	if err := tx.QueryRow(query, roomID, date, exceptID, endTime, startTime).Scan(&count); err != nil {
+		return false, err
+	}
+	return count == 0, nil
+}
+
+// Réservations qui empêchent le créneau demandé dans la salle (temps de préparation et
+// de nettoyage compris, y compris celles des sous-salles ou de la salle parente)
+func GetConflictingReservations(db *sql.DB, roomID int, date, startTime, endTime string) ([]models.Reservation, error) {
+	query := `SELECT r.id, r.room_id, COALESCE(r.user_id, 0), r.date, r.start_time, r.end_time, COALESCE(r.group_id, ''), COALESCE(r.series_id, ''), r.status FROM reservations r
+              JOIN rooms b ON b.id = r.room_id
+              JOIN rooms c ON c.id = ?
+              WHERE r.date = ?
+                AND ` + blockingCondition + `
+              ORDER BY r.start_time`
+	rows, err := db.Query(query, roomID, date, endTime, startTime)
+	if err != nil {
+		return nil, err
+	}
+	defer func(rows *sql.Rows) {
+		rowErr := rows.Close()
+		if rowErr != nil {
+			log.Printf("Erreur: %v", rowErr)
+		}
+	}(rows)
+
+	var conflicts []models.Reservation
+	for rows.Next() {
+		var r models.Reservation
+		if err := rows.Scan(&r.ID, &r.RoomID, &r.UserID, &r.Date, &r.StartTime, &r.EndTime, &r.GroupID, &r.SeriesID, &r.Status); err != nil {
+			return nil, err
+		}
+		conflicts = append(conflicts, r)
+	}
+	return conflicts, rows.Err()
+}
+
 func IsRoomExists(db *sql.DB, roomID int) bool {
 	var exists bool
 	err := db.QueryRow("SELECT EXISTS(SELECT 1 FROM rooms WHERE id = ?)", roomID).Scan(&exists)
 	return err == nil && exists
 }
+
+// Plage horaire en minutes depuis minuit
+type Interval struct {
+	Start int
+	End   int
+}
+
+// Plages occupées de chaque salle pour une date : réservations de la salle, de ses
+// sous-salles et de sa salle parente, élargies des temps de préparation et de
+// nettoyage. Un nouveau créneau est libre s'il ne chevauche aucune de ces plages.
+func GetBusyIntervals(db *sql.DB, date string) (map[int][]Interval, error) {
+	rooms, err := GetRooms(db)
+	if err != nil {
+		return nil, err
+	}
+	components, err := GetComponents(db)
+	if err != nil {
+		return nil, err
+	}
+	buffers := map[int]models.Room{}
+	for _, room := range rooms {
+		buffers[room.ID] = room
+	}
+
+	query := `SELECT room_id, start_time, end_time FROM reservations r WHERE date = ? AND ` + activeReservation + ` ORDER BY start_time`
+	rows, err := db.Query(query, date)
+	if err != nil {
+		return nil, err
+	}
+	defer func(rows *sql.Rows) {
+		rowErr := rows.Close()
+		if rowErr != nil {
+			log.Printf("Erreur: %v", rowErr)
+		}
+	}(rows)
+
+	reserved := map[int][]Interval{}
+	for rows.Next() {
+		var roomID int
+		var startTime, endTime string
+		if err := rows.Scan(&roomID, &startTime, &endTime); err != nil {
+			return nil, err
+		}
+		start, err := utils.ParseClock(startTime)
+		if err != nil {
+			return nil, err
+		}
+		end, err := utils.ParseClock(endTime)
+		if err != nil {
+			return nil, err
+		}
+		reserved[roomID] = append(reserved[roomID], Interval{Start: start, End: end})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	busy := map[int][]Interval{}
+	for _, room := range rooms {
+		for _, relatedID := range RelatedRoomIDs(components, room.ID) {
+			other := buffers[relatedID]
+			for _, r := range reserved[relatedID] {
+				busy[room.ID] = append(busy[room.ID], Interval{
+					Start: r.Start - other.BufferBefore - room.BufferAfter,
+					End:   r.End + other.BufferAfter + room.BufferBefore,
+				})
+			}
+		}
+	}
+	return busy, nil
+}
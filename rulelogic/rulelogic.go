@@ -0,0 +1,199 @@
+package rulelogic
+
+import (
+	"Reserve-Go/models"
+	"Reserve-Go/utils"
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+// Types de règles. Les durées sont en minutes, l'horizon en jours.
+const (
+	RuleMinDuration = "min_duration"
+	RuleMaxDuration = "max_duration"
+	RuleGranularity = "granularity"
+	RuleLeadTime    = "lead_time"
+	RuleHorizon     = "horizon"
+)
+
+var RuleDescriptions = map[string]string{
+	RuleMinDuration: "durée minimale (minutes)",
+	RuleMaxDuration: "durée maximale (minutes)",
+	RuleGranularity: "alignement des horaires (minutes)",
+	RuleLeadTime:    "délai minimum avant le début (minutes)",
+	RuleHorizon:     "réservation au plus tard N jours à l'avance",
+}
+
+// Une réservation à évaluer
+type Request struct {
+	RoomID    int
+	Role      string
+	Date      string
+	StartTime string
+	EndTime   string
+}
+
+type Violation struct {
+	Rule    models.BookingRule
+	Message string
+}
+
+// Erreur renvoyée lorsqu'au moins une règle n'est pas respectée
+type ViolationError struct {
+	Violations []Violation
+}
+
+func (e *ViolationError) Error() string {
+	messages := make([]string, 0, len(e.Violations))
+	for _, v := range e.Violations {
+		messages = append(messages, fmt.Sprintf("règle « %s » : %s", v.Rule.Name, v.Message))
+	}
+	return strings.Join(messages, " ; ")
+}
+
+func (e *ViolationError) Unwrap() error {
+	return models.ErrForbidden
+}
+
+// Indique si une règle s'applique à la salle et au rôle de la demande
+func Applies(rule models.BookingRule, roomID int, role string) bool {
+	return (rule.RoomID == 0 || rule.RoomID == roomID) && (rule.Role == "" || rule.Role == role)
+}
+
+// Évalue toutes les règles applicables ; toutes doivent être respectées
+func Evaluate(rules []models.BookingRule, req Request, now time.Time) ([]Violation, error) {
+	day, err := time.ParseInLocation(utils.DateLayout, req.Date, now.Location())
+	if err != nil {
+		return nil, models.Errorf(models.ErrInvalid, "date invalide : %q", req.Date)
+	}
+	start, err := utils.ParseClock(req.StartTime)
+	if err != nil {
+		return nil, err
+	}
+	end, err := utils.ParseClock(req.EndTime)
+	if err != nil {
+		return nil, err
+	}
+	duration := end - start
+	startsAt := day.Add(time.Duration(start) * time.Minute)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	var violations []Violation
+	for _, rule := range rules {
+		if !Applies(rule, req.RoomID, req.Role) {
+			continue
+		}
+		var message string
+		switch rule.Type {
+		case RuleMinDuration:
+			if duration < rule.Value {
+				message = fmt.Sprintf("la réservation doit durer au moins %d minutes", rule.Value)
+			}
+		case RuleMaxDuration:
+			if duration > rule.Value {
+				message = fmt.Sprintf("la réservation ne peut pas dépasser %d minutes", rule.Value)
+			}
+		case RuleGranularity:
+			if rule.Value > 0 && (start%rule.Value != 0 || end%rule.Value != 0) {
+				message = fmt.Sprintf("le début et la fin doivent être alignés sur des tranches de %d minutes", rule.Value)
+			}
+		case RuleLeadTime:
+			if startsAt.Before(now.Add(time.Duration(rule.Value) * time.Minute)) {
+				message = fmt.Sprintf("la réservation doit être faite au moins %d minutes à l'avance", rule.Value)
+			}
+		case RuleHorizon:
+			if day.After(today.AddDate(0, 0, rule.Value)) {
+				message = fmt.Sprintf("la réservation ne peut pas être faite plus de %d jours à l'avance", rule.Value)
+			}
+		default:
+			log.Printf("Type de règle inconnu ignoré : %s", rule.Type)
+		}
+		if message != "" {
+			violations = append(violations, Violation{Rule: rule, Message: message})
+		}
+	}
+	return violations, nil
+}
+
+// Charge les règles et vérifie la demande ; renvoie un *ViolationError si une règle est enfreinte
+func CheckRules(db *sql.DB, req Request) error {
+	rules, err := GetRules(db)
+	if err != nil {
+		return err
+	}
+	violations, err := Evaluate(rules, req, time.Now())
+	if err != nil {
+		return err
+	}
+	if len(violations) > 0 {
+		return &ViolationError{Violations: violations}
+	}
+	return nil
+}
+
+func GetRules(db *sql.DB) ([]models.BookingRule, error) {
+	var rules []models.BookingRule
+	query := "SELECT id, name, rule_type, value, COALESCE(room_id, 0), COALESCE(role, '') FROM booking_rules ORDER BY id"
+	rows, err := db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer func(rows *sql.Rows) {
+		rowErr := rows.Close()
+		if rowErr != nil {
+			log.Printf("Erreur: %v", rowErr)
+		}
+	}(rows)
+
+	for rows.Next() {
+		var r models.BookingRule
+		if err := rows.Scan(&r.ID, &r.Name, &r.Type, &r.Value, &r.RoomID, &r.Role); err != nil {
+			return nil, err
+		}
+		rules = append(rules, r)
+	}
+	return rules, rows.Err()
+}
+
+func InsertRule(db *sql.DB, rule models.BookingRule) (int, error) {
+	if _, ok := RuleDescriptions[rule.Type]; !ok {
+		return 0, models.Errorf(models.ErrInvalid, "type de règle inconnu : %q", rule.Type)
+	}
+	if rule.Value < 0 {
+		return 0, models.Errorf(models.ErrInvalid, "valeur négative : %d", rule.Value)
+	}
+	var roomID sql.NullInt64
+	if rule.RoomID != 0 {
+		roomID = sql.NullInt64{Int64: int64(rule.RoomID), Valid: true}
+	}
+	var role sql.NullString
+	if rule.Role != "" {
+		role = sql.NullString{String: rule.Role, Valid: true}
+	}
+	query := "INSERT INTO booking_rules (name, rule_type, value, room_id, role) VALUES (?, ?, ?, ?, ?)"
+	result, err := db.Exec(query, rule.Name, rule.Type, rule.Value, roomID, role)
+	if err != nil {
+		return 0, err
+	}
+	id, err := result.LastInsertId()
+	return int(id), err
+}
+
+func DeleteRule(db *sql.DB, id int) error {
+	_, err := db.Exec("DELETE FROM booking_rules WHERE id = ?", id)
+	return err
+}
+
+func DescribeScope(rule models.BookingRule) string {
+	scope := "toutes les salles"
+	if rule.RoomID != 0 {
+		scope = fmt.Sprintf("salle %d", rule.RoomID)
+	}
+	if rule.Role != "" {
+		scope += ", rôle " + rule.Role
+	}
+	return scope
+}
@@ -0,0 +1,307 @@
+package slotlogic
+
+import (
+	"Reserve-Go/blackoutlogic"
+	"Reserve-Go/calendarlogic"
+	"Reserve-Go/models"
+	"Reserve-Go/roomlogic"
+	"Reserve-Go/rulelogic"
+	"Reserve-Go/userlogic"
+	"Reserve-Go/utils"
+	"database/sql"
+	"sort"
+	"time"
+)
+
+const (
+	// Pas sur lequel les débuts de créneaux proposés sont alignés
+	DefaultStep  = 15
+	DefaultLimit = 5
+	// Nombre maximum de jours parcourus par une recherche
+	MaxSearchDays = 366
+)
+
+// Critères de recherche de créneaux libres
+type Search struct {
+	Duration    int // minutes
+	From        string
+	To          string
+	MinCapacity int
+	Features    []string
+	// Plage horaire préférée (optionnelle), au format HH:MM
+	PreferredStart string
+	PreferredEnd   string
+	// Salles à considérer (toutes si vide)
+	RoomIDs []int
+	// Utilisateur qui cherche : seuls les créneaux que ses règles de réservation et les
+	// périodes de gel lui permettent de réserver sont proposés (utilisateur ordinaire si 0)
+	UserID int
+	// Propose tous les débuts alignés de chaque trou, et pas seulement le premier
+	EveryStart bool
+	Limit      int
+	Step       int
+}
+
+type Slot struct {
+	RoomID    int
+	RoomName  string
+	Capacity  int
+	Date      string
+	StartTime string
+	EndTime   string
+}
+
+// Recherche les premiers créneaux libres de la durée demandée dans toutes les salles
+// correspondant aux critères, à partir des réservations existantes de chaque jour.
+func FindFreeSlots(db *sql.DB, search Search, now time.Time) ([]Slot, error) {
+	if search.Duration <= 0 {
+		return nil, models.NewError(models.ErrInvalid, "la durée doit être positive")
+	}
+	if search.Limit <= 0 {
+		search.Limit = DefaultLimit
+	}
+	if search.Step <= 0 {
+		search.Step = DefaultStep
+	}
+	from, err := utils.ParseDate(search.From)
+	if err != nil {
+		return nil, err
+	}
+	to, err := utils.ParseDate(search.To)
+	if err != nil {
+		return nil, err
+	}
+	if to.Before(from) {
+		return nil, models.NewError(models.ErrInvalid, "la date de fin est avant la date de début")
+	}
+	if to.Sub(from) > MaxSearchDays*24*time.Hour {
+		return nil, models.Errorf(models.ErrInvalid, "la recherche est limitée à %d jours", MaxSearchDays)
+	}
+	preferred := roomlogic.Interval{Start: 0, End: 24 * 60}
+	if search.PreferredStart != "" {
+		if preferred.Start, err = utils.ParseClock(search.PreferredStart); err != nil {
+			return nil, err
+		}
+	}
+	if search.PreferredEnd != "" {
+		if preferred.End, err = utils.ParseClock(search.PreferredEnd); err != nil {
+			return nil, err
+		}
+	}
+
+	rooms, err := candidateRooms(db, search)
+	if err != nil {
+		return nil, err
+	}
+	user := &models.User{Role: userlogic.RoleUser}
+	if search.UserID != 0 {
+		if user, err = userlogic.GetUser(db, search.UserID); err != nil {
+			return nil, err
+		}
+	}
+	bookable, err := newBookableCheck(db, user, now)
+	if err != nil {
+		return nil, err
+	}
+	hours := map[int][]models.OpeningHours{}
+	for _, room := range rooms {
+		if hours[room.ID], err = calendarlogic.GetOpeningHours(db, room.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	today := now.Format(utils.DateLayout)
+	nowMinutes := now.Hour()*60 + now.Minute()
+	var slots []Slot
+	for day := from; !day.After(to) && len(slots) < search.Limit; day = day.AddDate(0, 0, 1) {
+		date := day.Format(utils.DateLayout)
+		if date < today {
+			continue
+		}
+		closure, err := calendarlogic.GetClosureDay(db, date)
+		if err != nil {
+			return nil, err
+		}
+		if closure != nil {
+			continue
+		}
+		busy, err := roomlogic.GetBusyIntervals(db, date)
+		if err != nil {
+			return nil, err
+		}
+
+		var daySlots []Slot
+		for _, room := range rooms {
+			for _, window := range OpenWindows(hours[room.ID], utils.IsoWeekday(day)) {
+				window = intersect(window, preferred)
+				if date == today && window.Start < nowMinutes {
+					window.Start = nowMinutes
+				}
+				for _, gap := range FreeGaps(window, busy[room.ID], search.Duration) {
+					// Premier début du trou que l'utilisateur a le droit de réserver, ou tous
+					for start := alignUp(gap.Start, search.Step); start+search.Duration <= gap.End; start += search.Step {
+						slot := Slot{
+							RoomID:    room.ID,
+							RoomName:  room.Name,
+							Capacity:  room.Capacity,
+							Date:      date,
+							StartTime: utils.FormatClock(start),
+							EndTime:   utils.FormatClock(start + search.Duration),
+						}
+						ok, err := bookable(room, slot)
+						if err != nil {
+							return nil, err
+						}
+						if ok {
+							daySlots = append(daySlots, slot)
+							if !search.EveryStart {
+								break
+							}
+						}
+					}
+				}
+			}
+		}
+		sort.SliceStable(daySlots, func(i, j int) bool {
+			if daySlots[i].StartTime != daySlots[j].StartTime {
+				return daySlots[i].StartTime < daySlots[j].StartTime
+			}
+			return daySlots[i].RoomID < daySlots[j].RoomID
+		})
+		slots = append(slots, daySlots...)
+	}
+	if len(slots) > search.Limit {
+		slots = slots[:search.Limit]
+	}
+	return slots, nil
+}
+
+func candidateRooms(db *sql.DB, search Search) ([]models.Room, error) {
+	rooms, err := roomlogic.GetRooms(db)
+	if err != nil {
+		return nil, err
+	}
+	wanted := map[int]bool{}
+	for _, id := range search.RoomIDs {
+		wanted[id] = true
+	}
+	var candidates []models.Room
+	for _, room := range rooms {
+		if !room.Available || room.Capacity < search.MinCapacity || !roomlogic.HasFeatures(room, search.Features) {
+			continue
+		}
+		if len(wanted) > 0 && !wanted[room.ID] {
+			continue
+		}
+		candidates = append(candidates, room)
+	}
+	return candidates, nil
+}
+
+// Plages d'ouverture d'un jour ; une salle sans horaire est ouverte toute la journée
+func OpenWindows(hours []models.OpeningHours, weekday int) []roomlogic.Interval {
+	if len(hours) == 0 {
+		return []roomlogic.Interval{{Start: 0, End: 24 * 60}}
+	}
+	var windows []roomlogic.Interval
+	for _, h := range hours {
+		if h.Weekday != weekday {
+			continue
+		}
+		open, openErr := utils.ParseClock(h.OpenTime)
+		closeAt, closeErr := utils.ParseClock(h.CloseTime)
+		if openErr != nil || closeErr != nil {
+			continue
+		}
+		windows = append(windows, roomlogic.Interval{Start: open, End: closeAt})
+	}
+	return windows
+}
+
+// Indique si l'utilisateur a le droit de réserver le créneau : règles de réservation et
+// périodes de gel, chargées une fois pour toute la recherche
+type bookableCheck func(room models.Room, slot Slot) (bool, error)
+
+func newBookableCheck(db *sql.DB, user *models.User, now time.Time) (bookableCheck, error) {
+	rules, err := rulelogic.GetRules(db)
+	if err != nil {
+		return nil, err
+	}
+	var blackouts []models.Blackout
+	if user.Role != userlogic.RoleAdmin {
+		if blackouts, err = blackoutlogic.GetBlackouts(db); err != nil {
+			return nil, err
+		}
+	}
+	components, err := roomlogic.GetComponents(db)
+	if err != nil {
+		return nil, err
+	}
+	return func(room models.Room, slot Slot) (bool, error) {
+		req := rulelogic.Request{RoomID: room.ID, Role: user.Role, Date: slot.Date, StartTime: slot.StartTime, EndTime: slot.EndTime}
+		violations, err := rulelogic.Evaluate(rules, req, now)
+		if err != nil || len(violations) > 0 {
+			return false, err
+		}
+		related := roomlogic.RelatedRoomIDs(components, room.ID)
+		for _, b := range blackouts {
+			if blackoutlogic.Blocks(b, room, related, slot.Date, slot.StartTime, slot.EndTime) {
+				return false, nil
+			}
+		}
+		return true, nil
+	}, nil
+}
+
+// Trous de la plage, entre les occupations, assez longs pour la durée demandée
+func FreeGaps(window roomlogic.Interval, busy []roomlogic.Interval, duration int) []roomlogic.Interval {
+	sorted := append([]roomlogic.Interval(nil), busy...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Start < sorted[j].Start })
+
+	var gaps []roomlogic.Interval
+	cursor := window.Start
+	for _, b := range sorted {
+		if b.End <= cursor {
+			continue
+		}
+		if b.Start >= window.End {
+			break
+		}
+		if b.Start-cursor >= duration {
+			gaps = append(gaps, roomlogic.Interval{Start: cursor, End: b.Start})
+		}
+		cursor = b.End
+	}
+	if window.End-cursor >= duration {
+		gaps = append(gaps, roomlogic.Interval{Start: cursor, End: window.End})
+	}
+	return gaps
+}
+
+// Premier début possible dans chaque trou de la plage, aligné sur le pas
+func FreeStarts(window roomlogic.Interval, busy []roomlogic.Interval, duration, step int) []int {
+	var starts []int
+	for _, gap := range FreeGaps(window, busy, duration) {
+		if start := alignUp(gap.Start, step); start+duration <= gap.End {
+			starts = append(starts, start)
+		}
+	}
+	return starts
+}
+
+func intersect(a, b roomlogic.Interval) roomlogic.Interval {
+	if b.Start > a.Start {
+		a.Start = b.Start
+	}
+	if b.End < a.End {
+		a.End = b.End
+	}
+	return a
+}
+
+func alignUp(minutes, step int) int {
+	if rest := minutes % step; rest != 0 {
+		return minutes + step - rest
+	}
+	return minutes
+}
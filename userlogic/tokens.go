@@ -0,0 +1,124 @@
+package userlogic
+
+import (
+	"Reserve-Go/models"
+	"Reserve-Go/utils"
+	"database/sql"
+	"log"
+)
+
+const tokenColumns = "token, user_id, created_at, COALESCE(revoked_at, '')"
+
+// Crée un jeton d'API au nom d'un utilisateur
+func CreateToken(db *sql.DB, userID int) (*models.APIToken, error) {
+	if _, err := GetUser(db, userID); err != nil {
+		return nil, err
+	}
+	token, err := utils.NewToken()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := db.Exec("INSERT INTO api_tokens (token, user_id) VALUES (?, ?)", token, userID); err != nil {
+		return nil, err
+	}
+	return getToken(db, token)
+}
+
+func GetTokens(db *sql.DB) ([]models.APIToken, error) {
+	rows, err := db.Query("SELECT " + tokenColumns + " FROM api_tokens ORDER BY created_at, token")
+	if err != nil {
+		return nil, err
+	}
+	defer func(rows *sql.Rows) {
+		rowErr := rows.Close()
+		if rowErr != nil {
+			log.Printf("Erreur: %v", rowErr)
+		}
+	}(rows)
+
+	var tokens []models.APIToken
+	for rows.Next() {
+		var t models.APIToken
+		if err := rows.Scan(&t.Token, &t.UserID, &t.CreatedAt, &t.RevokedAt); err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, t)
+	}
+	return tokens, rows.Err()
+}
+
+// Utilisateur au nom duquel le jeton a été créé ; un jeton inconnu ou révoqué est refusé
+func Authenticate(db *sql.DB, token string) (*models.User, error) {
+	if token == "" {
+		return nil, models.NewError(models.ErrUnauthorized, "jeton d'API manquant")
+	}
+	t, err := getToken(db, token)
+	if err != nil {
+		return nil, err
+	}
+	if t.RevokedAt != "" {
+		return nil, models.NewError(models.ErrUnauthorized, "jeton d'API inconnu ou révoqué")
+	}
+	return GetUser(db, t.UserID)
+}
+
+func getToken(db *sql.DB, token string) (*models.APIToken, error) {
+	var t models.APIToken
+	err := db.QueryRow("SELECT "+tokenColumns+" FROM api_tokens WHERE token = ?", token).
+		Scan(&t.Token, &t.UserID, &t.CreatedAt, &t.RevokedAt)
+	if err == sql.ErrNoRows {
+		return nil, models.NewError(models.ErrUnauthorized, "jeton d'API inconnu ou révoqué")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// Révoque un jeton : les requêtes qui le présentent sont refusées immédiatement
+func RevokeToken(db *sql.DB, token string) error {
+	result, err := db.Exec("UPDATE api_tokens SET revoked_at = NOW() WHERE token = ? AND revoked_at IS NULL", token)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return models.NewError(models.ErrNotFound, "jeton d'API inconnu ou déjà révoqué")
+	}
+	return nil
+}
+
+// Refuse les opérations réservées aux administrateurs
+func CheckAdmin(user *models.User) error {
+	if user.Role != RoleAdmin {
+		return models.NewError(models.ErrForbidden, "opération réservée aux administrateurs")
+	}
+	return nil
+}
+
+// Utilisateur au nom duquel agir : user si userID vaut 0 ou le désigne ; seul un
+// administrateur peut agir au nom d'un autre
+func ActingUser(db *sql.DB, user *models.User, userID int) (*models.User, error) {
+	if userID == 0 || userID == user.ID {
+		return user, nil
+	}
+	if user.Role != RoleAdmin {
+		return nil, models.NewError(models.ErrForbidden, "un utilisateur ne peut agir qu'en son nom")
+	}
+	return GetUser(db, userID)
+}
+
+// Propriétaire des réservations que user peut consulter : un administrateur voit celles
+// de userID (toutes si 0), un utilisateur uniquement les siennes
+func ReadableUserID(user *models.User, userID int) (int, error) {
+	if user.Role == RoleAdmin {
+		return userID, nil
+	}
+	if userID != 0 && userID != user.ID {
+		return 0, models.NewError(models.ErrForbidden, "un utilisateur ne consulte que ses propres réservations")
+	}
+	return user.ID, nil
+}
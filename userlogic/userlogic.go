@@ -0,0 +1,71 @@
+package userlogic
+
+import (
+	"Reserve-Go/models"
+	"database/sql"
+	"log"
+	"strings"
+)
+
+const (
+	RoleAdmin = "admin"
+	RoleUser  = "user"
+)
+
+func GetUser(db *sql.DB, id int) (*models.User, error) {
+	var u models.User
+	err := db.QueryRow("SELECT id, name, role, COALESCE(group_name, '') FROM users WHERE id = ?", id).Scan(&u.ID, &u.Name, &u.Role, &u.Group)
+	if err == sql.ErrNoRows {
+		return nil, models.Errorf(models.ErrNotFound, "aucun utilisateur avec l'ID %d", id)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
+
+func GetUsers(db *sql.DB) ([]models.User, error) {
+	var users []models.User
+	rows, err := db.Query("SELECT id, name, role, COALESCE(group_name, '') FROM users ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	defer func(rows *sql.Rows) {
+		rowErr := rows.Close()
+		if rowErr != nil {
+			log.Printf("Erreur: %v", rowErr)
+		}
+	}(rows)
+
+	for rows.Next() {
+		var u models.User
+		if err := rows.Scan(&u.ID, &u.Name, &u.Role, &u.Group); err != nil {
+			return nil, err
+		}
+		users = append(users, u)
+	}
+	return users, rows.Err()
+}
+
+func InsertUser(db *sql.DB, name, role, group string) (int, error) {
+	if strings.TrimSpace(name) == "" {
+		return 0, models.NewError(models.ErrInvalid, "le nom de l'utilisateur est obligatoire")
+	}
+	switch role {
+	case "":
+		role = RoleUser
+	case RoleUser, RoleAdmin:
+	default:
+		return 0, models.Errorf(models.ErrInvalid, "rôle inconnu : %q", role)
+	}
+	var groupName sql.NullString
+	if group != "" {
+		groupName = sql.NullString{String: group, Valid: true}
+	}
+	result, err := db.Exec("INSERT INTO users (name, role, group_name) VALUES (?, ?, ?)", name, role, groupName)
+	if err != nil {
+		return 0, err
+	}
+	id, err := result.LastInsertId()
+	return int(id), err
+}
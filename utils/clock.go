@@ -0,0 +1,82 @@
+package utils
+
+import (
+	"Reserve-Go/models"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const DateLayout = "2006-01-02"
+
+// Fonction pour convertir une heure "HH:MM" ou "HH:MM:SS" en minutes depuis minuit
+func ParseClock(value string) (int, error) {
+	parts := strings.Split(strings.TrimSpace(value), ":")
+	if len(parts) < 2 || len(parts) > 3 {
+		return 0, models.Errorf(models.ErrInvalid, "heure invalide : %q", value)
+	}
+	hours, err := strconv.Atoi(parts[0])
+	if err != nil || hours < 0 || hours > 24 {
+		return 0, models.Errorf(models.ErrInvalid, "heure invalide : %q", value)
+	}
+	minutes, err := strconv.Atoi(parts[1])
+	if err != nil || minutes < 0 || minutes > 59 {
+		return 0, models.Errorf(models.ErrInvalid, "heure invalide : %q", value)
+	}
+	if hours == 24 && minutes != 0 {
+		return 0, models.Errorf(models.ErrInvalid, "heure invalide : %q", value)
+	}
+	return hours*60 + minutes, nil
+}
+
+// Fonction pour formater des minutes depuis minuit en "HH:MM:SS"
+func FormatClock(minutes int) string {
+	return fmt.Sprintf("%02d:%02d:00", minutes/60, minutes%60)
+}
+
+// Ramène une heure saisie ("9:00", "09:00:00"...) au format "HH:MM:00" stocké en base
+func NormalizeClock(value string) (string, error) {
+	minutes, err := ParseClock(value)
+	if err != nil {
+		return "", err
+	}
+	return FormatClock(minutes), nil
+}
+
+// Fonction pour lire une date au format AAAA-MM-JJ
+func ParseDate(value string) (time.Time, error) {
+	date, err := time.Parse(DateLayout, strings.TrimSpace(value))
+	if err != nil {
+		return time.Time{}, models.Errorf(models.ErrInvalid, "date invalide : %q", value)
+	}
+	return date, nil
+}
+
+// Fonction qui renvoie le jour de la semaine ISO (1 = lundi ... 7 = dimanche)
+func IsoWeekday(date time.Time) int {
+	return (int(date.Weekday())+6)%7 + 1
+}
+
+var weekdayNames = []string{"lundi", "mardi", "mercredi", "jeudi", "vendredi", "samedi", "dimanche"}
+
+// Fonction qui renvoie le nom français d'un jour ISO
+func WeekdayName(weekday int) string {
+	if weekday < 1 || weekday > 7 {
+		return strconv.Itoa(weekday)
+	}
+	return weekdayNames[weekday-1]
+}
+
+const DateTimeLayout = "2006-01-02 15:04:05"
+
+// Fonction pour lire une date et une heure ("AAAA-MM-JJ HH:MM[:SS]"), ou une date seule (minuit)
+func ParseDateTime(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	for _, layout := range []string{DateTimeLayout, "2006-01-02 15:04", DateLayout} {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, models.Errorf(models.ErrInvalid, "date et heure invalides : %q", value)
+}
@@ -1,6 +1,10 @@
 package utils
 
-import "fmt"
+import (
+	"fmt"
+	"io"
+	"os"
+)
 
 const (
 	ColorRed   = "\033[31m"
@@ -9,8 +13,12 @@ const (
 	ColorReset = "\033[0m"
 )
 
+// Sortie des messages colorés ; la CLI non interactive la redirige vers stderr
+// pour garder stdout exploitable par les scripts
+var Output io.Writer = os.Stdout
+
 func ColorLog(color, message string) {
-	fmt.Println(color, message, ColorReset)
+	fmt.Fprintln(Output, color, message, ColorReset)
 }
 
 // Fonction pour colorer le texte
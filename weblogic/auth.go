@@ -0,0 +1,137 @@
+package weblogic
+
+import (
+	"Reserve-Go/apilogic"
+	"Reserve-Go/models"
+	"Reserve-Go/userlogic"
+	"Reserve-Go/utils"
+	"context"
+	"crypto/subtle"
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Cookie de session : le jeton d'API saisi à la connexion, revérifié à chaque requête
+// (un jeton révoqué déconnecte immédiatement) et accepté par les lectures de l'API
+const sessionCookie = apilogic.SessionCookie
+
+// Cookie anti-CSRF, recopié dans un champ caché de chaque formulaire : un autre site ne
+// peut pas le lire, donc pas envoyer de formulaire accepté
+const csrfCookie = "reserve_csrf"
+
+type userKey struct{}
+
+// Exige une session ; sans session valide, renvoie vers la page de connexion
+func (s *server) loggedIn(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		cookie, err := r.Cookie(sessionCookie)
+		if err != nil {
+			redirectToLogin(w, r)
+			return
+		}
+		user, err := userlogic.Authenticate(s.db, cookie.Value)
+		if errors.Is(err, models.ErrUnauthorized) {
+			redirectToLogin(w, r)
+			return
+		}
+		if err != nil {
+			renderError(w, r, "login.html", &page{Title: "Connexion"}, err)
+			return
+		}
+		next(w, r.WithContext(context.WithValue(r.Context(), userKey{}, user)))
+	}
+}
+
+// Comme loggedIn, pour les pages réservées aux administrateurs (403 sinon)
+func (s *server) adminOnly(next http.HandlerFunc) http.HandlerFunc {
+	return s.loggedIn(func(w http.ResponseWriter, r *http.Request) {
+		if err := userlogic.CheckAdmin(currentUser(r)); err != nil {
+			renderError(w, r, "home.html", &page{Title: "Accès refusé"}, err)
+			return
+		}
+		next(w, r)
+	})
+}
+
+// Utilisateur de la session, nil hors des pages protégées par loggedIn
+func currentUser(r *http.Request) *models.User {
+	user, _ := r.Context().Value(userKey{}).(*models.User)
+	return user
+}
+
+func redirectToLogin(w http.ResponseWriter, r *http.Request) {
+	http.Redirect(w, r, "/login?next="+url.QueryEscape(r.URL.RequestURI()), http.StatusSeeOther)
+}
+
+func (s *server) loginForm(w http.ResponseWriter, r *http.Request) {
+	render(w, r, http.StatusOK, "login.html", &page{Title: "Connexion", Form: r.URL.Query()})
+}
+
+// Ouvre une session avec un jeton d'API (reserve tokens create --user ID)
+func (s *server) login(w http.ResponseWriter, r *http.Request) {
+	data := &page{Title: "Connexion"}
+	if err := r.ParseForm(); err != nil {
+		renderError(w, r, "login.html", data, models.NewError(models.ErrInvalid, "formulaire illisible"))
+		return
+	}
+	// Le jeton saisi n'est pas réaffiché
+	data.Form = url.Values{"next": {r.PostForm.Get("next")}}
+
+	token := strings.TrimSpace(r.PostForm.Get("token"))
+	if _, err := userlogic.Authenticate(s.db, token); err != nil {
+		renderError(w, r, "login.html", data, err)
+		return
+	}
+	setCookie(w, r, sessionCookie, token)
+	http.Redirect(w, r, localPath(r.PostForm.Get("next")), http.StatusSeeOther)
+}
+
+func (s *server) logout(w http.ResponseWriter, r *http.Request) {
+	http.SetCookie(w, &http.Cookie{Name: sessionCookie, Path: "/", MaxAge: -1})
+	http.Redirect(w, r, "/login", http.StatusSeeOther)
+}
+
+// Chemin de retour après la connexion, limité au site lui-même
+func localPath(next string) string {
+	if !strings.HasPrefix(next, "/") || strings.HasPrefix(next, "//") || strings.HasPrefix(next, "/\\") {
+		return "/"
+	}
+	return next
+}
+
+func setCookie(w http.ResponseWriter, r *http.Request, name, value string) {
+	http.SetCookie(w, &http.Cookie{Name: name, Value: value, Path: "/", HttpOnly: true,
+		SameSite: http.SameSiteLaxMode, Secure: r.TLS != nil})
+}
+
+// Jeton anti-CSRF du navigateur, créé à sa première visite
+func csrfToken(w http.ResponseWriter, r *http.Request) string {
+	if cookie, err := r.Cookie(csrfCookie); err == nil && cookie.Value != "" {
+		return cookie.Value
+	}
+	token, err := utils.NewToken()
+	if err != nil {
+		log.Printf("Erreur lors de la création du jeton anti-CSRF : %v", err)
+		return ""
+	}
+	setCookie(w, r, csrfCookie, token)
+	return token
+}
+
+// Refuse les formulaires (POST) dont le champ csrf ne reprend pas le cookie anti-CSRF
+func csrfProtected(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			cookie, err := r.Cookie(csrfCookie)
+			if err != nil || cookie.Value == "" || subtle.ConstantTimeCompare([]byte(cookie.Value), []byte(r.PostFormValue("csrf"))) != 1 {
+				renderError(w, r, "login.html", &page{Title: "Formulaire refusé"},
+					models.NewError(models.ErrForbidden, "formulaire expiré ou envoyé depuis un autre site : rechargez la page et recommencez"))
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,43 @@
+package weblogic
+
+import (
+	"Reserve-Go/planninglogic"
+	"Reserve-Go/userlogic"
+	"Reserve-Go/utils"
+	"net/http"
+	"time"
+)
+
+// Grille d'occupation : ?date=AAAA-MM-JJ (aujourd'hui par défaut) et ?view=day pour une seule journée
+func (s *server) planning(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	data := &page{Title: "Planning des salles", Form: query}
+	date := query.Get("date")
+	if date == "" {
+		date = time.Now().Format(utils.DateLayout)
+	}
+	view := planninglogic.WeekView
+	if query.Get("view") == "day" {
+		view = planninglogic.DayView
+	}
+
+	grid, err := planninglogic.BuildGrid(s.db, date, view)
+	if err != nil {
+		renderError(w, r, "planning.html", data, err)
+		return
+	}
+	// Un utilisateur voit l'occupation des salles, mais pas qui a réservé
+	if userID, _ := userlogic.ReadableUserID(currentUser(r), 0); userID != 0 {
+		for _, day := range grid.Days {
+			for _, row := range day.Rows {
+				for i := range row.Blocks {
+					if block := &row.Blocks[i]; block.Reservation != nil && block.Reservation.UserID != userID {
+						block.UserName = "Réservé"
+					}
+				}
+			}
+		}
+	}
+	data.Grid = grid
+	render(w, r, http.StatusOK, "planning.html", data)
+}
@@ -0,0 +1,195 @@
+package weblogic
+
+import (
+	"Reserve-Go/models"
+	"Reserve-Go/reservationlogic"
+	"Reserve-Go/userlogic"
+	"Reserve-Go/utils"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Toutes les réservations, ou celles d'une salle (?room=ID) ou d'une date (?date=AAAA-MM-JJ) ;
+// un utilisateur ne voit que les siennes
+func (s *server) listReservations(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	data := &page{Title: "Réservations", Form: query}
+	switch {
+	case query.Get("booked") != "":
+		data.Message = "Réservation " + query.Get("booked") + " créée avec succès."
+	case query.Get("group") != "":
+		data.Message = "Réservation multi-salles créée avec succès (groupe " + query.Get("group") + ")."
+	case query.Get("series") != "":
+		data.Message = "Série de réservations créée avec succès (série " + query.Get("series") + ")."
+	case query.Get("cancelled") != "":
+		data.Message = query.Get("cancelled") + " réservation(s) annulée(s)."
+	}
+
+	var reservations []models.Reservation
+	var err error
+	switch {
+	case query.Get("series") != "":
+		data.Title = "Réservations de la série " + query.Get("series")
+		var occurrences []reservationlogic.Occurrence
+		occurrences, err = reservationlogic.GetSeriesOccurrences(s.db, query.Get("series"))
+		for _, o := range occurrences {
+			reservations = append(reservations, o.Reservation)
+		}
+	case strings.TrimSpace(query.Get("room")) != "":
+		var roomID int
+		if roomID, err = formInt(query, "room", "Salle"); err == nil {
+			data.Title = fmt.Sprintf("Réservations de la salle %d", roomID)
+			reservations, err = reservationlogic.GetReservationsByRoom(s.db, roomID)
+		}
+	case strings.TrimSpace(query.Get("date")) != "":
+		if _, err = utils.ParseDate(query.Get("date")); err == nil {
+			data.Title = "Réservations du " + query.Get("date")
+			reservations, err = reservationlogic.GetReservationsByDate(s.db, query.Get("date"))
+		}
+	default:
+		reservations, err = reservationlogic.GetAllReservations(s.db)
+	}
+	if err != nil {
+		renderError(w, r, "reservations.html", data, err)
+		return
+	}
+	userID, err := userlogic.ReadableUserID(currentUser(r), 0)
+	if err != nil {
+		renderError(w, r, "reservations.html", data, err)
+		return
+	}
+	for _, reservation := range reservations {
+		if userID == 0 || reservation.UserID == userID {
+			data.Reservations = append(data.Reservations, reservation)
+		}
+	}
+	render(w, r, http.StatusOK, "reservations.html", data)
+}
+
+func (s *server) newReservation(w http.ResponseWriter, r *http.Request) {
+	// Les liens des alternatives préremplissent le formulaire
+	render(w, r, http.StatusOK, "reservation_form.html", &page{Title: "Créer une réservation", Form: r.URL.Query()})
+}
+
+func (s *server) createReservation(w http.ResponseWriter, r *http.Request) {
+	data := &page{Title: "Créer une réservation"}
+	if err := r.ParseForm(); err != nil {
+		renderError(w, r, "reservation_form.html", data, models.NewError(models.ErrInvalid, "formulaire illisible"))
+		return
+	}
+	form := r.PostForm
+	data.Form = form
+
+	if err := required(form, [2]string{"rooms", "Salle(s)"}, [2]string{"date", "Date"}, [2]string{"start", "Début"}, [2]string{"end", "Fin"}); err != nil {
+		renderError(w, r, "reservation_form.html", data, err)
+		return
+	}
+	roomIDs, err := reservationlogic.ParseRoomIDs(form.Get("rooms"))
+	if err != nil {
+		renderError(w, r, "reservation_form.html", data, err)
+		return
+	}
+	date := strings.TrimSpace(form.Get("date"))
+	startTime, err := utils.NormalizeClock(form.Get("start"))
+	if err != nil {
+		renderError(w, r, "reservation_form.html", data, err)
+		return
+	}
+	endTime, err := utils.NormalizeClock(form.Get("end"))
+	if err != nil {
+		renderError(w, r, "reservation_form.html", data, err)
+		return
+	}
+	weeks, err := formOptionalInt(form, "weeks", "Nombre de semaines")
+	if err != nil {
+		renderError(w, r, "reservation_form.html", data, err)
+		return
+	}
+	// Un administrateur peut réserver au nom d'un autre utilisateur (champ user)
+	onBehalf, err := formOptionalInt(form, "user", "Au nom de l'utilisateur")
+	if err != nil {
+		renderError(w, r, "reservation_form.html", data, err)
+		return
+	}
+	userID := 0
+	if onBehalf != nil {
+		userID = *onBehalf
+	}
+	user, err := userlogic.ActingUser(s.db, currentUser(r), userID)
+	if err != nil {
+		renderError(w, r, "reservation_form.html", data, err)
+		return
+	}
+
+	if weeks != nil && *weeks > 1 {
+		if len(roomIDs) > 1 {
+			renderError(w, r, "reservation_form.html", data, models.NewError(models.ErrInvalid, "une série ne porte que sur une salle"))
+			return
+		}
+		series := models.Series{RoomID: roomIDs[0], StartDate: date, StartTime: startTime, EndTime: endTime,
+			Frequency: models.FrequencyWeekly, Interval: 1, Count: *weeks}
+		seriesID, err := reservationlogic.BookSeries(s.db, user, series)
+		if err != nil {
+			renderError(w, r, "reservation_form.html", data, err)
+			return
+		}
+		http.Redirect(w, r, "/reservations?series="+url.QueryEscape(seriesID), http.StatusSeeOther)
+		return
+	}
+
+	if len(roomIDs) > 1 {
+		groupID, err := reservationlogic.BookGroup(s.db, user, roomIDs, date, startTime, endTime)
+		if err != nil {
+			renderError(w, r, "reservation_form.html", data, err)
+			return
+		}
+		http.Redirect(w, r, "/reservations?group="+url.QueryEscape(groupID), http.StatusSeeOther)
+		return
+	}
+
+	id, err := reservationlogic.BookReservation(s.db, user, roomIDs[0], date, startTime, endTime)
+	if err != nil {
+		if errors.Is(err, reservationlogic.ErrRoomUnavailable) {
+			if data.Alternatives, err = reservationlogic.SuggestAlternatives(s.db, roomIDs[0], date, startTime, endTime); err != nil {
+				log.Printf("Erreur lors de la recherche d'alternatives : %v", err)
+			}
+			err = reservationlogic.ErrRoomUnavailable
+		}
+		renderError(w, r, "reservation_form.html", data, err)
+		return
+	}
+	http.Redirect(w, r, fmt.Sprintf("/reservations?booked=%d", id), http.StatusSeeOther)
+}
+
+func (s *server) cancelForm(w http.ResponseWriter, r *http.Request) {
+	render(w, r, http.StatusOK, "cancel.html", &page{Title: "Annuler une réservation", Form: r.URL.Query()})
+}
+
+func (s *server) cancelReservation(w http.ResponseWriter, r *http.Request) {
+	data := &page{Title: "Annuler une réservation"}
+	if err := r.ParseForm(); err != nil {
+		renderError(w, r, "cancel.html", data, models.NewError(models.ErrInvalid, "formulaire illisible"))
+		return
+	}
+	data.Form = r.PostForm
+
+	id, err := formInt(r.PostForm, "id", "Identifiant de la réservation")
+	if err != nil {
+		renderError(w, r, "cancel.html", data, err)
+		return
+	}
+	if err := reservationlogic.CheckOwner(s.db, currentUser(r), id); err != nil {
+		renderError(w, r, "cancel.html", data, err)
+		return
+	}
+	count, err := reservationlogic.CancelReservation(s.db, id, r.PostForm.Get("group") == "on")
+	if err != nil {
+		renderError(w, r, "cancel.html", data, err)
+		return
+	}
+	http.Redirect(w, r, fmt.Sprintf("/reservations?cancelled=%d", count), http.StatusSeeOther)
+}
@@ -0,0 +1,178 @@
+package weblogic
+
+import (
+	"Reserve-Go/models"
+	"Reserve-Go/roomlogic"
+	"Reserve-Go/utils"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+func (s *server) listRooms(w http.ResponseWriter, r *http.Request) {
+	data := &page{Title: "Salles"}
+	if id := r.URL.Query().Get("saved"); id != "" {
+		data.Message = "La salle " + id + " a été enregistrée."
+	}
+	rooms, err := roomlogic.GetRooms(s.db)
+	if err != nil {
+		renderError(w, r, "rooms.html", data, err)
+		return
+	}
+	data.Rooms = rooms
+	render(w, r, http.StatusOK, "rooms.html", data)
+}
+
+func (s *server) newRoom(w http.ResponseWriter, r *http.Request) {
+	render(w, r, http.StatusOK, "room_form.html", &page{Title: "Créer une salle", Form: url.Values{}})
+}
+
+func (s *server) createRoom(w http.ResponseWriter, r *http.Request) {
+	data := &page{Title: "Créer une salle"}
+	if err := r.ParseForm(); err != nil {
+		renderError(w, r, "room_form.html", data, models.NewError(models.ErrInvalid, "formulaire illisible"))
+		return
+	}
+	data.Form = r.PostForm
+
+	room, err := roomFromForm(r.PostForm)
+	if err != nil {
+		renderError(w, r, "room_form.html", data, err)
+		return
+	}
+	id, err := roomlogic.InsertRoom(s.db, room)
+	if err != nil {
+		renderError(w, r, "room_form.html", data, err)
+		return
+	}
+	http.Redirect(w, r, fmt.Sprintf("/rooms?saved=%d", id), http.StatusSeeOther)
+}
+
+func roomFromForm(form url.Values) (models.Room, error) {
+	var room models.Room
+	if err := required(form, [2]string{"name", "Nom"}); err != nil {
+		return room, err
+	}
+	capacity, err := formInt(form, "capacity", "Capacité")
+	if err != nil {
+		return room, err
+	}
+	room = models.Room{
+		Name:     form.Get("name"),
+		Capacity: capacity,
+		Features: roomlogic.ParseFeatures(form.Get("features")),
+		Building: form.Get("building"),
+	}
+	if before, err := formOptionalInt(form, "buffer_before", "Préparation"); err != nil {
+		return room, err
+	} else if before != nil {
+		room.BufferBefore = *before
+	}
+	if after, err := formOptionalInt(form, "buffer_after", "Nettoyage"); err != nil {
+		return room, err
+	} else if after != nil {
+		room.BufferAfter = *after
+	}
+	return room, nil
+}
+
+func (s *server) editRoom(w http.ResponseWriter, r *http.Request) {
+	data := &page{Title: "Modifier une salle"}
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		renderError(w, r, "room_form.html", data, models.NewError(models.ErrNotFound, "salle inconnue"))
+		return
+	}
+	room, err := roomlogic.GetRoom(s.db, id)
+	if err != nil {
+		renderError(w, r, "room_form.html", data, err)
+		return
+	}
+	data.Room = room
+	data.Form = url.Values{
+		"name":          {room.Name},
+		"capacity":      {strconv.Itoa(room.Capacity)},
+		"buffer_before": {strconv.Itoa(room.BufferBefore)},
+		"buffer_after":  {strconv.Itoa(room.BufferAfter)},
+		"features":      {roomlogic.JoinFeatures(room.Features)},
+		"building":      {room.Building},
+	}
+	render(w, r, http.StatusOK, "room_form.html", data)
+}
+
+func (s *server) updateRoom(w http.ResponseWriter, r *http.Request) {
+	data := &page{Title: "Modifier une salle"}
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		renderError(w, r, "room_form.html", data, models.NewError(models.ErrNotFound, "salle inconnue"))
+		return
+	}
+	if data.Room, err = roomlogic.GetRoom(s.db, id); err != nil {
+		renderError(w, r, "room_form.html", data, err)
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		renderError(w, r, "room_form.html", data, models.NewError(models.ErrInvalid, "formulaire illisible"))
+		return
+	}
+	data.Form = r.PostForm
+
+	// Le formulaire est prérempli : tous les champs sont renvoyés et remplacent les valeurs actuelles
+	room, err := roomFromForm(r.PostForm)
+	if err != nil {
+		renderError(w, r, "room_form.html", data, err)
+		return
+	}
+	update := roomlogic.RoomUpdate{
+		Name:         &room.Name,
+		Capacity:     &room.Capacity,
+		BufferBefore: &room.BufferBefore,
+		BufferAfter:  &room.BufferAfter,
+		Features:     &room.Features,
+		Building:     &room.Building,
+	}
+	if _, err := roomlogic.UpdateRoom(s.db, id, update); err != nil {
+		renderError(w, r, "room_form.html", data, err)
+		return
+	}
+	http.Redirect(w, r, fmt.Sprintf("/rooms?saved=%d", id), http.StatusSeeOther)
+}
+
+// Salles libres sur un créneau ; le formulaire et les résultats sont sur la même page
+func (s *server) availableRooms(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	data := &page{Title: "Salles disponibles à un temps donné", Form: query}
+	if strings.TrimSpace(query.Get("date")) == "" && query.Get("start") == "" && query.Get("end") == "" {
+		render(w, r, http.StatusOK, "available.html", data)
+		return
+	}
+
+	if err := required(query, [2]string{"date", "Date"}, [2]string{"start", "Début"}, [2]string{"end", "Fin"}); err != nil {
+		renderError(w, r, "available.html", data, err)
+		return
+	}
+	if _, err := utils.ParseDate(query.Get("date")); err != nil {
+		renderError(w, r, "available.html", data, err)
+		return
+	}
+	startTime, err := utils.NormalizeClock(query.Get("start"))
+	if err != nil {
+		renderError(w, r, "available.html", data, err)
+		return
+	}
+	endTime, err := utils.NormalizeClock(query.Get("end"))
+	if err != nil {
+		renderError(w, r, "available.html", data, err)
+		return
+	}
+	rooms, err := roomlogic.GetAvailableRooms(s.db, query.Get("date"), startTime, endTime)
+	if err != nil {
+		renderError(w, r, "available.html", data, err)
+		return
+	}
+	data.Rooms = rooms
+	data.Searched = true
+	render(w, r, http.StatusOK, "available.html", data)
+}
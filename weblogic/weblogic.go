@@ -0,0 +1,179 @@
+package weblogic
+
+import (
+	"Reserve-Go/apilogic"
+	"Reserve-Go/models"
+	"Reserve-Go/planninglogic"
+	"Reserve-Go/reservationlogic"
+	"Reserve-Go/userlogic"
+	"bytes"
+	"database/sql"
+	"embed"
+	"html/template"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+//go:embed templates/*.html
+var templateFiles embed.FS
+
+// Une page par fichier, chacune rendue dans templates/layout.html
+var pages = map[string]*template.Template{}
+
+var funcs = template.FuncMap{
+	"join": strings.Join,
+	// "09:30:00" -> "09:30"
+	"clock": func(value string) string { return strings.TrimSuffix(value, ":00") },
+	// Libellé d'en-tête d'une case du planning : l'heure pleine uniquement
+	"hour": func(value string) string {
+		if strings.HasSuffix(value, ":00:00") {
+			return value[:2] + "h"
+		}
+		return ""
+	},
+	"status": planninglogic.StatusLabel,
+}
+
+func init() {
+	layout := template.Must(template.New("layout.html").Funcs(funcs).ParseFS(templateFiles, "templates/layout.html"))
+	entries, err := templateFiles.ReadDir("templates")
+	if err != nil {
+		panic(err)
+	}
+	for _, entry := range entries {
+		if entry.Name() == "layout.html" {
+			continue
+		}
+		pages[entry.Name()] = template.Must(template.Must(layout.Clone()).ParseFS(templateFiles, "templates/"+entry.Name()))
+	}
+}
+
+// Données communes à toutes les pages ; seuls les champs utiles à la page sont remplis
+type page struct {
+	// Utilisateur connecté (nil sur la page de connexion) et jeton anti-CSRF des formulaires
+	User    *models.User
+	CSRF    string
+	Title   string
+	Error   string
+	Message string
+	// Valeurs saisies, réaffichées lorsque le formulaire est refusé
+	Form         url.Values
+	Room         *models.Room
+	Rooms        []models.Room
+	Reservations []models.Reservation
+	Alternatives *reservationlogic.Alternatives
+	Grid         *planninglogic.Grid
+	// Vrai lorsqu'une recherche a été lancée (pour distinguer « aucun résultat » du formulaire vide)
+	Searched bool
+}
+
+// Les liens et champs d'administration ne sont affichés qu'aux administrateurs
+func (p *page) Admin() bool {
+	return p.User != nil && p.User.Role == userlogic.RoleAdmin
+}
+
+type server struct {
+	db *sql.DB
+}
+
+// Interface web et API JSON (/api/...) servies ensemble. Les pages exigent une session
+// ouverte avec un jeton d'API ; la gestion des salles est réservée aux administrateurs.
+func NewHandler(db *sql.DB) http.Handler {
+	s := &server{db: db}
+	web := http.NewServeMux()
+	web.HandleFunc("GET /login", s.loginForm)
+	web.HandleFunc("POST /login", s.login)
+	web.HandleFunc("POST /logout", s.logout)
+
+	web.HandleFunc("GET /{$}", s.loggedIn(s.home))
+	web.HandleFunc("GET /rooms", s.loggedIn(s.listRooms))
+	web.HandleFunc("GET /rooms/new", s.adminOnly(s.newRoom))
+	web.HandleFunc("POST /rooms/new", s.adminOnly(s.createRoom))
+	web.HandleFunc("GET /rooms/{id}/edit", s.adminOnly(s.editRoom))
+	web.HandleFunc("POST /rooms/{id}/edit", s.adminOnly(s.updateRoom))
+	web.HandleFunc("GET /rooms/available", s.loggedIn(s.availableRooms))
+
+	web.HandleFunc("GET /reservations", s.loggedIn(s.listReservations))
+	web.HandleFunc("GET /reservations/new", s.loggedIn(s.newReservation))
+	web.HandleFunc("POST /reservations/new", s.loggedIn(s.createReservation))
+	web.HandleFunc("GET /reservations/cancel", s.loggedIn(s.cancelForm))
+	web.HandleFunc("POST /reservations/cancel", s.loggedIn(s.cancelReservation))
+
+	web.HandleFunc("GET /planning", s.loggedIn(s.planning))
+
+	mux := http.NewServeMux()
+	mux.Handle("/api/", apilogic.NewHandler(db))
+	mux.Handle("/", csrfProtected(web))
+	return mux
+}
+
+func (s *server) home(w http.ResponseWriter, r *http.Request) {
+	render(w, r, http.StatusOK, "home.html", &page{Title: "Service de réservation en ligne"})
+}
+
+// Rend une page ; le rendu est fait en mémoire pour pouvoir encore signaler une erreur
+func render(w http.ResponseWriter, r *http.Request, status int, name string, data *page) {
+	data.User = currentUser(r)
+	data.CSRF = csrfToken(w, r)
+	var buf bytes.Buffer
+	if err := pages[name].ExecuteTemplate(&buf, "layout.html", data); err != nil {
+		log.Printf("Erreur lors du rendu de %s : %v", name, err)
+		http.Error(w, "erreur interne du serveur", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	if _, err := buf.WriteTo(w); err != nil {
+		log.Printf("Erreur lors de l'envoi de la page : %v", err)
+	}
+}
+
+// Réaffiche la page avec le message de l'erreur et le code HTTP correspondant
+func renderError(w http.ResponseWriter, r *http.Request, name string, data *page, err error) {
+	status := apilogic.StatusCode(err)
+	if status == http.StatusInternalServerError {
+		log.Printf("Erreur web : %v", err)
+		data.Error = "Une erreur interne est survenue, veuillez réessayer."
+	} else {
+		data.Error = err.Error()
+	}
+	render(w, r, status, name, data)
+}
+
+// Lit un champ entier obligatoire du formulaire
+func formInt(form url.Values, name, label string) (int, error) {
+	value := strings.TrimSpace(form.Get(name))
+	if value == "" {
+		return 0, models.Errorf(models.ErrInvalid, "le champ « %s » est obligatoire", label)
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, models.Errorf(models.ErrInvalid, "le champ « %s » doit être un nombre entier", label)
+	}
+	return n, nil
+}
+
+// Lit un champ entier facultatif : nil s'il est vide
+func formOptionalInt(form url.Values, name, label string) (*int, error) {
+	if strings.TrimSpace(form.Get(name)) == "" {
+		return nil, nil
+	}
+	n, err := formInt(form, name, label)
+	if err != nil {
+		return nil, err
+	}
+	return &n, nil
+}
+
+// Vérifie que les champs obligatoires, donnés par paires {nom, libellé}, sont remplis
+func required(form url.Values, fields ...[2]string) error {
+	for _, field := range fields {
+		if strings.TrimSpace(form.Get(field[0])) == "" {
+			return models.Errorf(models.ErrInvalid, "le champ « %s » est obligatoire", field[1])
+		}
+	}
+	return nil
+}